@@ -0,0 +1,239 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package cdr parses the CDR formats go-cdr loads from any io.Reader, without
+// the files, the database or the configuration the parser package needs. The
+// metadata the parser package takes from the name of a file is passed in
+// explicitly, and records are returned one at a time along with the rows that
+// could not be parsed. Numbers are only normalized and calls classified with
+// the dial plan passed in the metadata.
+//
+//	records := cdr.NewCucmCdrParser().Parse(reader, cdr.Metadata{Source: "cdrs", Cluster: "StandAloneCluster"})
+//	for records.Next() {
+//		if rowErr := records.RowError(); rowErr != nil {
+//			// Handle the row that was rejected
+//			continue
+//		}
+//		cdr := records.Record()
+//	}
+//	if err := records.Err(); err != nil {
+//		// The input could not be read to its end
+//	}
+package cdr
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ziondials/go-cdr/dialplan"
+	"github.com/ziondials/go-cdr/models"
+)
+
+// Metadata describes where records come from. Fields that are empty or zero
+// are left out of the records.
+type Metadata struct {
+	Source    string    // Name of the input in errors and logs, e.g. its filename
+	Cluster   string    // CUCM cluster ID
+	Node      string    // CUCM node ID
+	Hostname  string    // CUBE gateway hostname
+	Filename  string    // Filename stored with CUBE and Oracle records
+	Timestamp time.Time // Time the input was written
+	Sequence  *int64    // CUCM file sequence number
+
+	// DialPlan normalizes the numbers of CUCM and CUBE records to E.164 and
+	// classifies the direction of their calls. Those fields are left empty if
+	// it is nil.
+	DialPlan *dialplan.Plan
+}
+
+func (m Metadata) source() string {
+	if m.Source == "" {
+		return "input"
+	}
+	return m.Source
+}
+
+// Parser parses the records of one format, of type T, from a reader.
+type Parser[T any] interface {
+	Parse(reader io.Reader, meta Metadata) *Records[T]
+}
+
+// Records iterates over the records parsed from a reader. Each call to Next
+// reads the next row, which is either a record or a row that could not be
+// parsed. Records are read as the iteration goes, so the whole input is never
+// held in memory.
+type Records[T any] struct {
+	next     func() (T, *RowError, error)
+	record   T
+	rowErr   *RowError
+	err      error
+	done     bool
+	warnings []error
+}
+
+// Next advances to the next record or rejected row. It returns false at the end
+// of the input or when the input cannot be read any further, see Err.
+func (r *Records[T]) Next() bool {
+	var zero T
+	r.record, r.rowErr = zero, nil
+	if r.done {
+		return false
+	}
+
+	record, rowErr, err := r.next()
+	if err != nil {
+		r.done = true
+		if err != io.EOF {
+			r.err = err
+		}
+		return false
+	}
+
+	r.record, r.rowErr = record, rowErr
+	return true
+}
+
+// Record returns the current record, or the zero value of T if the current row
+// was rejected.
+func (r *Records[T]) Record() T {
+	return r.record
+}
+
+// RowError returns the error of the current row, or nil if it was parsed.
+func (r *Records[T]) RowError() *RowError {
+	return r.rowErr
+}
+
+// Err returns the error that ended the iteration, or nil if the input was read
+// to its end.
+func (r *Records[T]) Err() error {
+	return r.err
+}
+
+// Warnings returns the problems found in the input so far that did not reject
+// any row, such as an *UnknownColumnError for each column of a CUCM header row
+// that is not read, or a *SkippedRecordsError counting the Oracle Start and
+// Interim records.
+func (r *Records[T]) Warnings() []error {
+	return r.warnings
+}
+
+// RowError is a row that could not be parsed. Record holds the fields exactly
+// as they were read, or is nil if the row was not valid CSV. Err is a
+// *csv.ParseError, a *FieldCountError, a *FieldError or the error of parsing
+// the record.
+type RowError struct {
+	Line   int
+	Record []string
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// FieldCountError is a row that does not have the number of fields of its
+// format.
+type FieldCountError struct {
+	Found    int
+	Expected int
+}
+
+func (e *FieldCountError) Error() string {
+	return fmt.Sprintf("Found %d fields instead of %d", e.Found, e.Expected)
+}
+
+// FieldError is a field of a row whose value cannot be converted to the type of
+// its column. Column is the name of the field in the raw record, e.g. Duration.
+type FieldError struct {
+	Row    int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("Error parsing %s %q: %s", e.Column, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// SkippedRecordsError counts the records of an input that are not read, such
+// as the Start and Interim records of Oracle CDR files.
+type SkippedRecordsError struct {
+	Source  string
+	Records string
+	Count   int
+}
+
+func (e *SkippedRecordsError) Error() string {
+	return fmt.Sprintf("Skipped %d %s records in %s", e.Count, e.Records, e.Source)
+}
+
+// newRowError returns the RowError of a record that could not be parsed. A
+// field that could not be converted is returned as a *FieldError.
+func newRowError(line int, record []string, err error) *RowError {
+	var conversionErr *models.ConversionError
+	if errors.As(err, &conversionErr) {
+		err = &FieldError{Row: line, Column: conversionErr.Field, Value: conversionErr.Value, Err: conversionErr.Err}
+	}
+	return &RowError{Line: line, Record: record, Err: err}
+}
+
+// readCSV reads the next record from reader. A record that is not valid CSV is
+// returned as a RowError, while errors reading the input end the iteration.
+func readCSV(reader *csv.Reader) ([]string, *RowError, error) {
+	record, err := reader.Read()
+	if err == nil || err == io.EOF {
+		return record, nil, err
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, &RowError{Line: parseErr.StartLine, Record: record, Err: err}, nil
+	}
+	return nil, nil, err
+}
+
+// recordLine returns the line the record returned by the last call to
+// reader.Read starts on.
+func recordLine(reader *csv.Reader) int {
+	line, _ := reader.FieldPos(0)
+	return line
+}
+
+func stringOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func unixOrNil(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+	unix := t.Unix()
+	return &unix
+}
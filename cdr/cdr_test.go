@@ -0,0 +1,164 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cdr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ziondials/go-cdr/models"
+)
+
+// A field that cannot be converted rejects the row with a *FieldError rather
+// than being stored as NULL.
+func TestParsersRejectMalformedNumbers(t *testing.T) {
+
+	cube := make([]string, cubeFieldCount)
+	cube[1] = "1001"
+	cube[16] = "12x"
+	oracle := make([]string, models.OracleStopRecordFieldCount)
+	oracle[0] = "2"
+	oracle[10] = "sixty"
+
+	tests := []struct {
+		name  string
+		parse func(input string) (int, *RowError)
+		input string
+		field string
+		value string
+	}{
+		{
+			name: "CUCM CDR",
+			parse: func(input string) (int, *RowError) {
+				return collect(NewCucmCdrParser().Parse(strings.NewReader(input), Metadata{}))
+			},
+			input: "pkid,duration\npkid-1,6O\n",
+			field: "Duration",
+			value: "6O",
+		},
+		{
+			name: "CUCM CMR",
+			parse: func(input string) (int, *RowError) {
+				return collect(NewCucmCmrParser().Parse(strings.NewReader(input), Metadata{}))
+			},
+			input: "pkid,numberPacketsLost\npkid-1,1.5\n",
+			field: "Numberpacketslost",
+			value: "1.5",
+		},
+		{
+			name: "CUBE",
+			parse: func(input string) (int, *RowError) {
+				return collect(NewCubeCdrParser().Parse(strings.NewReader(input), Metadata{}))
+			},
+			input: strings.Join(cube, ",") + "\n",
+			field: "PaksOut",
+			value: "12x",
+		},
+		{
+			name: "Oracle",
+			parse: func(input string) (int, *RowError) {
+				return collect(NewOracleCdrParser().Parse(strings.NewReader(input), Metadata{}))
+			},
+			input: strings.Join(oracle, ",") + "\n",
+			field: "Accountingsessiontime",
+			value: "sixty",
+		},
+		{
+			name: "CMS",
+			parse: func(input string) (int, *RowError) {
+				return collect(NewCmsRecordParser().Parse(strings.NewReader(input), Metadata{}))
+			},
+			input: `<records session="s-1"><record type="callEnd" time="2023-01-01T00:00:00Z"><call id="c-1"><durationSeconds>ten</durationSeconds></call></record></records>`,
+			field: "DurationSeconds",
+			value: "ten",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			parsed, rowErr := tt.parse(tt.input)
+			if parsed != 0 {
+				t.Errorf("got %d records, want 0", parsed)
+			}
+			if rowErr == nil {
+				t.Fatal("got no row error")
+			}
+			var fieldErr *FieldError
+			if !errors.As(rowErr, &fieldErr) {
+				t.Fatalf("got %T, want *FieldError", rowErr.Err)
+			}
+			if fieldErr.Row != rowErr.Line {
+				t.Errorf("got row %d, want %d", fieldErr.Row, rowErr.Line)
+			}
+			if fieldErr.Column != tt.field {
+				t.Errorf("got column %s, want %s", fieldErr.Column, tt.field)
+			}
+			if fieldErr.Value != tt.value {
+				t.Errorf("got value %q, want %q", fieldErr.Value, tt.value)
+			}
+			if fieldErr.Err == nil {
+				t.Error("got no conversion error")
+			}
+		})
+	}
+}
+
+// Oracle Start and Interim records are skipped and counted by a single warning.
+func TestOracleSkippedRecordsWarning(t *testing.T) {
+
+	stop := make([]string, models.OracleStopRecordFieldCount)
+	stop[0] = "2"
+	input := "1,10.0.0.1,5060\n3,10.0.0.1\n" + strings.Join(stop, ",") + "\n1,10.0.0.1,5060\n"
+
+	records := NewOracleCdrParser().Parse(strings.NewReader(input), Metadata{Source: "oracle.csv"})
+	parsed, rowErr := collect(records)
+	if rowErr != nil {
+		t.Fatalf("got row error %s", rowErr)
+	}
+	if parsed != 1 {
+		t.Errorf("got %d records, want 1", parsed)
+	}
+
+	warnings := records.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings, want 1", len(warnings))
+	}
+	var skipped *SkippedRecordsError
+	if !errors.As(warnings[0], &skipped) {
+		t.Fatalf("got %T, want *SkippedRecordsError", warnings[0])
+	}
+	if skipped.Count != 3 || skipped.Source != "oracle.csv" {
+		t.Errorf("got %d skipped records in %s, want 3 in oracle.csv", skipped.Count, skipped.Source)
+	}
+}
+
+// collect returns the number of records parsed and the first row error.
+func collect[T any](records *Records[T]) (int, *RowError) {
+	parsed := 0
+	var rowErr *RowError
+	for records.Next() {
+		if records.RowError() != nil {
+			if rowErr == nil {
+				rowErr = records.RowError()
+			}
+			continue
+		}
+		parsed++
+	}
+	return parsed, rowErr
+}
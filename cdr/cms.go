@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cdr
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+
+	"github.com/ziondials/go-cdr/models"
+)
+
+// CmsRecord is a record posted by Cisco Meeting Server, which is either a call
+// or a call leg.
+type CmsRecord struct {
+	Call    *models.CmsCall
+	CallLeg *models.CmsCallLeg
+}
+
+// CmsRecordParser parses the <records> documents that Cisco Meeting Server
+// posts to its CDR receivers.
+type CmsRecordParser struct{}
+
+var _ Parser[*CmsRecord] = (*CmsRecordParser)(nil)
+
+func NewCmsRecordParser() *CmsRecordParser {
+	return &CmsRecordParser{}
+}
+
+// Parse returns the records read from reader. Records that cannot be parsed
+// are rejected with the line they start on, while a document that is not
+// well-formed ends the iteration.
+func (p *CmsRecordParser) Parse(reader io.Reader, meta Metadata) *Records[*CmsRecord] {
+
+	decoder := xml.NewDecoder(reader)
+	var session *string
+
+	next := func() (*CmsRecord, *RowError, error) {
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			element, ok := token.(xml.StartElement)
+			if !ok {
+				continue
+			}
+
+			switch element.Name.Local {
+			case "records":
+				for _, attr := range element.Attr {
+					if attr.Name.Local == "session" {
+						value := attr.Value
+						session = &value
+					}
+				}
+
+			case "record":
+				line, _ := decoder.InputPos()
+				var raw models.RawCmsRecord
+				if err := decoder.DecodeElement(&raw, &element); err != nil {
+					return nil, nil, err
+				}
+				record, err := parseCmsRecord(&raw, session)
+				if err != nil {
+					return nil, newRowError(line, cmsRecordFields(&raw), err), nil
+				}
+				return record, nil, nil
+			}
+		}
+	}
+
+	return &Records[*CmsRecord]{next: next}
+}
+
+func parseCmsRecord(raw *models.RawCmsRecord, session *string) (*CmsRecord, error) {
+
+	if raw.Type == nil {
+		return nil, errors.New("record has no type")
+	}
+
+	switch *raw.Type {
+	case models.CmsRecordTypeCallStart, models.CmsRecordTypeCallEnd:
+		if raw.Call == nil {
+			return nil, errors.New(*raw.Type + " record has no call")
+		}
+		call, err := raw.Call.Parse(raw, session)
+		if err != nil {
+			return nil, err
+		}
+		return &CmsRecord{Call: call}, nil
+
+	case models.CmsRecordTypeCallLegStart, models.CmsRecordTypeCallLegUpdate, models.CmsRecordTypeCallLegEnd:
+		if raw.CallLeg == nil {
+			return nil, errors.New(*raw.Type + " record has no callLeg")
+		}
+		leg, err := raw.CallLeg.Parse(raw, session)
+		if err != nil {
+			return nil, err
+		}
+		return &CmsRecord{CallLeg: leg}, nil
+
+	default:
+		return nil, errors.New("unknown record type " + *raw.Type)
+	}
+}
+
+// cmsRecordFields identifies a rejected record.
+func cmsRecordFields(raw *models.RawCmsRecord) []string {
+	fields := []string{}
+	for _, value := range []*string{raw.Type, raw.Time, raw.RecordIndex} {
+		if value != nil {
+			fields = append(fields, *value)
+		} else {
+			fields = append(fields, "")
+		}
+	}
+	return fields
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cdr
+
+import (
+	"encoding/csv"
+	"io"
+	"reflect"
+
+	"github.com/ziondials/go-cdr/models"
+)
+
+// CucmCdrParser parses the CDR files of CUCM.
+type CucmCdrParser struct{}
+
+// CucmCmrParser parses the CMR files of CUCM.
+type CucmCmrParser struct{}
+
+var (
+	_ Parser[*models.CucmCdr] = (*CucmCdrParser)(nil)
+	_ Parser[*models.CucmCmr] = (*CucmCmrParser)(nil)
+)
+
+func NewCucmCdrParser() *CucmCdrParser {
+	return &CucmCdrParser{}
+}
+
+func NewCucmCmrParser() *CucmCmrParser {
+	return &CucmCmrParser{}
+}
+
+// Parse returns the CDRs read from reader. The Cluster, Node, Timestamp and
+// Sequence of meta are stored with every CDR.
+func (p *CucmCdrParser) Parse(reader io.Reader, meta Metadata) *Records[*models.CucmCdr] {
+	return parseCucmFile(reader, meta, reflect.TypeOf(models.RawCucmCdr{}), func(header *csvHeader, record []string) (*models.CucmCdr, error) {
+		raw := &models.RawCucmCdr{
+			FileClusterId:      stringOrNil(meta.Cluster),
+			FileNodeId:         stringOrNil(meta.Node),
+			FileDateTime:       unixOrNil(meta.Timestamp),
+			FileSequenceNumber: meta.Sequence,
+		}
+		header.populate(record, raw)
+		return raw.Parse(meta.source(), meta.DialPlan)
+	})
+}
+
+// Parse returns the CMRs read from reader. The Cluster, Node, Timestamp and
+// Sequence of meta are stored with every CMR.
+func (p *CucmCmrParser) Parse(reader io.Reader, meta Metadata) *Records[*models.CucmCmr] {
+	return parseCucmFile(reader, meta, reflect.TypeOf(models.RawCucmCmr{}), func(header *csvHeader, record []string) (*models.CucmCmr, error) {
+		raw := &models.RawCucmCmr{
+			FileClusterId:      stringOrNil(meta.Cluster),
+			FileNodeId:         stringOrNil(meta.Node),
+			FileDateTime:       unixOrNil(meta.Timestamp),
+			FileSequenceNumber: meta.Sequence,
+		}
+		header.populate(record, raw)
+		return raw.Parse(meta.source())
+	})
+}
+
+// parseCucmFile reads a CUCM CDR or CMR file, whose first row names the columns
+// of the raw record type rawType and may be followed by the type of each
+// column. Rows of a single field are blank and skipped.
+func parseCucmFile[T any](r io.Reader, meta Metadata, rawType reflect.Type, parse func(header *csvHeader, record []string) (T, error)) *Records[T] {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var header *csvHeader
+	lineCount := 0
+	records := &Records[T]{}
+
+	next := func() (T, *RowError, error) {
+		var zero T
+		for {
+			lineCount++
+			record, rowErr, err := readCSV(reader)
+			if err != nil || rowErr != nil {
+				return zero, rowErr, err
+			}
+
+			if header == nil {
+				header = newCSVHeader(record, rawType, meta.source())
+				records.warnings = append(records.warnings, header.warnings...)
+				continue
+			}
+
+			if lineCount == 2 && header.isTypeRow(record) {
+				continue
+			}
+
+			line := recordLine(reader)
+			if len(record) == len(header.names) {
+				parsed, err := parse(header, record)
+				if err != nil {
+					return zero, newRowError(line, record, err), nil
+				}
+				return parsed, nil, nil
+			}
+			if len(record) != 1 {
+				return zero, &RowError{Line: line, Record: record, Err: &FieldCountError{Found: len(record), Expected: len(header.names)}}, nil
+			}
+		}
+	}
+
+	records.next = next
+	return records
+}
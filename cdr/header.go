@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cdr
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ziondials/go-cdr/helpers"
+)
+
+// csvHeader maps the columns named in the header row of a CUCM CDR/CMR file
+// onto the *string fields of a raw record struct. Column order and the set of
+// columns change between CUCM releases, so columns are matched by name.
+type csvHeader struct {
+	names    []string
+	columns  []int // Index of the struct field for each column, -1 if unknown
+	warnings []error
+}
+
+// columnAliases maps header names that differ from the name of their struct
+// field, in lower case, onto the field name.
+var columnAliases = map[string]string{
+	"destlegidentifier": "destlegcallidentifier",
+}
+
+// newCSVHeader builds a column mapping for the raw record type of rawType.
+// Header names are compared case-insensitively to the struct field names,
+// e.g. globalCallID_callManagerId matches Globalcallid_Callmanagerid.
+func newCSVHeader(names []string, rawType reflect.Type, source string) *csvHeader {
+
+	fields := map[string]int{}
+	for i := 0; i < rawType.NumField(); i++ {
+		field := rawType.Field(i)
+		if field.Type == reflect.TypeOf((*string)(nil)) {
+			fields[strings.ToLower(field.Name)] = i
+		}
+	}
+
+	header := &csvHeader{names: names, columns: make([]int, len(names))}
+	for i, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := columnAliases[key]; ok {
+			key = alias
+		}
+		index, ok := fields[key]
+		if !ok {
+			header.warnings = append(header.warnings, &UnknownColumnError{Source: source, Column: name})
+			header.columns[i] = -1
+			continue
+		}
+		header.columns[i] = index
+	}
+
+	return header
+}
+
+// UnknownColumnError is a column of a header row that matches no field of the
+// records, so its values are left out of them.
+type UnknownColumnError struct {
+	Source string
+	Column string
+}
+
+func (e *UnknownColumnError) Error() string {
+	return fmt.Sprintf("Unknown column %s in %s", e.Column, e.Source)
+}
+
+// isTypeRow reports whether record is the column type row (INTEGER,
+// VARCHAR(50), ...) that CUCM writes directly below the header row.
+func (h *csvHeader) isTypeRow(record []string) bool {
+	if len(record) != len(h.names) {
+		return false
+	}
+	for _, value := range record {
+		if !helpers.CUCMTypeRowReg.MatchString(strings.TrimSpace(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// populate copies record into the mapped fields of raw, which must be a
+// pointer to the struct type the header was built for.
+func (h *csvHeader) populate(record []string, raw interface{}) {
+	value := reflect.ValueOf(raw).Elem()
+	for i := range record {
+		if i >= len(h.columns) || h.columns[i] < 0 {
+			continue
+		}
+		value.Field(h.columns[i]).Set(reflect.ValueOf(&record[i]))
+	}
+}
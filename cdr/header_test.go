@@ -0,0 +1,209 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cdr
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/dialplan"
+	"github.com/ziondials/go-cdr/models"
+)
+
+// The CDR files in testdata have the header of each CUCM release, followed by
+// the type row and a single call.
+func TestCucmCdrReleases(t *testing.T) {
+
+	tests := []struct {
+		release    string
+		deviceType *string
+	}{
+		{"10.5", nil},
+		{"11.5", nil},
+		{"12.5", strPtr("Cisco8865")},
+		{"14", strPtr("Cisco8865")},
+		{"15", strPtr("Cisco8865")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.release, func(t *testing.T) {
+
+			file, err := os.Open("testdata/cdr_cucm_" + tt.release + ".csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			records := NewCucmCdrParser().Parse(file, Metadata{Source: file.Name(), Cluster: "StandAloneCluster", Node: "01"})
+			cdrs := []*models.CucmCdr{}
+			for records.Next() {
+				if rowErr := records.RowError(); rowErr != nil {
+					t.Fatalf("unexpected row error: %s", rowErr)
+				}
+				cdrs = append(cdrs, records.Record())
+			}
+			if err := records.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			// The type row is skipped rather than parsed or rejected.
+			if len(cdrs) != 1 {
+				t.Fatalf("got %d CDRs, want 1", len(cdrs))
+			}
+			cdr := cdrs[0]
+
+			assertString(t, "OriginPkid", cdr.OriginPkid, strPtr("1b6c3e2a-0d7f-4c1a-9f3e-5a2b7c8d9e01"))
+			assertString(t, "FileClusterId", cdr.FileClusterId, strPtr("StandAloneCluster"))
+			assertString(t, "Callingpartynumber", cdr.Callingpartynumber, strPtr("1001"))
+			assertString(t, "Finalcalledpartynumber", cdr.Finalcalledpartynumber, strPtr("2002"))
+			assertString(t, "Origdevicename", cdr.Origdevicename, strPtr("SEP001122334455"))
+			assertString(t, "Destdevicename", cdr.Destdevicename, strPtr("SEP66778899AABB"))
+			assertString(t, "Origdevicetype", cdr.Origdevicetype, tt.deviceType)
+			assertString(t, "Origipaddr", cdr.Origipaddr, strPtr("192.168.1.10"))
+			assertString(t, "Destipaddr", cdr.Destipaddr, strPtr("192.168.1.20"))
+			assertInt(t, "Globalcallid_Callid", cdr.Globalcallid_Callid, 1001)
+			assertInt(t, "Origlegcallidentifier", cdr.Origlegcallidentifier, 31000001)
+			assertInt(t, "Destlegcallidentifier", cdr.Destlegcallidentifier, 31000002)
+			assertInt(t, "Datetimeorigination", cdr.Datetimeorigination, 1704103200)
+			assertInt(t, "Duration", cdr.Duration, 60)
+		})
+	}
+}
+
+func TestCSVHeaderMapsColumnsByName(t *testing.T) {
+
+	rawType := reflect.TypeOf(models.RawCucmCdr{})
+	header := newCSVHeader([]string{"pkid", " DURATION ", "notAColumn", "destLegIdentifier", "globalCallID_callId"}, rawType, "test")
+
+	want := []string{"Pkid", "Duration", "", "Destlegcallidentifier", "Globalcallid_Callid"}
+	for i, name := range want {
+		if name == "" {
+			if header.columns[i] != -1 {
+				t.Errorf("column %d: got field %d, want unknown", i, header.columns[i])
+			}
+			continue
+		}
+		if got := rawType.Field(header.columns[i]).Name; got != name {
+			t.Errorf("column %d: got %s, want %s", i, got, name)
+		}
+	}
+
+	raw := &models.RawCucmCdr{}
+	header.populate([]string{"pkid-1", "60", "ignored", "2", "1001"}, raw)
+	assertString(t, "Pkid", raw.Pkid, strPtr("pkid-1"))
+	assertString(t, "Duration", raw.Duration, strPtr("60"))
+	assertString(t, "Destlegcallidentifier", raw.Destlegcallidentifier, strPtr("2"))
+	assertString(t, "Globalcallid_Callid", raw.Globalcallid_Callid, strPtr("1001"))
+}
+
+func TestCucmCdrDialPlan(t *testing.T) {
+
+	plan, err := dialplan.NewPlan(&config.DialPlanConfig{CountryCode: "1", Extensions: []string{"1000-2999"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		plan      *dialplan.Plan
+		direction *string
+	}{
+		{"no dial plan", nil, nil},
+		{"dial plan", plan, strPtr(dialplan.CallDirectionInternal)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			file, err := os.Open("testdata/cdr_cucm_15.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			records := NewCucmCdrParser().Parse(file, Metadata{Source: file.Name(), DialPlan: tt.plan})
+			if !records.Next() || records.RowError() != nil {
+				t.Fatalf("no CDR parsed: %v %v", records.RowError(), records.Err())
+			}
+			assertString(t, "CallDirection", records.Record().CallDirection, tt.direction)
+		})
+	}
+}
+
+func TestCucmCdrUnknownColumnWarnings(t *testing.T) {
+
+	input := "pkid,notAColumn,duration\nINTEGER,INTEGER,INTEGER\npkid-1,1,60\n"
+	records := NewCucmCdrParser().Parse(strings.NewReader(input), Metadata{Source: "test"})
+	for records.Next() {
+	}
+	if err := records.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := records.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings, want 1", len(warnings))
+	}
+	var unknown *UnknownColumnError
+	if !errors.As(warnings[0], &unknown) || unknown.Column != "notAColumn" || unknown.Source != "test" {
+		t.Errorf("got warning %v, want unknown column notAColumn in test", warnings[0])
+	}
+}
+
+func TestCSVHeaderIsTypeRow(t *testing.T) {
+
+	header := newCSVHeader([]string{"cdrRecordType", "pkid", "origDeviceName"}, reflect.TypeOf(models.RawCucmCdr{}), "test")
+
+	tests := []struct {
+		record []string
+		want   bool
+	}{
+		{[]string{"INTEGER", "UNIQUEIDENTIFIER", "VARCHAR(129)"}, true},
+		{[]string{"1", "UNIQUEIDENTIFIER", "VARCHAR(129)"}, false},
+		{[]string{"INTEGER", "UNIQUEIDENTIFIER"}, false},
+	}
+	for _, tt := range tests {
+		if got := header.isTypeRow(tt.record); got != tt.want {
+			t.Errorf("isTypeRow(%v) = %t, want %t", tt.record, got, tt.want)
+		}
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func assertString(t *testing.T, name string, got *string, want *string) {
+	t.Helper()
+	switch {
+	case got == nil && want == nil:
+	case got == nil || want == nil:
+		t.Errorf("%s = %v, want %v", name, got, want)
+	case *got != *want:
+		t.Errorf("%s = %q, want %q", name, *got, *want)
+	}
+}
+
+func assertInt(t *testing.T, name string, got *int64, want int64) {
+	t.Helper()
+	if got == nil || *got != want {
+		t.Errorf("%s = %v, want %d", name, got, want)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cdr
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ziondials/go-cdr/helpers"
+)
+
+// CucmFileMetadata returns the metadata of a CUCM CDR or CMR file named like
+// cdr_Cluster_Node_YYYYMMDDhhmm_Sequence. The metadata that could be read is
+// returned along with the error when a part of the name cannot be parsed.
+func CucmFileMetadata(filename string) (Metadata, error) {
+
+	baseFileName := filepath.Base(filename)
+	meta := Metadata{Source: filename}
+
+	parts := strings.Split(baseFileName, "_")
+	if len(parts) < 5 {
+		return meta, fmt.Errorf("filename %s does not match Type_Cluster_Node_Timestamp_Sequence", baseFileName)
+	}
+
+	meta.Cluster = parts[1]
+	meta.Node = parts[2]
+
+	var errs []error
+	timestamp, err := helpers.ParseCUCMFilenameTimestamp(parts[3])
+	if err != nil {
+		errs = append(errs, fmt.Errorf("file date time: %w", err))
+	} else {
+		meta.Timestamp = time.Unix(*timestamp, 0).UTC()
+	}
+	sequence, err := helpers.ConvertStringToInt64(&parts[4])
+	if err != nil {
+		errs = append(errs, fmt.Errorf("file sequence: %w", err))
+	} else {
+		meta.Sequence = sequence
+	}
+
+	return meta, errors.Join(errs...)
+}
+
+// CubeFileMetadata returns the metadata of a CUBE gw-accounting file named like
+// Filename.Hostname.MM_DD_YYYY_hh_mm_ss.sss. The metadata that could be read is
+// returned along with the error when a part of the name cannot be parsed.
+func CubeFileMetadata(filename string) (Metadata, error) {
+
+	baseFileName := filepath.Base(filename)
+	meta := Metadata{Source: filename}
+
+	if !helpers.IsCUBEFilename(baseFileName) {
+		return meta, fmt.Errorf("filename %s does not match Filename.Hostname.Timestamp", baseFileName)
+	}
+
+	parts := strings.Split(baseFileName, ".")
+	meta.Filename = parts[0]
+	meta.Hostname = parts[1]
+
+	fileTimestamp := strings.ReplaceAll(parts[2]+"."+parts[3], "_", " ")
+	timestamp, err := helpers.ConvertStringToUnixTime(&fileTimestamp, nil)
+	if err != nil {
+		return meta, fmt.Errorf("file timestamp: %w", err)
+	}
+	if timestamp != nil {
+		meta.Timestamp = time.Unix(*timestamp, 0).UTC()
+	}
+
+	return meta, nil
+}
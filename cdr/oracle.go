@@ -0,0 +1,181 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cdr
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/ziondials/go-cdr/models"
+)
+
+// OracleCdrParser parses the CDR files of Oracle session border controllers.
+type OracleCdrParser struct{}
+
+var _ Parser[*models.OracleCDR] = (*OracleCdrParser)(nil)
+
+func NewOracleCdrParser() *OracleCdrParser {
+	return &OracleCdrParser{}
+}
+
+// Parse returns the Stop records read from reader, skipping the Start and
+// Interim records, which are counted by a *SkippedRecordsError warning. The
+// Filename of meta is stored with every CDR.
+func (p *OracleCdrParser) Parse(r io.Reader, meta Metadata) *Records[*models.OracleCDR] {
+
+	reader := csv.NewReader(r)
+	// Start, Interim and Stop records are interleaved and have different field counts
+	reader.FieldsPerRecord = -1
+	filename := stringOrNil(meta.Filename)
+	records := &Records[*models.OracleCDR]{}
+	var skipped *SkippedRecordsError
+
+	next := func() (*models.OracleCDR, *RowError, error) {
+		for {
+			record, rowErr, err := readCSV(reader)
+			if err != nil || rowErr != nil {
+				return nil, rowErr, err
+			}
+
+			if len(record) >= models.OracleStopRecordFieldCount {
+				raw := newRawOracleCDR(record)
+				raw.Filename = filename
+				cdr, err := raw.Parse(meta.source())
+				if err != nil {
+					return nil, newRowError(recordLine(reader), record, err), nil
+				}
+				return cdr, nil, nil
+			}
+			if len(record) != 1 {
+				if skipped == nil {
+					skipped = &SkippedRecordsError{Source: meta.source(), Records: "Start/Interim"}
+					records.warnings = append(records.warnings, skipped)
+				}
+				skipped.Count++
+			}
+		}
+	}
+
+	records.next = next
+	return records
+}
+
+// newRawOracleCDR maps the fields of a Stop record, which must have at least
+// models.OracleStopRecordFieldCount fields, onto a RawOracleCDR.
+func newRawOracleCDR(record []string) *models.RawOracleCDR {
+	return &models.RawOracleCDR{
+		Accountingstatus:              &record[0],
+		Nasipaddress:                  &record[1],
+		Nasport:                       &record[2],
+		Accountingsessionid:           &record[3],
+		Ingresssessionid:              &record[4],
+		Egresssessionid:               &record[5],
+		Sessionprotocoltype:           &record[6],
+		Callingstationid:              &record[7],
+		Calledstationid:               &record[8],
+		Accountingterminationcause:    &record[9],
+		Accountingsessiontime:         &record[10],
+		Ciscosetuptime:                &record[11],
+		Ciscoconnecttime:              &record[12],
+		Ciscodisconnecttime:           &record[13],
+		Ciscodisconnectcause:          &record[14],
+		Egressnetworkinterfaceid:      &record[15],
+		Egressvlantagvalue:            &record[16],
+		Ingressnetworkinterfaceid:     &record[17],
+		Ingressvlantagvalue:           &record[18],
+		Egressrealm:                   &record[19],
+		Ingressrealm:                  &record[20],
+		Flowidentifier:                &record[21],
+		Flowtype:                      &record[22],
+		Flowinputrealm:                &record[23],
+		Flowinputsrcaddr:              &record[24],
+		Flowinputsrcport:              &record[25],
+		Flowinputdestaddress:          &record[26],
+		Flowinputdestport:             &record[27],
+		Flowoutputrealm:               &record[28],
+		Flowoutputsrcaddress:          &record[29],
+		Flowoutputsrcport:             &record[30],
+		Flowoutputdestaddr:            &record[31],
+		Flowoutputdestport:            &record[32],
+		Rtcpcallingpacketslost:        &record[33],
+		Rtcpcallingavgjitter:          &record[34],
+		Rtcpcallingavglatency:         &record[35],
+		Rtcpcallingmaxjitter:          &record[36],
+		Rtcpcallingmaxlatency:         &record[37],
+		Rtpcallingpacketslost:         &record[38],
+		Rtpcallingavgjitter:           &record[39],
+		Rtpcallingmaxjitter:           &record[40],
+		Rtpcallingoctets:              &record[41],
+		Rtpcallingpackets:             &record[42],
+		Callingrfactor:                &record[43],
+		Callingmos:                    &record[44],
+		Flowidentifier2:               &record[45],
+		Flowtype2:                     &record[46],
+		Flowinputrealm2:               &record[47],
+		Flowinputsrcaddr2:             &record[48],
+		Flowinputsrcport2:             &record[49],
+		Flowinputdestaddress2:         &record[50],
+		Flowinputdestport2:            &record[51],
+		Flowoutputrealm2:              &record[52],
+		Flowoutputsrcaddress2:         &record[53],
+		Flowoutputsrcport2:            &record[54],
+		Flowoutputdestaddr2:           &record[55],
+		Flowoutputdestport2:           &record[56],
+		Rtcpcalledpacketslost:         &record[57],
+		Rtcpcalledavgjitter:           &record[58],
+		Rtcpcalledavglatency:          &record[59],
+		Rtcpcalledmaxjitter:           &record[60],
+		Rtcpcalledmaxlatency:          &record[61],
+		Rtpcalledpacketslost:          &record[62],
+		Rtpcalledavgjitter:            &record[63],
+		Rtpcalledmaxjitter:            &record[64],
+		Rtpcalledoctets:               &record[65],
+		Rtpcalledpackets:              &record[66],
+		Calledrfactor:                 &record[67],
+		Calledmos:                     &record[68],
+		Firmwareversion:               &record[69],
+		Localtimezone:                 &record[70],
+		Postdialdelay:                 &record[71],
+		Primaryroutingnumber:          &record[72],
+		Ingresslocaladdress:           &record[73],
+		Ingressremoteaddress:          &record[74],
+		Egresslocaladdress:            &record[75],
+		Egressremoteaddress:           &record[76],
+		Sessiondisposition:            &record[77],
+		Disconnectinitiator:           &record[78],
+		Disconnectcause:               &record[79],
+		Sipstatuscode:                 &record[80],
+		Egressroutingnumber:           &record[81],
+		Callingmediastoptime:          &record[82],
+		Calledmediastoptime:           &record[83],
+		Flowmediatype:                 &record[84],
+		Flowmediatype2:                &record[85],
+		Rtpcallingoctetstransmitted:   &record[86],
+		Rtpcallingpacketstransmitted:  &record[87],
+		Rtpcalledoctetstransmitted:    &record[88],
+		Rtpcalledpacketstransmitted:   &record[89],
+		Msrpcalledoctets:              &record[90],
+		Msrpcalledpackets:             &record[91],
+		Msrpcalledoctetstransmitted:   &record[92],
+		Msrpcalledpacketstransmitted:  &record[93],
+		Msrpcallingoctets:             &record[94],
+		Msrpcallingpackets:            &record[95],
+		Msrpcallingoctetstransmitted:  &record[96],
+		Msrpcallingpacketstransmitted: &record[97],
+		Nodefunctionality:             &record[98],
+		Cdrsequencenumber:             &record[99],
+	}
+}
@@ -19,9 +19,15 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/ziondials/go-cdr/config"
 	"github.com/ziondials/go-cdr/cron"
+	"github.com/ziondials/go-cdr/dialplan"
+	"github.com/ziondials/go-cdr/fraud"
 	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/quality"
+	"github.com/ziondials/go-cdr/rating"
 )
 
+var watch bool
+
 // parseCmd represents the parse command
 var parseCmd = &cobra.Command{
 	Use:   "parse",
@@ -29,7 +35,15 @@ var parseCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		config.SetDefaults()
 		logger.InitLogger()
-		cron.RunCronJobs()
+		dialplan.InitDialPlan()
+		rating.InitRating()
+		fraud.InitFraud()
+		quality.InitVoiceQuality()
+		if watch {
+			cron.RunWatchJobs()
+		} else {
+			cron.RunCronJobs()
+		}
 	},
 }
 
@@ -45,4 +59,5 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// parseCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	parseCmd.Flags().BoolVar(&watch, "watch", false, "Parse files as soon as they are written instead of every parseInterval")
 }
@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/quality"
+)
+
+var qualityFrom string
+var qualityTo string
+
+var qualityCmd = &cobra.Command{
+	Use:   "quality",
+	Short: "Checks the voice quality SLAs between --from and --to against the stored CMRs and CDRs",
+	Run: func(cmd *cobra.Command, args []string) {
+		config.SetDefaults()
+		logger.InitLogger()
+		quality.InitVoiceQuality()
+
+		from, err := parseTimeFlag(qualityFrom)
+		if err != nil {
+			logger.Fatal("Error parsing --from: %s", err)
+		}
+		to, err := parseTimeFlag(qualityTo)
+		if err != nil {
+			logger.Fatal("Error parsing --to: %s", err)
+		}
+		from = from.Truncate(time.Hour)
+
+		db := database.Connect()
+
+		count, alerts, err := db.ComputeVoiceQuality(from.Unix(), to.Unix())
+		if err != nil {
+			logger.Fatal("Error computing voice quality: %s", err)
+		}
+		logger.Info("Computed %s voice quality rows between %s and %s with %s new alerts", strconv.Itoa(count), from, to, strconv.Itoa(len(alerts)))
+
+		// The command exits right away, so the alerts are not posted in the
+		// background.
+		if err := quality.Post(alerts); err != nil {
+			logger.Error("Error posting %d alerts to webhook: %s", len(alerts), err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(qualityCmd)
+
+	qualityCmd.Flags().StringVar(&qualityFrom, "from", "", "Start of the voice quality to check, as a date or an RFC 3339 time")
+	qualityCmd.Flags().StringVar(&qualityTo, "to", "", "End of the voice quality to check, excluded, as a date or an RFC 3339 time")
+	qualityCmd.MarkFlagRequired("from")
+	qualityCmd.MarkFlagRequired("to")
+}
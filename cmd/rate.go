@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/rating"
+)
+
+var rateFrom string
+var rateTo string
+
+var rateCmd = &cobra.Command{
+	Use:   "rate",
+	Short: "Rates the calls stored between --from and --to again with the configured tariffs",
+	Run: func(cmd *cobra.Command, args []string) {
+		config.SetDefaults()
+		logger.InitLogger()
+		rating.InitRating()
+		if rating.RatingEngine == nil {
+			logger.Fatal("No rating settings found in config file")
+		}
+
+		from, err := parseTimeFlag(rateFrom)
+		if err != nil {
+			logger.Fatal("Error parsing --from: %s", err)
+		}
+		to, err := parseTimeFlag(rateTo)
+		if err != nil {
+			logger.Fatal("Error parsing --to: %s", err)
+		}
+
+		db := database.Connect()
+
+		rated, err := db.RateCalls(from.Unix(), to.Unix())
+		if err != nil {
+			logger.Fatal("Error rating calls: %s", err)
+		}
+		logger.Info("Rated %s calls between %s and %s", strconv.Itoa(rated), from, to)
+	},
+}
+
+// parseTimeFlag parses a date, in the local time zone, or an RFC 3339 time.
+func parseTimeFlag(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s is neither a date (2006-01-02) nor an RFC 3339 time", s)
+	}
+	return t, nil
+}
+
+func init() {
+	rootCmd.AddCommand(rateCmd)
+
+	rateCmd.Flags().StringVar(&rateFrom, "from", "", "Start of the calls to rate, as a date or an RFC 3339 time")
+	rateCmd.Flags().StringVar(&rateTo, "to", "", "End of the calls to rate, excluded, as a date or an RFC 3339 time")
+	rateCmd.MarkFlagRequired("from")
+	rateCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/dialplan"
+	"github.com/ziondials/go-cdr/fraud"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/rating"
+	"github.com/ziondials/go-cdr/receiver"
+)
+
+var sftpCmd = &cobra.Command{
+	Use:   "sftp",
+	Short: "Runs an SFTP server that CUCM Billing Servers can push CDR files to",
+	Run: func(cmd *cobra.Command, args []string) {
+		config.SetDefaults()
+		logger.InitLogger()
+		dialplan.InitDialPlan()
+		rating.InitRating()
+		fraud.InitFraud()
+		receiver.RunSFTPServer()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sftpCmd)
+}
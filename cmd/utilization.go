@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/logger"
+)
+
+var utilizationFrom string
+var utilizationTo string
+
+var utilizationCmd = &cobra.Command{
+	Use:   "utilization",
+	Short: "Computes the trunk utilization between --from and --to from the stored calls",
+	Run: func(cmd *cobra.Command, args []string) {
+		config.SetDefaults()
+		logger.InitLogger()
+
+		from, err := parseTimeFlag(utilizationFrom)
+		if err != nil {
+			logger.Fatal("Error parsing --from: %s", err)
+		}
+		to, err := parseTimeFlag(utilizationTo)
+		if err != nil {
+			logger.Fatal("Error parsing --to: %s", err)
+		}
+		from = from.Truncate(time.Hour)
+
+		var cucmTrunks []string
+		if utilizationConfig := config.GetUtilizationFromGlobalConfig(); utilizationConfig != nil {
+			cucmTrunks = utilizationConfig.CucmTrunks
+		}
+
+		db := database.Connect()
+
+		count, err := db.ComputeTrunkUtilization(from.Unix(), to.Unix(), cucmTrunks)
+		if err != nil {
+			logger.Fatal("Error computing trunk utilization: %s", err)
+		}
+		logger.Info("Computed %s trunk utilization rows between %s and %s", strconv.Itoa(count), from, to)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(utilizationCmd)
+
+	utilizationCmd.Flags().StringVar(&utilizationFrom, "from", "", "Start of the utilization to compute, as a date or an RFC 3339 time")
+	utilizationCmd.Flags().StringVar(&utilizationTo, "to", "", "End of the utilization to compute, excluded, as a date or an RFC 3339 time")
+	utilizationCmd.MarkFlagRequired("from")
+	utilizationCmd.MarkFlagRequired("to")
+}
@@ -17,6 +17,7 @@ package config
 
 import (
 	"log"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -28,16 +29,18 @@ type GlobalConfig struct {
 }
 
 type DatabaseConfig struct {
-	AutoMigrate bool
-	Database    string
-	Driver      string
-	Host        string
-	Limit       uint32
-	Password    string
-	Path        string
-	Port        int
-	Username    string
-	SSL         string
+	AutoMigrate    bool
+	ConflictPolicy string
+	Database       string
+	Driver         string
+	Host           string
+	Limit          uint32
+	MaxOpenConns   int
+	Password       string
+	Path           string
+	Port           int
+	Username       string
+	SSL            string
 }
 
 type LoggingConfig struct {
@@ -50,8 +53,201 @@ type LoggingConfig struct {
 }
 
 type ParserConfig struct {
-	Directories   []DirectoryConfig `mapstructure:"directories"`
-	ParseInterval int
+	Directories      []DirectoryConfig `mapstructure:"directories"`
+	DirectoryWorkers int
+	FileWorkers      int
+	ParseInterval    int
+	WatchSettleTime  int
+}
+
+type SFTPConfig struct {
+	HostKey string
+	Listen  string
+	Users   []SFTPUserConfig
+}
+
+// SFTPUserConfig is a user of the embedded SFTP server, usually one per CUCM
+// cluster. Uploads are written to Input, which must be the input directory of
+// a cucm DirectoryConfig.
+type SFTPUserConfig struct {
+	Input     string `mapstructure:"input"`
+	Password  string `mapstructure:"password"`
+	PublicKey string `mapstructure:"publicKey"`
+	Username  string `mapstructure:"username"`
+}
+
+type FTPConfig struct {
+	Listen       string
+	PassivePorts string
+	PublicHost   string
+	Users        []FTPUserConfig
+}
+
+// FTPUserConfig is a user of the embedded FTP server, usually one per CUBE.
+// Uploads are written to Input, which must be the input directory of a cube
+// DirectoryConfig.
+type FTPUserConfig struct {
+	Input    string `mapstructure:"input"`
+	Password string `mapstructure:"password"`
+	Username string `mapstructure:"username"`
+}
+
+type RADIUSConfig struct {
+	Clients []RADIUSClientConfig
+	Listen  string
+}
+
+// RADIUSClientConfig is a gateway allowed to send RADIUS accounting. Address
+// is an IP address or a CIDR range, and Secret is the shared secret configured
+// for the server on the gateway.
+type RADIUSClientConfig struct {
+	Address string `mapstructure:"address"`
+	Secret  string `mapstructure:"secret"`
+}
+
+// CMSConfig is the HTTP CDR receiver for Cisco Meeting Server. Clients limits
+// the addresses allowed to post records to IP addresses or CIDR ranges, and
+// allows every address if it is empty. TLS is used if CertFile and KeyFile are
+// set.
+type CMSConfig struct {
+	CertFile string
+	Clients  []string
+	KeyFile  string
+	Listen   string
+	Path     string
+}
+
+// DialPlanConfig describes the dial plan of the site, which is used to
+// normalize calling and called numbers to E.164 and to classify the direction
+// of calls. Extensions are the internal numbers of the site, either ranges such
+// as 1000-1999 or patterns such as 2XXX, and may include DIDs written in E.164
+// such as +1212555XXXX. AreaCode is prepended to numbers of LocalNumberLength
+// digits, and calls to it and to LocalAreaCodes are local. TollFreePrefixes are
+// the prefixes of toll-free national numbers.
+type DialPlanConfig struct {
+	AccessCodes          []string
+	AreaCode             string
+	CountryCode          string
+	EmergencyNumbers     []string
+	Extensions           []string
+	InternationalPrefix  string
+	LocalAreaCodes       []string
+	LocalNumberLength    int
+	NationalNumberLength int
+	NationalPrefix       string
+	TollFreePrefixes     []string
+}
+
+// FraudConfig is the toll-fraud and anomaly detection, whose Rules are checked
+// against the CDRs as they are loaded. Business hours are evaluated in Timezone,
+// the local time zone if it is empty, and alerts are posted as JSON to Webhook
+// if it is set.
+type FraudConfig struct {
+	Rules    []FraudRuleConfig
+	Timezone string
+	Webhook  string
+}
+
+// FraudRuleConfig is a rule of one of these types:
+//
+//   - afterHours flags calls in Directions (international if empty) that start
+//     outside the business hours given by Days, From and To.
+//   - burst flags a call once Count calls of at most MaxDuration seconds to the
+//     E.164 numbers starting with Prefixes were made within Window minutes.
+//   - unexpectedPeer flags calls whose remote address is not one of Peers, IP
+//     addresses or CIDR ranges.
+//   - longDuration flags calls longer than MinDuration seconds.
+//
+// Every rule can be limited to the calls in Directions and to the calls from the
+// CUCM devices or CUBE gateways whose names match one of Devices, as patterns
+// such as SIP_TRUNK_*.
+type FraudRuleConfig struct {
+	Count       int64    `mapstructure:"count"`
+	Days        []string `mapstructure:"days"`
+	Devices     []string `mapstructure:"devices"`
+	Directions  []string `mapstructure:"directions"`
+	From        string   `mapstructure:"from"`
+	MaxDuration int64    `mapstructure:"maxDuration"`
+	MinDuration int64    `mapstructure:"minDuration"`
+	Name        string   `mapstructure:"name"`
+	Peers       []string `mapstructure:"peers"`
+	Prefixes    []string `mapstructure:"prefixes"`
+	Severity    string   `mapstructure:"severity"`
+	To          string   `mapstructure:"to"`
+	Type        string   `mapstructure:"type"`
+	Window      int64    `mapstructure:"window"`
+}
+
+// VoiceQualityConfig is the voice quality SLA job, which recomputes the hourly
+// voice quality of the last Lookback hours (24 if unset) every Interval minutes
+// (15 if unset), so CMRs that arrive late are counted. The hours that break an
+// SLA are written to the alerts and posted as JSON to Webhook if it is set.
+type VoiceQualityConfig struct {
+	Interval int
+	Lookback int
+	SLAs     []VoiceQualitySLAConfig
+	Webhook  string
+}
+
+// VoiceQualitySLAConfig is the thresholds of a group of devices, a trunk or a
+// site. Its calls are the CUCM CMRs and CUBE legs whose device, gateway or trunk
+// group label matches one of Devices, as patterns such as SEP*, or whose address
+// is in one of Subnets, IP addresses or CIDR ranges, and every call if both are
+// empty. CDRs do not carry device pools, so Subnets stand in for them.
+// A call is poor if its jitter, latency (ms) or lost packets (%) are above or
+// its MOS is below a threshold, thresholds of 0 being ignored; CUBE legs are
+// checked against MaxReceiveDelay and MaxRoundTripDelay (ms) instead of
+// MaxJitter and MaxLatency. An hour breaks the SLA when more than
+// MaxPoorPercent of its calls, and at least MinCalls calls, are poor.
+type VoiceQualitySLAConfig struct {
+	Devices           []string `mapstructure:"devices"`
+	MaxJitter         int64    `mapstructure:"maxJitter"`
+	MaxLatency        int64    `mapstructure:"maxLatency"`
+	MaxLossPercent    float64  `mapstructure:"maxLossPercent"`
+	MaxPoorPercent    float64  `mapstructure:"maxPoorPercent"`
+	MaxReceiveDelay   int64    `mapstructure:"maxReceiveDelay"`
+	MaxRoundTripDelay int64    `mapstructure:"maxRoundTripDelay"`
+	MinCalls          int64    `mapstructure:"minCalls"`
+	MinMos            float64  `mapstructure:"minMos"`
+	Name              string   `mapstructure:"name"`
+	Severity          string   `mapstructure:"severity"`
+	Subnets           []string `mapstructure:"subnets"`
+}
+
+// RatingConfig is the tariff table used to rate outbound calls. Time bands are
+// evaluated in Timezone, the local time zone if it is empty.
+type RatingConfig struct {
+	Currency string
+	Timezone string
+	Tariffs  []TariffConfig
+}
+
+// TariffConfig is a rate for the E.164 destinations starting with Prefix. The
+// first tariff with the longest matching prefix wins, and tariffs can be limited
+// to a time band with Days (mon-sun) and From and To (15:04, To is exclusive). A
+// call is billed InitialIncrement seconds, then in steps of Increment seconds,
+// and costs at least MinimumCharge.
+type TariffConfig struct {
+	Band             string   `mapstructure:"band"`
+	Days             []string `mapstructure:"days"`
+	From             string   `mapstructure:"from"`
+	Increment        int64    `mapstructure:"increment"`
+	InitialIncrement int64    `mapstructure:"initialIncrement"`
+	MinimumCharge    float64  `mapstructure:"minimumCharge"`
+	Name             string   `mapstructure:"name"`
+	Prefix           string   `mapstructure:"prefix"`
+	RatePerMinute    float64  `mapstructure:"ratePerMinute"`
+	To               string   `mapstructure:"to"`
+}
+
+// UtilizationConfig is the trunk utilization job, which recomputes the last
+// Lookback hours (24 if unset) every Interval minutes (15 if unset), so calls
+// whose CDRs arrive late are counted. CucmTrunks are the names of the CUCM
+// gateways and SIP trunks, as patterns such as SIP_TRUNK_*.
+type UtilizationConfig struct {
+	CucmTrunks []string
+	Interval   int
+	Lookback   int
 }
 
 type DirectoryConfig struct {
@@ -75,11 +271,49 @@ func SetDefaults() {
 	// viper.SetDefault("database.driver", "sqlite")
 	viper.SetDefault("database.path", "./go-cdr/db/go-cdr.db")
 	viper.SetDefault("database.limit", 100)
+	viper.SetDefault("database.conflictPolicy", "skip")
+	viper.SetDefault("database.maxOpenConns", 10)
+
+	// Set defaults for the ParserConfig
+	viper.SetDefault("parser.directoryWorkers", 1)
+	viper.SetDefault("parser.fileWorkers", 1)
+	viper.SetDefault("parser.watchSettleTime", 10)
+
+	// Set defaults for the SFTPConfig
+	viper.SetDefault("sftp.hostKey", "./go-cdr/sftp_host_key")
+	viper.SetDefault("sftp.listen", ":2222")
+
+	// Set defaults for the FTPConfig
+	viper.SetDefault("ftp.listen", ":21")
+
+	// Set defaults for the RADIUSConfig
+	viper.SetDefault("radius.listen", ":1813")
+
+	// Set defaults for the CMSConfig
+	viper.SetDefault("cms.listen", ":8080")
+	viper.SetDefault("cms.path", "/cdr")
+
+}
 
+// subConfig returns the section of the global config under key, or nil if
+// there is none. viper.Sub drops the defaults registered by SetDefaults once
+// the section appears in the config file, so they are copied over here.
+func subConfig(key string) *viper.Viper {
+	section := viper.Sub(key)
+	if section == nil {
+		return nil
+	}
+	prefix := strings.ToLower(key) + "."
+	for _, name := range viper.AllKeys() {
+		if strings.HasPrefix(name, prefix) {
+			section.SetDefault(strings.TrimPrefix(name, prefix), viper.Get(name))
+		}
+	}
+	return section
 }
 
 func GetLoggerFromGlobalConfig() *LoggingConfig {
-	loggerConfig := viper.Sub("logging")
+	loggerConfig := subConfig("logging")
 	if loggerConfig == nil {
 		log.Fatalf("No log settings found in config file")
 		return nil
@@ -95,13 +329,16 @@ func GetLoggerFromGlobalConfig() *LoggingConfig {
 }
 
 func GetParserFromGlobalConfig() *ParserConfig {
-	parserConfig := viper.Sub("parser")
+	parserConfig := subConfig("parser")
 	if parserConfig == nil {
 		log.Fatalf("No parser settings found in config file")
 		return nil
 	}
 	return &ParserConfig{
-		ParseInterval: parserConfig.GetInt("parseInterval"),
+		DirectoryWorkers: parserConfig.GetInt("directoryWorkers"),
+		FileWorkers:      parserConfig.GetInt("fileWorkers"),
+		ParseInterval:    parserConfig.GetInt("parseInterval"),
+		WatchSettleTime:  parserConfig.GetInt("watchSettleTime"),
 	}
 }
 
@@ -114,22 +351,187 @@ func GetDirectoriesFromGlobalConfig() []DirectoryConfig {
 	return directories
 }
 
+func GetSFTPFromGlobalConfig() *SFTPConfig {
+	sftpConfig := subConfig("sftp")
+	if sftpConfig == nil {
+		log.Fatalf("No sftp settings found in config file")
+		return nil
+	}
+
+	var users []SFTPUserConfig
+
+	viper.UnmarshalKey("sftp.users", &users)
+
+	return &SFTPConfig{
+		HostKey: sftpConfig.GetString("hostKey"),
+		Listen:  sftpConfig.GetString("listen"),
+		Users:   users,
+	}
+}
+
+func GetFTPFromGlobalConfig() *FTPConfig {
+	ftpConfig := subConfig("ftp")
+	if ftpConfig == nil {
+		log.Fatalf("No ftp settings found in config file")
+		return nil
+	}
+
+	var users []FTPUserConfig
+
+	viper.UnmarshalKey("ftp.users", &users)
+
+	return &FTPConfig{
+		Listen:       ftpConfig.GetString("listen"),
+		PassivePorts: ftpConfig.GetString("passivePorts"),
+		PublicHost:   ftpConfig.GetString("publicHost"),
+		Users:        users,
+	}
+}
+
+func GetRADIUSFromGlobalConfig() *RADIUSConfig {
+	radiusConfig := subConfig("radius")
+	if radiusConfig == nil {
+		log.Fatalf("No radius settings found in config file")
+		return nil
+	}
+
+	var clients []RADIUSClientConfig
+
+	viper.UnmarshalKey("radius.clients", &clients)
+
+	return &RADIUSConfig{
+		Clients: clients,
+		Listen:  radiusConfig.GetString("listen"),
+	}
+}
+
+func GetCMSFromGlobalConfig() *CMSConfig {
+	cmsConfig := subConfig("cms")
+	if cmsConfig == nil {
+		log.Fatalf("No cms settings found in config file")
+		return nil
+	}
+	return &CMSConfig{
+		CertFile: cmsConfig.GetString("certFile"),
+		Clients:  cmsConfig.GetStringSlice("clients"),
+		KeyFile:  cmsConfig.GetString("keyFile"),
+		Listen:   cmsConfig.GetString("listen"),
+		Path:     cmsConfig.GetString("path"),
+	}
+}
+
+// GetDialPlanFromGlobalConfig returns nil if no dial plan is configured, in
+// which case numbers are not normalized.
+func GetDialPlanFromGlobalConfig() *DialPlanConfig {
+	dialPlanConfig := subConfig("dialPlan")
+	if dialPlanConfig == nil {
+		return nil
+	}
+	return &DialPlanConfig{
+		AccessCodes:          dialPlanConfig.GetStringSlice("accessCodes"),
+		AreaCode:             dialPlanConfig.GetString("areaCode"),
+		CountryCode:          dialPlanConfig.GetString("countryCode"),
+		EmergencyNumbers:     dialPlanConfig.GetStringSlice("emergencyNumbers"),
+		Extensions:           dialPlanConfig.GetStringSlice("extensions"),
+		InternationalPrefix:  dialPlanConfig.GetString("internationalPrefix"),
+		LocalAreaCodes:       dialPlanConfig.GetStringSlice("localAreaCodes"),
+		LocalNumberLength:    dialPlanConfig.GetInt("localNumberLength"),
+		NationalNumberLength: dialPlanConfig.GetInt("nationalNumberLength"),
+		NationalPrefix:       dialPlanConfig.GetString("nationalPrefix"),
+		TollFreePrefixes:     dialPlanConfig.GetStringSlice("tollFreePrefixes"),
+	}
+}
+
+// GetFraudFromGlobalConfig returns nil if no fraud rules are configured, in
+// which case calls are not checked.
+func GetFraudFromGlobalConfig() *FraudConfig {
+	fraudConfig := subConfig("fraud")
+	if fraudConfig == nil {
+		return nil
+	}
+
+	var rules []FraudRuleConfig
+
+	viper.UnmarshalKey("fraud.rules", &rules)
+
+	return &FraudConfig{
+		Rules:    rules,
+		Timezone: fraudConfig.GetString("timezone"),
+		Webhook:  fraudConfig.GetString("webhook"),
+	}
+}
+
+// GetVoiceQualityFromGlobalConfig returns nil if no voice quality SLAs are
+// configured.
+func GetVoiceQualityFromGlobalConfig() *VoiceQualityConfig {
+	voiceQualityConfig := subConfig("voiceQuality")
+	if voiceQualityConfig == nil {
+		return nil
+	}
+
+	var slas []VoiceQualitySLAConfig
+
+	viper.UnmarshalKey("voiceQuality.slas", &slas)
+
+	return &VoiceQualityConfig{
+		Interval: voiceQualityConfig.GetInt("interval"),
+		Lookback: voiceQualityConfig.GetInt("lookback"),
+		SLAs:     slas,
+		Webhook:  voiceQualityConfig.GetString("webhook"),
+	}
+}
+
+// GetRatingFromGlobalConfig returns nil if no tariffs are configured, in which
+// case calls are not rated.
+func GetRatingFromGlobalConfig() *RatingConfig {
+	ratingConfig := subConfig("rating")
+	if ratingConfig == nil {
+		return nil
+	}
+
+	var tariffs []TariffConfig
+
+	viper.UnmarshalKey("rating.tariffs", &tariffs)
+
+	return &RatingConfig{
+		Currency: ratingConfig.GetString("currency"),
+		Timezone: ratingConfig.GetString("timezone"),
+		Tariffs:  tariffs,
+	}
+}
+
+// GetUtilizationFromGlobalConfig returns nil if the utilization job is not
+// configured.
+func GetUtilizationFromGlobalConfig() *UtilizationConfig {
+	utilizationConfig := subConfig("utilization")
+	if utilizationConfig == nil {
+		return nil
+	}
+	return &UtilizationConfig{
+		CucmTrunks: utilizationConfig.GetStringSlice("cucmTrunks"),
+		Interval:   utilizationConfig.GetInt("interval"),
+		Lookback:   utilizationConfig.GetInt("lookback"),
+	}
+}
+
 func GetDatabaseFromGlobalConfig() *DatabaseConfig {
-	databaseConfig := viper.Sub("database")
+	databaseConfig := subConfig("database")
 	if databaseConfig == nil {
 		log.Fatalf("No database settings found in config file")
 		return nil
 	}
 	return &DatabaseConfig{
-		AutoMigrate: databaseConfig.GetBool("autoMigrate"),
-		Database:    databaseConfig.GetString("database"),
-		Driver:      databaseConfig.GetString("driver"),
-		Host:        databaseConfig.GetString("host"),
-		Limit:       databaseConfig.GetUint32("limit"),
-		Password:    databaseConfig.GetString("password"),
-		Path:        databaseConfig.GetString("path"),
-		Port:        databaseConfig.GetInt("port"),
-		Username:    databaseConfig.GetString("username"),
+		AutoMigrate:    databaseConfig.GetBool("autoMigrate"),
+		ConflictPolicy: databaseConfig.GetString("conflictPolicy"),
+		Database:       databaseConfig.GetString("database"),
+		Driver:         databaseConfig.GetString("driver"),
+		Host:           databaseConfig.GetString("host"),
+		Limit:          databaseConfig.GetUint32("limit"),
+		MaxOpenConns:   databaseConfig.GetInt("maxOpenConns"),
+		Password:       databaseConfig.GetString("password"),
+		Path:           databaseConfig.GetString("path"),
+		Port:           databaseConfig.GetInt("port"),
+		Username:       databaseConfig.GetString("username"),
 	}
 }
 
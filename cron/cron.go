@@ -16,28 +16,88 @@
 package cron
 
 import (
+	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron"
 	"github.com/ziondials/go-cdr/config"
 	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/logger"
 	"github.com/ziondials/go-cdr/parser"
 )
 
+// How often unhealthy directories are checked for a retry that is due.
+const retryCheckInterval = 10
+
 func RunCronJobs() {
 
-	db := database.InitDB()
+	db := database.Connect()
+	startUtilizationJob(db)
+	startVoiceQualityJob(db)
+
+	runParseJobs(db)
+}
+
+// runParseJobs parses the configured input directories every ParseInterval
+// minutes and retries the unhealthy ones as they become due. It blocks.
+func runParseJobs(db *database.DataService) {
+
 	s := gocron.NewScheduler(time.UTC)
 
-	parseInterval := config.GetParserFromGlobalConfig().ParseInterval
+	// A run that takes longer than parseInterval delays the next one instead
+	// of parsing the same files twice.
+	s.SingletonModeAll()
+
+	parserConfig := config.GetParserFromGlobalConfig()
 
 	parseDirectories := config.GetDirectoriesFromGlobalConfig()
 
-	s.Every(parseInterval).Minutes().Do(func() {
-		for _, directory := range parseDirectories {
-			parser.ParseFiles(directory.Input, directory.Output, directory.Type, directory.DeleteOriginal, db)
-		}
+	health := newDirectoryHealth(time.Duration(parserConfig.ParseInterval) * time.Minute)
+
+	s.Every(parserConfig.ParseInterval).Minutes().Do(func() {
+		parseDirectoriesConcurrently(parseDirectories, parserConfig, health, false, db)
+	})
+
+	s.Every(retryCheckInterval).Seconds().Do(func() {
+		parseDirectoriesConcurrently(parseDirectories, parserConfig, health, true, db)
 	})
 
 	s.StartBlocking()
 }
+
+// parseDirectoriesConcurrently parses up to DirectoryWorkers directories at a
+// time, each with up to FileWorkers files at a time, and returns once every
+// directory is done. Regular runs parse the healthy directories, retries the
+// unhealthy directories that are due. Nothing is parsed while the database
+// cannot be reached.
+func parseDirectoriesConcurrently(directories []config.DirectoryConfig, parserConfig *config.ParserConfig, health *directoryHealth, retry bool, db *database.DataService) {
+
+	if err := db.Ping(); err != nil {
+		logger.Error("Database Connection Error: %s, skipping run\n", err)
+		return
+	}
+
+	directoryWorkers := parserConfig.DirectoryWorkers
+	if directoryWorkers < 1 {
+		directoryWorkers = 1
+	}
+
+	slots := make(chan struct{}, directoryWorkers)
+	var wg sync.WaitGroup
+
+	for _, directory := range directories {
+		if !health.start(directory, retry, time.Now()) {
+			continue
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(directory config.DirectoryConfig) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			err := parser.ParseFiles(directory.Input, directory.Output, directory.Type, directory.DeleteOriginal, parserConfig.FileWorkers, db)
+			health.finish(directory, err, time.Now())
+		}(directory)
+	}
+
+	wg.Wait()
+}
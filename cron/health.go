@@ -0,0 +1,127 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cron
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+)
+
+const retryMinBackoff = 30 * time.Second
+
+// directoryHealth tracks the directories whose last run failed. An unhealthy
+// directory is skipped by the regular schedule and retried on its own with an
+// exponential backoff until a run succeeds.
+type directoryHealth struct {
+	mutex      sync.Mutex
+	states     map[string]*directoryState
+	maxBackoff time.Duration
+}
+
+type directoryState struct {
+	failures int
+	retryAt  time.Time
+	running  bool
+}
+
+func newDirectoryHealth(maxBackoff time.Duration) *directoryHealth {
+	if maxBackoff < retryMinBackoff {
+		maxBackoff = retryMinBackoff
+	}
+	return &directoryHealth{states: map[string]*directoryState{}, maxBackoff: maxBackoff}
+}
+
+// start reports whether the directory should be parsed now and, if so, marks
+// it as running. Regular runs only pick healthy directories, retries only pick
+// unhealthy directories whose backoff has passed.
+func (h *directoryHealth) start(directory config.DirectoryConfig, retry bool, now time.Time) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	state, ok := h.states[healthKey(directory)]
+	if !ok {
+		state = &directoryState{}
+		h.states[healthKey(directory)] = state
+	}
+
+	if state.running {
+		return false
+	}
+	unhealthy := state.failures > 0
+	if retry != unhealthy || (retry && now.Before(state.retryAt)) {
+		return false
+	}
+
+	state.running = true
+	return true
+}
+
+// admit reports whether a single file of the directory may be parsed now, as
+// watch mode does. Every file of a healthy directory is admitted, while an
+// unhealthy directory admits one file once its backoff has passed and waits for
+// its result before admitting any more. Each admitted file is reported back
+// with finish.
+func (h *directoryHealth) admit(directory config.DirectoryConfig, now time.Time) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	state, ok := h.states[healthKey(directory)]
+	if !ok {
+		state = &directoryState{}
+		h.states[healthKey(directory)] = state
+	}
+
+	if state.failures == 0 {
+		return true
+	}
+	if state.running || now.Before(state.retryAt) {
+		return false
+	}
+
+	state.running = true
+	return true
+}
+
+// finish records the result of a run started with start or a file admitted
+// with admit.
+func (h *directoryHealth) finish(directory config.DirectoryConfig, err error, now time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	state := h.states[healthKey(directory)]
+	state.running = false
+
+	if err == nil {
+		if state.failures > 0 {
+			logger.Info("Directory %s is healthy again", directory.Input)
+		}
+		state.failures = 0
+		return
+	}
+
+	state.failures++
+	backoff := helpers.ExponentialBackoff(state.failures, retryMinBackoff, h.maxBackoff)
+	state.retryAt = now.Add(backoff)
+	logger.Error("Directory %s is unhealthy, retrying in %s Error: %s", directory.Input, backoff, err)
+}
+
+func healthKey(directory config.DirectoryConfig) string {
+	return directory.Type + ":" + directory.Input
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cron
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/logger"
+)
+
+const (
+	defaultUtilizationInterval = 15
+	defaultUtilizationLookback = 24
+)
+
+// startUtilizationJob computes the trunk utilization in the background every
+// Interval minutes, if the job is configured.
+func startUtilizationJob(db *database.DataService) {
+
+	utilizationConfig := config.GetUtilizationFromGlobalConfig()
+	if utilizationConfig == nil {
+		return
+	}
+
+	interval := utilizationConfig.Interval
+	if interval <= 0 {
+		interval = defaultUtilizationInterval
+	}
+	lookback := utilizationConfig.Lookback
+	if lookback <= 0 {
+		lookback = defaultUtilizationLookback
+	}
+
+	s := gocron.NewScheduler(time.UTC)
+	s.SingletonModeAll()
+
+	s.Every(interval).Minutes().Do(func() {
+		computeUtilization(db, utilizationConfig.CucmTrunks, lookback, time.Now())
+	})
+
+	s.StartAsync()
+}
+
+// computeUtilization recomputes the utilization from the start of the hour
+// lookback hours before now up to the current minute.
+func computeUtilization(db *database.DataService, cucmTrunks []string, lookback int, now time.Time) {
+
+	if err := db.Ping(); err != nil {
+		logger.Error("Database Connection Error: %s, skipping utilization\n", err)
+		return
+	}
+
+	to := now.Truncate(time.Minute)
+	from := now.Truncate(time.Hour).Add(-time.Duration(lookback) * time.Hour)
+
+	count, err := db.ComputeTrunkUtilization(from.Unix(), to.Unix(), cucmTrunks)
+	if err != nil {
+		logger.Error("Error computing trunk utilization: %s", err)
+		return
+	}
+	logger.Debug("Computed %s trunk utilization rows from %s to %s", strconv.Itoa(count), from, to)
+}
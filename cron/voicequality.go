@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cron
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/quality"
+)
+
+const (
+	defaultVoiceQualityInterval = 15
+	defaultVoiceQualityLookback = 24
+)
+
+// startVoiceQualityJob checks the voice quality SLAs in the background every
+// Interval minutes, if they are configured.
+func startVoiceQualityJob(db *database.DataService) {
+
+	voiceQualityConfig := config.GetVoiceQualityFromGlobalConfig()
+	if voiceQualityConfig == nil {
+		return
+	}
+
+	interval := voiceQualityConfig.Interval
+	if interval <= 0 {
+		interval = defaultVoiceQualityInterval
+	}
+	lookback := voiceQualityConfig.Lookback
+	if lookback <= 0 {
+		lookback = defaultVoiceQualityLookback
+	}
+
+	s := gocron.NewScheduler(time.UTC)
+	s.SingletonModeAll()
+
+	s.Every(interval).Minutes().Do(func() {
+		computeVoiceQuality(db, lookback, time.Now())
+	})
+
+	s.StartAsync()
+}
+
+// computeVoiceQuality recomputes the voice quality from the start of the hour
+// lookback hours before now up to the current minute, and posts the alerts of
+// the hours that broke their SLA since the last run.
+func computeVoiceQuality(db *database.DataService, lookback int, now time.Time) {
+
+	if err := db.Ping(); err != nil {
+		logger.Error("Database Connection Error: %s, skipping voice quality\n", err)
+		return
+	}
+
+	to := now.Truncate(time.Minute)
+	from := now.Truncate(time.Hour).Add(-time.Duration(lookback) * time.Hour)
+
+	count, alerts, err := db.ComputeVoiceQuality(from.Unix(), to.Unix())
+	if err != nil {
+		logger.Error("Error computing voice quality: %s", err)
+		return
+	}
+	logger.Debug("Computed %s voice quality rows from %s to %s with %s new alerts", strconv.Itoa(count), from, to, strconv.Itoa(len(alerts)))
+
+	quality.Notify(alerts)
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/parser"
+)
+
+// pendingFile is a file that has been seen in an input directory but whose
+// size has not yet been stable for long enough to be parsed.
+type pendingFile struct {
+	size    int64
+	modTime time.Time
+	changed time.Time
+}
+
+// RunWatchJobs parses files as soon as they are written to the configured input
+// directories instead of waiting for the next ParseInterval. A file is only
+// parsed once its size and modification time have not changed for
+// WatchSettleTime seconds, so files that are still being uploaded are never
+// read. Every directory is still rescanned every ParseInterval minutes in case
+// an event was missed. Up to FileWorkers files per directory are parsed at a
+// time, and a directory whose files cannot be processed is backed off the same
+// way as in RunCronJobs. When no watcher can be created, e.g. because the
+// inotify limits are exhausted, the directories are polled as in RunCronJobs.
+func RunWatchJobs() {
+
+	db := database.Connect()
+	startUtilizationJob(db)
+	startVoiceQualityJob(db)
+
+	parserConfig := config.GetParserFromGlobalConfig()
+	parseDirectories := config.GetDirectoriesFromGlobalConfig()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Error("Error creating file watcher: %s, parsing every %d minutes instead", err, parserConfig.ParseInterval)
+		runParseJobs(db)
+		return
+	}
+	defer watcher.Close()
+
+	// A directory that cannot be watched, e.g. because its share is not
+	// mounted, is tried again on every rescan.
+	directories := map[string]config.DirectoryConfig{}
+	watched := map[string]bool{}
+	watch := func() {
+		for input := range directories {
+			if watched[input] {
+				continue
+			}
+			if err := watcher.Add(input); err != nil {
+				logger.Error("Error watching directory: %s Error: %s", input, err)
+				continue
+			}
+			watched[input] = true
+			logger.Info("Watching directory: %s", input)
+		}
+	}
+	for _, directory := range parseDirectories {
+		directories[filepath.Clean(directory.Input)] = directory
+	}
+	watch()
+
+	settleTime := time.Duration(parserConfig.WatchSettleTime) * time.Second
+	pending := map[string]*pendingFile{}
+
+	// Each directory has its own queue of files that are ready, worked by up
+	// to FileWorkers goroutines, so a busy directory does not hold up the rest.
+	fileWorkers := parserConfig.FileWorkers
+	if fileWorkers < 1 {
+		fileWorkers = 1
+	}
+
+	health := newDirectoryHealth(time.Duration(parserConfig.ParseInterval) * time.Minute)
+
+	var inFlight sync.Map
+	queues := map[string]chan string{}
+	for input, directory := range directories {
+		queue := make(chan string, 1024)
+		queues[input] = queue
+		for i := 0; i < fileWorkers; i++ {
+			go func(directory config.DirectoryConfig) {
+				for path := range queue {
+					var err error
+					if _, err = os.Stat(path); err == nil {
+						// A file that could not be processed is left in place
+						// and picked up again by the next rescan.
+						if _, err = parser.ParseFile(path, directory.Output, directory.Type, directory.DeleteOriginal, db); err != nil {
+							logger.Error("Error while parsing file: %s Error: %s", path, err)
+						}
+					} else if os.IsNotExist(err) {
+						err = nil
+					}
+					health.finish(directory, err, time.Now())
+					inFlight.Delete(path)
+				}
+			}(directory)
+		}
+	}
+
+	add := func(path string) {
+		if helpers.IsPartialUpload(path) {
+			return
+		}
+		if _, ok := inFlight.Load(path); ok {
+			return
+		}
+		if _, ok := pending[path]; !ok {
+			pending[path] = &pendingFile{size: -1, changed: time.Now()}
+		}
+	}
+
+	rescan := func() {
+		for input := range directories {
+			files, err := os.ReadDir(input)
+			if err != nil {
+				logger.Error("Error reading directory: %s Error: %s", input, err)
+				continue
+			}
+			for _, file := range files {
+				if !file.IsDir() {
+					add(filepath.Join(input, file.Name()))
+				}
+			}
+		}
+	}
+
+	var rescanTicker <-chan time.Time
+	if parserConfig.ParseInterval > 0 {
+		ticker := time.NewTicker(time.Duration(parserConfig.ParseInterval) * time.Minute)
+		defer ticker.Stop()
+		rescanTicker = ticker.C
+	}
+
+	settleTicker := time.NewTicker(time.Second)
+	defer settleTicker.Stop()
+
+	rescan()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			path := filepath.Clean(event.Name)
+			if _, ok := directories[path]; ok && (event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)) {
+				logger.Error("Watched directory was removed: %s", path)
+				watched[path] = false
+				continue
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				add(path)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Error("File watcher error: %s", err)
+
+		case <-rescanTicker:
+			logger.Info("Rescanning watched directories")
+			watch()
+			rescan()
+
+		case <-settleTicker.C:
+			now := time.Now()
+			for path, file := range pending {
+				info, err := os.Stat(path)
+				if err != nil || info.IsDir() {
+					delete(pending, path)
+					continue
+				}
+				if info.Size() != file.size || !info.ModTime().Equal(file.modTime) {
+					file.size = info.Size()
+					file.modTime = info.ModTime()
+					file.changed = now
+					continue
+				}
+				if now.Sub(file.changed) < settleTime {
+					continue
+				}
+				// This loop is the only sender, so a queue with room cannot
+				// block it. A file whose queue is full, or whose directory is
+				// backing off, stays pending and is tried on a later tick.
+				input := filepath.Dir(path)
+				queue := queues[input]
+				if len(queue) == cap(queue) || !health.admit(directories[input], now) {
+					continue
+				}
+				delete(pending, path)
+				inFlight.Store(path, true)
+				queue <- path
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"strings"
+
+	"github.com/ziondials/go-cdr/models"
+)
+
+// alertKey is the natural key of alerts.
+var alertKey = []string{"Rule", "Source", "CallKey"}
+
+// Number of call keys looked up per query, well below the parameter limits of
+// every driver.
+const alertLookupSize = 500
+
+// SaveAlerts writes the alerts that were not raised before and returns them. A
+// rule raises a single alert for a call, so the alerts raised again when the
+// start and stop records of a call or a file are loaded again are left out,
+// whatever the conflict policy is.
+func (ds DataService) SaveAlerts(alerts []*models.Alert) ([]*models.Alert, error) {
+
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+
+	type raisedKey struct{ rule, source, callKey string }
+	keyOf := func(rule, source, callKey *string) raisedKey {
+		return raisedKey{firstString(rule), firstString(source), firstString(callKey)}
+	}
+
+	callKeys := []string{}
+	seen := map[string]bool{}
+	for _, alert := range alerts {
+		callKey := firstString(alert.CallKey)
+		if !seen[callKey] {
+			seen[callKey] = true
+			callKeys = append(callKeys, callKey)
+		}
+	}
+
+	raised := map[raisedKey]bool{}
+	for start := 0; start < len(callKeys); start += alertLookupSize {
+		end := start + alertLookupSize
+		if end > len(callKeys) {
+			end = len(callKeys)
+		}
+
+		var existing []*models.Alert
+		rsp := ds.Session.Select("rule", "source", "call_key").Where("call_key IN ?", callKeys[start:end]).Find(&existing)
+		if rsp.Error != nil {
+			return nil, rsp.Error
+		}
+		for _, alert := range existing {
+			raised[keyOf(alert.Rule, alert.Source, alert.CallKey)] = true
+		}
+	}
+
+	created := []*models.Alert{}
+	for _, alert := range alerts {
+		key := keyOf(alert.Rule, alert.Source, alert.CallKey)
+		if raised[key] {
+			continue
+		}
+		raised[key] = true
+		created = append(created, alert)
+	}
+
+	if len(created) == 0 {
+		return nil, nil
+	}
+
+	return created, ds.writeInBatches(&created, ConflictPolicySkip, alertKey...)
+}
+
+// CountShortCalls returns the number of calls of source that started between
+// from and to, in unix time with both included, lasted at most maxDuration
+// seconds and went to an E.164 number starting with one of prefixes. CUCM calls
+// are counted from cucm_cdrs and CUBE calls from cube_calls. Calls that were
+// never answered have no duration in cube_calls and count as 0 seconds.
+func (ds DataService) CountShortCalls(source string, prefixes []string, maxDuration int64, from int64, to int64) (int64, error) {
+
+	var model interface{}
+	var numberColumn, durationColumn, timeColumn string
+	switch source {
+	case models.AlertSourceCucm:
+		model = &models.CucmCdr{}
+		numberColumn, durationColumn, timeColumn = "finalcalledpartynumber_e164", "duration", "datetimeorigination"
+	case models.AlertSourceCube:
+		model = &models.CubeCall{}
+		numberColumn, durationColumn, timeColumn = "dialed_number_e164", "duration_seconds", "setup_time"
+	default:
+		return 0, nil
+	}
+
+	conditions := []string{}
+	args := []interface{}{}
+	for _, prefix := range prefixes {
+		if prefix != "" {
+			conditions = append(conditions, numberColumn+" LIKE ?")
+			args = append(args, prefix+"%")
+		}
+	}
+	if len(conditions) == 0 {
+		return 0, nil
+	}
+
+	var count int64
+	rsp := ds.Session.Model(model).
+		Where(timeColumn+" >= ? AND "+timeColumn+" <= ?", from, to).
+		Where("COALESCE("+durationColumn+", 0) <= ?", maxDuration).
+		Where("("+strings.Join(conditions, " OR ")+")", args...).
+		Count(&count)
+	return count, rsp.Error
+}
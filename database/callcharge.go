@@ -0,0 +1,115 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"github.com/ziondials/go-cdr/models"
+	"github.com/ziondials/go-cdr/rating"
+	"gorm.io/gorm"
+)
+
+// callChargeKey is the natural key of call_charges.
+var callChargeKey = []string{"Source", "CallKey"}
+
+// Number of CallKeys deleted per query, well below the parameter limits of
+// every driver.
+const callChargeDeleteSize = 500
+
+// SaveCallCharges replaces the charges of the calls of source with callKeys by
+// charges, so a call that no longer matches a tariff loses its old charge.
+func (ds DataService) SaveCallCharges(source string, callKeys []string, charges []*models.CallCharge) error {
+
+	for start := 0; start < len(callKeys); start += callChargeDeleteSize {
+		end := start + callChargeDeleteSize
+		if end > len(callKeys) {
+			end = len(callKeys)
+		}
+		if rsp := ds.Session.Where("source = ? AND call_key IN ?", source, callKeys[start:end]).Delete(&models.CallCharge{}); rsp.Error != nil {
+			return rsp.Error
+		}
+	}
+
+	if len(charges) == 0 {
+		return nil
+	}
+
+	return ds.upsertInBatches(&charges, callChargeKey...)
+}
+
+// RateCucmCdrs replaces the charges of cdrs with their charges under the
+// configured tariffs and returns the number of CDRs that were charged.
+func (ds DataService) RateCucmCdrs(cdrs []*models.CucmCdr) (int, error) {
+
+	callKeys := make([]string, 0, len(cdrs))
+	for _, cdr := range cdrs {
+		if key := models.CucmCdrCallKey(cdr); key != nil {
+			callKeys = append(callKeys, *key)
+		}
+	}
+
+	charges := rating.RateCucmCdrs(cdrs)
+	return len(charges), ds.SaveCallCharges(models.CallChargeSourceCucm, callKeys, charges)
+}
+
+// RateCubeCalls replaces the charges of calls with their charges under the
+// configured tariffs and returns the number of calls that were charged.
+func (ds DataService) RateCubeCalls(calls []*models.CubeCall) (int, error) {
+
+	callKeys := make([]string, 0, len(calls))
+	for _, call := range calls {
+		if key := models.CubeCallCallKey(call); key != nil {
+			callKeys = append(callKeys, *key)
+		}
+	}
+
+	charges := rating.RateCubeCalls(calls)
+	return len(charges), ds.SaveCallCharges(models.CallChargeSourceCube, callKeys, charges)
+}
+
+// RateCalls replaces the charges of the CUCM CDRs and CUBE calls that started
+// between from and to, in unix time with to excluded, with the charges of the
+// configured tariffs. It returns the number of calls that were rated.
+func (ds DataService) RateCalls(from int64, to int64) (int, error) {
+
+	rated := 0
+
+	err := ds.Transaction(func(tx DataService) error {
+
+		if rsp := tx.Session.Where("start_time >= ? AND start_time < ?", from, to).Delete(&models.CallCharge{}); rsp.Error != nil {
+			return rsp.Error
+		}
+
+		var cdrs []*models.CucmCdr
+		rsp := tx.Session.Where("datetimeorigination >= ? AND datetimeorigination < ?", from, to).FindInBatches(&cdrs, int(tx.Config.Limit), func(_ *gorm.DB, _ int) error {
+			count, err := tx.RateCucmCdrs(cdrs)
+			rated += count
+			return err
+		})
+		if rsp.Error != nil {
+			return rsp.Error
+		}
+
+		var calls []*models.CubeCall
+		rsp = tx.Session.Where("setup_time >= ? AND setup_time < ?", from, to).FindInBatches(&calls, int(tx.Config.Limit), func(_ *gorm.DB, _ int) error {
+			count, err := tx.RateCubeCalls(calls)
+			rated += count
+			return err
+		})
+		return rsp.Error
+	})
+
+	return rated, err
+}
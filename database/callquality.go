@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import "gorm.io/gorm"
+
+const callQualityView = "call_quality"
+
+// The call_quality view has one row per CMR that has been correlated with its
+// CDR, with the voice quality of the leg next to the numbers of the call.
+// Jitter, latency and one way delay are in milliseconds.
+const callQualityQuery = `SELECT
+	cucm_cmrs.id AS cucm_cmr_id,
+	cucm_cdrs.id AS cucm_cdr_id,
+	cucm_cmrs.cucm_cdr_leg AS leg,
+	cucm_cdrs.globalcallid_clusterid AS globalcallid_clusterid,
+	cucm_cdrs.globalcallid_callmanagerid AS globalcallid_callmanagerid,
+	cucm_cdrs.globalcallid_callid AS globalcallid_callid,
+	cucm_cdrs.datetimeorigination AS datetimeorigination,
+	cucm_cdrs.datetimeconnect AS datetimeconnect,
+	cucm_cdrs.datetimedisconnect AS datetimedisconnect,
+	cucm_cdrs.duration AS duration,
+	cucm_cdrs.callingpartynumber AS callingpartynumber,
+	cucm_cdrs.originalcalledpartynumber AS originalcalledpartynumber,
+	cucm_cdrs.finalcalledpartynumber AS finalcalledpartynumber,
+	cucm_cmrs.directorynum AS directorynum,
+	cucm_cmrs.devicename AS devicename,
+	cucm_cmrs.vqvorxcodec AS codec,
+	cucm_cmrs.vqvorxcodec_family AS codec_family,
+	cucm_cmrs.vqmlqk AS mos,
+	cucm_cmrs.vqmlqkav AS mos_average,
+	cucm_cmrs.vqmlqkmn AS mos_min,
+	cucm_cmrs.vqmlqkmx AS mos_max,
+	cucm_cmrs.jitter AS jitter,
+	cucm_cmrs.vqmaxjitter AS max_jitter,
+	cucm_cmrs.latency AS latency,
+	cucm_cmrs.vqvoonewaydelayms AS one_way_delay,
+	cucm_cmrs.numberpacketsreceived AS packets_received,
+	cucm_cmrs.numberpacketslost AS packets_lost,
+	CASE WHEN cucm_cmrs.numberpacketsreceived + cucm_cmrs.numberpacketslost > 0
+		THEN 100.0 * cucm_cmrs.numberpacketslost / (cucm_cmrs.numberpacketsreceived + cucm_cmrs.numberpacketslost)
+	END AS loss_percent,
+	cucm_cmrs.vqccr AS concealment_ratio
+FROM cucm_cmrs
+INNER JOIN cucm_cdrs ON cucm_cdrs.id = cucm_cmrs.cucm_cdr_id`
+
+// Recreates the call_quality view, so its definition follows the tables.
+func migrateCallQualityView(db *gorm.DB) error {
+	if err := db.Migrator().DropView(callQualityView); err != nil {
+		return err
+	}
+	return db.Exec("CREATE VIEW " + callQualityView + " AS " + callQualityQuery).Error
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import "github.com/ziondials/go-cdr/models"
+
+// SaveCmsRecords merges the calls and call legs of a batch of Cisco Meeting
+// Server records.
+func (ds DataService) SaveCmsRecords(calls []*models.CmsCall, legs []*models.CmsCallLeg) error {
+
+	if err := ds.SaveCmsCalls(calls); err != nil {
+		return err
+	}
+
+	return ds.SaveCmsCallLegs(legs)
+}
+
+// SaveCmsCalls merges every record into the call with the same ID, creating
+// the call from its first record. Only the fields a record carries are written,
+// so the records of a call can be saved in any number of batches. Records are
+// merged rather than inserted, so the conflict policy does not apply.
+func (ds DataService) SaveCmsCalls(calls []*models.CmsCall) error {
+
+	for _, call := range calls {
+		if rsp := ds.Session.Where("id = ?", call.ID).Assign(call).FirstOrCreate(&models.CmsCall{}); rsp.Error != nil {
+			return rsp.Error
+		}
+	}
+
+	return nil
+}
+
+// SaveCmsCallLegs merges every record into the call leg with the same ID, in
+// the same way as SaveCmsCalls.
+func (ds DataService) SaveCmsCallLegs(legs []*models.CmsCallLeg) error {
+
+	for _, leg := range legs {
+		if rsp := ds.Session.Where("id = ?", leg.ID).Assign(leg).FirstOrCreate(&models.CmsCallLeg{}); rsp.Error != nil {
+			return rsp.Error
+		}
+	}
+
+	return nil
+}
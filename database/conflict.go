@@ -0,0 +1,238 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Conflict policies applied when an inserted record has the same natural key
+// as a row that is already in the database.
+const (
+	ConflictPolicySkip   = "skip"
+	ConflictPolicyUpdate = "update"
+	ConflictPolicyFail   = "fail"
+)
+
+// ErrConflict is returned under ConflictPolicyFail when a record has the same
+// natural key as a row that is already in the database.
+var ErrConflict = errors.New("record already exists")
+
+// SQL Server rejects statements with more than 2100 parameters.
+const mssqlMaxParameters = 2000
+
+// createInBatches inserts the slice that records points to in batches of
+// Config.Limit, resolving collisions on keyFields according to
+// Config.ConflictPolicy. keyFields are the Go field names of the natural key.
+func (ds DataService) createInBatches(records interface{}, keyFields ...string) error {
+
+	return ds.writeInBatches(records, ds.Config.ConflictPolicy, keyFields...)
+}
+
+// upsertInBatches writes records that are derived from other tables, which are
+// always replaced by their latest version whatever the conflict policy is.
+func (ds DataService) upsertInBatches(records interface{}, keyFields ...string) error {
+
+	return ds.writeInBatches(records, ConflictPolicyUpdate, keyFields...)
+}
+
+func (ds DataService) writeInBatches(records interface{}, conflictPolicy string, keyFields ...string) error {
+
+	stmt := &gorm.Statement{DB: ds.Session}
+	if err := stmt.Parse(records); err != nil {
+		return err
+	}
+
+	keys := make([]*schema.Field, 0, len(keyFields))
+	for _, name := range keyFields {
+		field := stmt.Schema.LookUpField(name)
+		if field == nil {
+			return fmt.Errorf("natural key field %s not found on %s", name, stmt.Schema.Name)
+		}
+		keys = append(keys, field)
+	}
+
+	coalesceKeys(reflect.ValueOf(records).Elem(), keys)
+
+	if conflictPolicy == ConflictPolicyFail {
+		err := ds.Session.CreateInBatches(records, int(ds.Config.Limit)).Error
+		if isDuplicatedKey(ds.Session, err) {
+			return fmt.Errorf("%w: %s", ErrConflict, err)
+		}
+		return err
+	}
+
+	update := conflictPolicy == ConflictPolicyUpdate
+	rows := dedupeByKey(stmt, reflect.ValueOf(records).Elem(), keys, update)
+	if rows.Len() == 0 {
+		return nil
+	}
+
+	if ds.Config.Driver == "mssql" {
+		return ds.mergeInBatches(stmt, rows, keys, update)
+	}
+
+	columns := make([]clause.Column, 0, len(keys))
+	for _, field := range keys {
+		columns = append(columns, clause.Column{Name: field.DBName})
+	}
+
+	onConflict := clause.OnConflict{Columns: columns, DoNothing: !update, UpdateAll: update}
+
+	return ds.Session.Clauses(onConflict).CreateInBatches(rows.Addr().Interface(), int(ds.Config.Limit)).Error
+}
+
+// isDuplicatedKey reports whether err is the unique constraint violation of the
+// driver of db.
+func isDuplicatedKey(db *gorm.DB, err error) bool {
+	if err == nil {
+		return false
+	}
+	translator, ok := db.Dialector.(gorm.ErrorTranslator)
+	return ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
+}
+
+// coalesceKeys sets the key fields of rows that are nil to their zero value.
+// Key columns are not null, as a unique index treats NULLs as distinct and
+// would never match a key with a NULL in it.
+func coalesceKeys(rows reflect.Value, keys []*schema.Field) {
+	for i := 0; i < rows.Len(); i++ {
+		row := reflect.Indirect(rows.Index(i))
+		for _, field := range keys {
+			value := row.FieldByIndex(field.StructField.Index)
+			if value.Kind() == reflect.Ptr && value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+		}
+	}
+}
+
+// dedupeByKey drops records that share a natural key with another record in
+// the same slice, keeping the first one for skip and the last one for update.
+// A single INSERT ... ON CONFLICT DO UPDATE or MERGE may not touch a row twice.
+func dedupeByKey(stmt *gorm.Statement, rows reflect.Value, keys []*schema.Field, keepLast bool) reflect.Value {
+
+	seen := map[string]int{}
+	deduped := reflect.MakeSlice(rows.Type(), 0, rows.Len())
+
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		key := naturalKey(stmt, reflect.Indirect(row), keys)
+		if index, ok := seen[key]; ok {
+			if keepLast {
+				deduped.Index(index).Set(row)
+			}
+			continue
+		}
+		seen[key] = deduped.Len()
+		deduped = reflect.Append(deduped, row)
+	}
+
+	result := reflect.New(rows.Type()).Elem()
+	result.Set(deduped)
+	return result
+}
+
+func naturalKey(stmt *gorm.Statement, row reflect.Value, keys []*schema.Field) string {
+	parts := make([]string, 0, len(keys))
+	for _, field := range keys {
+		value, isZero := field.ValueOf(stmt.Context, row)
+		if isZero {
+			parts = append(parts, "<nil>")
+			continue
+		}
+		parts = append(parts, fmt.Sprint(reflect.Indirect(reflect.ValueOf(value)).Interface()))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// mergeInBatches is the SQL Server equivalent of INSERT ... ON CONFLICT. The
+// sqlserver driver only builds MERGE statements against the primary key, which
+// is a random UUID here, so the statement is built against the natural key.
+func (ds DataService) mergeInBatches(stmt *gorm.Statement, rows reflect.Value, keys []*schema.Field, update bool) error {
+
+	columns := stmt.Schema.DBNames
+
+	batchSize := int(ds.Config.Limit)
+	if maxRows := mssqlMaxParameters / len(columns); batchSize > maxRows || batchSize <= 0 {
+		batchSize = maxRows
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	quotedColumns := make([]string, 0, len(columns))
+	excludedColumns := make([]string, 0, len(columns))
+	assignments := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted := stmt.Quote(column)
+		quotedColumns = append(quotedColumns, quoted)
+		excludedColumns = append(excludedColumns, "excluded."+quoted)
+		if field := stmt.Schema.FieldsByDBName[column]; field != nil && !field.PrimaryKey {
+			assignments = append(assignments, "target."+quoted+" = excluded."+quoted)
+		}
+	}
+
+	conditions := make([]string, 0, len(keys))
+	for _, field := range keys {
+		quoted := stmt.Quote(field.DBName)
+		conditions = append(conditions, fmt.Sprintf("target.%[1]s = excluded.%[1]s", quoted))
+	}
+
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+
+	for start := 0; start < rows.Len(); start += batchSize {
+		end := start + batchSize
+		if end > rows.Len() {
+			end = rows.Len()
+		}
+
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for i := start; i < end; i++ {
+			row := reflect.Indirect(rows.Index(i))
+			for _, column := range columns {
+				value, _ := stmt.Schema.FieldsByDBName[column].ValueOf(stmt.Context, row)
+				args = append(args, value)
+			}
+			values = append(values, placeholders)
+		}
+
+		var sql strings.Builder
+		sql.WriteString("MERGE INTO " + stmt.Quote(stmt.Table) + " WITH (HOLDLOCK) AS target")
+		sql.WriteString(" USING (VALUES " + strings.Join(values, ",") + ")")
+		sql.WriteString(" AS excluded (" + strings.Join(quotedColumns, ",") + ")")
+		sql.WriteString(" ON " + strings.Join(conditions, " AND "))
+		if update && len(assignments) > 0 {
+			sql.WriteString(" WHEN MATCHED THEN UPDATE SET " + strings.Join(assignments, ","))
+		}
+		sql.WriteString(" WHEN NOT MATCHED THEN INSERT (" + strings.Join(quotedColumns, ",") + ")")
+		sql.WriteString(" VALUES (" + strings.Join(excludedColumns, ",") + ");")
+
+		if rsp := ds.Session.Exec(sql.String(), args...); rsp.Error != nil {
+			return rsp.Error
+		}
+	}
+
+	return nil
+}
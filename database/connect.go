@@ -0,0 +1,46 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+)
+
+const (
+	connectMinBackoff = 30 * time.Second
+	connectMaxBackoff = 30 * time.Minute
+)
+
+// Connect connects to the database, retrying with backoff for as long as it
+// cannot be reached. Invalid database settings are fatal.
+func Connect() *DataService {
+	for failures := 1; ; failures++ {
+		db, err := InitDB()
+		if err == nil {
+			return db
+		}
+		if errors.Is(err, ErrInvalidConfig) {
+			logger.Fatal("Database Connection Error: %s\n", err)
+		}
+		backoff := helpers.ExponentialBackoff(failures, connectMinBackoff, connectMaxBackoff)
+		logger.Error("Database Connection Error: %s, retrying in %s\n", err, backoff)
+		time.Sleep(backoff)
+	}
+}
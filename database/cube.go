@@ -17,11 +17,41 @@ package database
 
 import "github.com/ziondials/go-cdr/models"
 
+// cubeCDRKey is the natural key of cube_cdrs.
+var cubeCDRKey = []string{"Hostname", "CallId", "H323ConfId", "LegType", "CdrType"}
+
 func (ds DataService) CreateCubeCDRs(cdrs []*models.CubeCDR) error {
 
-	if rsp := ds.Session.CreateInBatches(&cdrs, int(ds.Config.Limit)); rsp.Error != nil {
-		return rsp.Error
+	return ds.createInBatches(&cdrs, cubeCDRKey...)
+}
+
+// SaveCubeCDRs writes cdrs along with their feature events and rebuilds the
+// cube_calls rows of their calls.
+func (ds DataService) SaveCubeCDRs(cdrs []*models.CubeCDR) error {
+
+	if err := ds.CreateCubeCDRs(cdrs); err != nil {
+		return err
+	}
+
+	events := []*models.CubeFeatureEvent{}
+	for _, cdr := range cdrs {
+		events = append(events, cdr.FeatureEvents...)
+	}
+	if err := ds.CreateCubeFeatureEvents(events); err != nil {
+		return err
+	}
+
+	return ds.StitchCubeCalls(cdrs)
+}
+
+// cubeFeatureEventKey is the natural key of cube_feature_events.
+var cubeFeatureEventKey = []string{"Hostname", "H323ConfId", "CallId", "Feature", "FeatureTime", "FeatureId"}
+
+func (ds DataService) CreateCubeFeatureEvents(events []*models.CubeFeatureEvent) error {
+
+	if len(events) == 0 {
+		return nil
 	}
 
-	return nil
+	return ds.createInBatches(&events, cubeFeatureEventKey...)
 }
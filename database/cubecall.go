@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import "github.com/ziondials/go-cdr/models"
+
+// cubeCallKey is the natural key of cube_calls.
+var cubeCallKey = []string{"Hostname", "H323ConfId"}
+
+// Number of H323ConfIds looked up per query, well below the parameter limits of
+// every driver.
+const cubeCallLookupSize = 500
+
+// StitchCubeCalls rebuilds the cube_calls rows of the calls that cdrs belong
+// to, along with their charges. The legs of a call can arrive in different
+// files or requests, so every stored CubeCDR of the call is read back rather
+// than only cdrs.
+func (ds DataService) StitchCubeCalls(cdrs []*models.CubeCDR) error {
+
+	type callKey struct{ hostname, confId string }
+
+	keys := map[callKey]bool{}
+	confIds := []string{}
+	for _, cdr := range cdrs {
+		// A leg without a conference ID cannot be matched to the other legs
+		// of its call. CDRs are written with an empty rather than a NULL key.
+		if cdr.Hostname == nil || cdr.H323ConfId == nil || *cdr.H323ConfId == "" {
+			continue
+		}
+		key := callKey{*cdr.Hostname, *cdr.H323ConfId}
+		if keys[key] {
+			continue
+		}
+		keys[key] = true
+		confIds = append(confIds, *cdr.H323ConfId)
+	}
+
+	calls := []*models.CubeCall{}
+	for start := 0; start < len(confIds); start += cubeCallLookupSize {
+		end := start + cubeCallLookupSize
+		if end > len(confIds) {
+			end = len(confIds)
+		}
+
+		var legs []*models.CubeCDR
+		if rsp := ds.Session.Where("h323_conf_id IN ?", confIds[start:end]).Find(&legs); rsp.Error != nil {
+			return rsp.Error
+		}
+
+		grouped := map[callKey][]*models.CubeCDR{}
+		for _, leg := range legs {
+			if leg.Hostname == nil || leg.H323ConfId == nil {
+				continue
+			}
+			key := callKey{*leg.Hostname, *leg.H323ConfId}
+			if keys[key] {
+				grouped[key] = append(grouped[key], leg)
+			}
+		}
+
+		for _, legs := range grouped {
+			calls = append(calls, models.NewCubeCall(legs))
+		}
+	}
+
+	if len(calls) == 0 {
+		return nil
+	}
+
+	if err := ds.upsertInBatches(&calls, cubeCallKey...); err != nil {
+		return err
+	}
+
+	_, err := ds.RateCubeCalls(calls)
+	return err
+}
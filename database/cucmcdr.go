@@ -19,9 +19,5 @@ import "github.com/ziondials/go-cdr/models"
 
 func (ds DataService) CreateCucmCDRs(cdrs []*models.CucmCdr) error {
 
-	if rsp := ds.Session.CreateInBatches(&cdrs, int(ds.Config.Limit)); rsp.Error != nil {
-		return rsp.Error
-	}
-
-	return nil
+	return ds.createInBatches(&cdrs, "OriginPkid")
 }
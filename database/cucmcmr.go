@@ -15,12 +15,42 @@
 
 package database
 
-import "github.com/ziondials/go-cdr/models"
+import (
+	"fmt"
+
+	"github.com/ziondials/go-cdr/models"
+)
 
 func (ds DataService) CreateCucmCMRs(cdrs []*models.CucmCmr) error {
 
-	if rsp := ds.Session.CreateInBatches(&cdrs, int(ds.Config.Limit)); rsp.Error != nil {
-		return rsp.Error
+	return ds.createInBatches(&cdrs, "Originpkid")
+}
+
+// A CMR belongs to the CDR with the same global call ID whose orig or dest leg
+// call identifier is the call identifier of the CMR.
+const cucmCmrCdrMatch = `FROM cucm_cdrs
+	WHERE cucm_cdrs.globalcallid_callmanagerid = cucm_cmrs.globalcallid_callmanagerid
+	AND cucm_cdrs.globalcallid_callid = cucm_cmrs.globalcallid_callid
+	AND (cucm_cdrs.globalcallid_clusterid = cucm_cmrs.globalcallid_clusterid OR cucm_cdrs.globalcallid_clusterid IS NULL OR cucm_cmrs.globalcallid_clusterid IS NULL)
+	AND cucm_cdrs.%slegcallidentifier = cucm_cmrs.callidentifier`
+
+// CorrelateCucmCMRs attaches the CMRs of the given global call IDs that are
+// not attached yet to the leg of their CDR. CDR and CMR files are written
+// independently, so this runs for every chunk of either and picks up the CMRs
+// whose CDR arrived later.
+func (ds DataService) CorrelateCucmCMRs(callIDs []int64) error {
+
+	if len(callIDs) == 0 {
+		return nil
+	}
+
+	for _, leg := range []string{models.CucmCdrLegOrig, models.CucmCdrLegDest} {
+		match := fmt.Sprintf(cucmCmrCdrMatch, leg)
+		sql := "UPDATE cucm_cmrs SET cucm_cdr_id = (SELECT MIN(cucm_cdrs.id) " + match + "), cucm_cdr_leg = ?" +
+			" WHERE cucm_cdr_id IS NULL AND globalcallid_callid IN ? AND EXISTS (SELECT 1 " + match + ")"
+		if rsp := ds.Session.Exec(sql, leg, callIDs); rsp.Error != nil {
+			return rsp.Error
+		}
 	}
 
 	return nil
@@ -16,6 +16,7 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/ziondials/go-cdr/config"
@@ -35,82 +36,122 @@ type DataService struct {
 	Config  *config.DatabaseConfig
 }
 
+// Transaction runs fn with a DataService bound to a single database
+// transaction. The transaction is committed if fn returns nil and rolled back
+// otherwise.
+func (ds DataService) Transaction(fn func(tx DataService) error) error {
+	return ds.Session.Transaction(func(tx *gorm.DB) error {
+		return fn(DataService{Session: tx, Config: ds.Config})
+	})
+}
+
+// ErrInvalidConfig is returned by InitDB when the database settings are wrong,
+// as opposed to the database being unreachable. Retrying will not help.
+var ErrInvalidConfig = errors.New("invalid database configuration")
+
 // Provides a pointer to a databse connection for a given configuration.
-func InitDB() *DataService {
+func InitDB() (*DataService, error) {
 
 	dbConfig := config.GetDatabaseFromGlobalConfig()
 
+	switch dbConfig.ConflictPolicy {
+	case ConflictPolicySkip, ConflictPolicyUpdate, ConflictPolicyFail:
+	default:
+		return nil, fmt.Errorf("%w: invalid conflict policy %s", ErrInvalidConfig, dbConfig.ConflictPolicy)
+	}
+
+	var dialector gorm.Dialector
+	var name string
+	maxOpenConns := dbConfig.MaxOpenConns
+
 	switch dbConfig.Driver {
 
 	case "mysql":
 		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?tls=%scharset=utf8&parseTime=True&loc=Local", dbConfig.Username, dbConfig.Password, dbConfig.Host, dbConfig.Port, dbConfig.Database, dbConfig.SSL)
-		db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-			Logger: glogger.Default.LogMode(glogger.Silent),
-		})
-		if err != nil {
-			logger.Fatal("Database Connection Error: %s\n", err)
-		}
-		logger.Info("Connected to MySQL database.\n")
-		if dbConfig.AutoMigrate {
-			migrate(db)
-		}
-		return &DataService{Session: db, Config: dbConfig}
+		dialector = mysql.Open(dsn)
+		name = "MySQL"
 
 	case "mssql":
 		dsn := fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s", dbConfig.Username, dbConfig.Password, dbConfig.Host, dbConfig.Port, dbConfig.Database)
-		db, err := gorm.Open(sqlserver.Open(dsn), &gorm.Config{
-			Logger: glogger.Default.LogMode(glogger.Silent),
-		})
-		if err != nil {
-			logger.Fatal("Database Connection Error: %s\n", err)
-		}
-		logger.Info("Connected to Microsoft SQL Server database.\n")
-		if dbConfig.AutoMigrate {
-			migrate(db)
-		}
-		return &DataService{Session: db, Config: dbConfig}
+		dialector = sqlserver.Open(dsn)
+		name = "Microsoft SQL Server"
 
 	case "postgres":
 		dsn := fmt.Sprintf("host=%s port=%d user=%s dbname=%s password=%s sslmode=%s", dbConfig.Host, dbConfig.Port, dbConfig.Username, dbConfig.Database, dbConfig.Password, dbConfig.SSL)
-		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-			Logger: glogger.Default.LogMode(glogger.Silent),
-		})
-		if err != nil {
-			logger.Fatal("Database Connection Error: %s\n", err)
-		}
-		logger.Info("Connected to PostgreSQL database.\n")
-		if dbConfig.AutoMigrate {
-			migrate(db)
-		}
-		return &DataService{Session: db, Config: dbConfig}
+		dialector = postgres.Open(dsn)
+		name = "PostgreSQL"
+
 	case "sqlite":
-		db, err := gorm.Open(sqlite.Open(dbConfig.Path), &gorm.Config{
-			Logger: glogger.Default.LogMode(glogger.Silent),
-		})
-		if err != nil {
-			logger.Panic("Database Connection %s\n", err)
-		}
-		logger.Info("Connected to SQLite database.")
-		if dbConfig.AutoMigrate {
-			migrate(db)
-		}
-		return &DataService{Session: db, Config: dbConfig}
+		dialector = sqlite.Open(dbConfig.Path)
+		name = "SQLite"
+		// SQLite only allows one writer at a time.
+		maxOpenConns = 1
+
 	case "":
-		logger.Fatal("No database driver specified.\n")
-		return nil
+		return nil, fmt.Errorf("%w: no database driver specified", ErrInvalidConfig)
 
 	default:
-		logger.Fatal("Database Connection Error: invalid driver %s.\n", dbConfig.Driver)
-		return nil
+		return nil, fmt.Errorf("%w: invalid driver %s", ErrInvalidConfig, dbConfig.Driver)
 	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: glogger.Default.LogMode(glogger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("database connection error: %w", err)
+	}
+	logger.Info("Connected to %s database.\n", name)
+
+	if err := setMaxOpenConns(db, maxOpenConns); err != nil {
+		return nil, fmt.Errorf("database connection error: %w", err)
+	}
+
+	if dbConfig.AutoMigrate {
+		if err := migrate(db); err != nil {
+			return nil, fmt.Errorf("database migration error: %w", err)
+		}
+	}
+
+	return &DataService{Session: db, Config: dbConfig}, nil
+}
+
+// Ping checks that the database can be reached. Connections that were lost
+// are reopened by the connection pool the next time they are needed.
+func (ds DataService) Ping() error {
+	sqlDB, err := ds.Session.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
+
+// Caps the number of connections the pool opens, shared by every directory and
+// file being parsed at the same time. Zero means no limit.
+func setMaxOpenConns(db *gorm.DB, maxOpenConns int) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(maxOpenConns)
+	return nil
 }
 
 // This method migrates all tables in the database
-func migrate(db *gorm.DB) {
+func migrate(db *gorm.DB) error {
 	logger.Info("Migrating database...\n")
-	err := db.AutoMigrate(&models.CubeCDR{}, &models.CucmCdr{}, &models.CucmCmr{})
+	if err := prepareUniqueKeys(db); err != nil {
+		return err
+	}
+	err := db.AutoMigrate(&models.CubeCDR{}, &models.CubeCall{}, &models.CubeFeatureEvent{}, &models.CucmCdr{}, &models.CucmCmr{}, &models.OracleCDR{}, &models.CmsCall{}, &models.CmsCallLeg{}, &models.IngestedFile{}, &models.Q850Cause{}, &models.CucmRedirectReasonCode{}, &models.CucmOnBehalfOfCode{}, &models.CucmRoutingReasonCode{}, &models.Codec{}, &models.CucmCodecType{}, &models.CallCharge{}, &models.TrunkUtilization{}, &models.Alert{}, &models.VoiceQualityHour{})
 	if err != nil {
-		logger.Fatal("Database Migration Error: %s\n", err)
+		return err
+	}
+	if err := migrateCallQualityView(db); err != nil {
+		return err
+	}
+	if err := seedLookupTables(db); err != nil {
+		return err
 	}
 	logger.Info("Database migration complete.\n")
+	return nil
 }
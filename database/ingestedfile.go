@@ -0,0 +1,49 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"errors"
+
+	"github.com/ziondials/go-cdr/models"
+	"gorm.io/gorm"
+)
+
+func (ds DataService) CreateIngestedFile(file *models.IngestedFile) error {
+
+	if rsp := ds.Session.Create(file); rsp.Error != nil {
+		return rsp.Error
+	}
+
+	return nil
+}
+
+// FindCompletedIngestedFile returns the ledger entry of a file with the given
+// SHA-256 that was loaded successfully, or nil if there is none.
+func (ds DataService) FindCompletedIngestedFile(sha256 string) (*models.IngestedFile, error) {
+
+	var file models.IngestedFile
+
+	rsp := ds.Session.Where("sha256 = ? AND status = ?", sha256, models.IngestionStatusComplete).First(&file)
+	if errors.Is(rsp.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if rsp.Error != nil {
+		return nil, rsp.Error
+	}
+
+	return &file, nil
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"github.com/ziondials/go-cdr/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Writes the built-in reference data to the lookup tables, replacing rows whose
+// description changed in a newer release.
+func seedLookupTables(db *gorm.DB) error {
+	tables := []interface{}{
+		&models.Q850Causes,
+		models.CucmRedirectReasonCodes(),
+		models.CucmOnBehalfOfCodes(),
+		models.CucmRoutingReasonCodes(),
+		&models.Codecs,
+		models.CucmCodecTypes(),
+	}
+
+	for _, rows := range tables {
+		if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(rows).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
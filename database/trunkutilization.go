@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"path"
+
+	"github.com/ziondials/go-cdr/models"
+	"gorm.io/gorm"
+)
+
+// trunkUtilizationKey is the natural key of trunk_utilizations.
+var trunkUtilizationKey = []string{"Source", "Gateway", "Trunk", "Granularity", "IntervalStart"}
+
+// ComputeTrunkUtilization replaces the trunk_utilizations rows from from to to,
+// in unix time with to excluded, with the utilization of the calls stored in
+// that time. from should be a whole hour. The legs of CUBE gateways are
+// counted per trunk, and their calls once per gateway. CUCM calls are counted
+// for the devices whose names match one of cucmTrunks. It returns the number of
+// rows that were written.
+func (ds DataService) ComputeTrunkUtilization(from int64, to int64, cucmTrunks []string) (int, error) {
+
+	counter := models.NewTrunkUtilizationCounter(from, to)
+
+	// Only the columns utilization is computed from are read, in batches of
+	// Config.Limit rows, and each batch is counted before the next is read.
+	var legs []*models.CubeCDR
+	rsp := ds.Session.
+		Select("id", "hostname", "in_trunkgroup_label", "out_trunkgroup_label", "peer_address", "h323_setup_time", "h323_disconnect_time").
+		Where("h323_setup_time < ? AND h323_disconnect_time > ? AND h323_setup_time > 0", to, from).
+		FindInBatches(&legs, int(ds.Config.Limit), func(_ *gorm.DB, _ int) error {
+			for _, leg := range legs {
+				trunk := firstString(leg.InTrunkgroupLabel, leg.OutTrunkgroupLabel, leg.PeerAddress)
+				if trunk == "" {
+					continue
+				}
+				counter.Add(models.TrunkSpan{
+					Source:  models.UtilizationSourceCube,
+					Gateway: firstString(leg.Hostname),
+					Trunk:   trunk,
+					Start:   *leg.H323SetupTime,
+					End:     *leg.H323DisconnectTime,
+				})
+			}
+			return nil
+		})
+	if rsp.Error != nil {
+		return 0, rsp.Error
+	}
+
+	var calls []*models.CubeCall
+	rsp = ds.Session.
+		Select("id", "hostname", "setup_time", "disconnect_time").
+		Where("setup_time < ? AND disconnect_time > ? AND setup_time > 0", to, from).
+		FindInBatches(&calls, int(ds.Config.Limit), func(_ *gorm.DB, _ int) error {
+			for _, call := range calls {
+				counter.Add(models.TrunkSpan{
+					Source:  models.UtilizationSourceCube,
+					Gateway: firstString(call.Hostname),
+					Trunk:   models.UtilizationAllTrunks,
+					Start:   *call.SetupTime,
+					End:     *call.DisconnectTime,
+				})
+			}
+			return nil
+		})
+	if rsp.Error != nil {
+		return 0, rsp.Error
+	}
+
+	if len(cucmTrunks) > 0 {
+		var cdrs []*models.CucmCdr
+		rsp = ds.Session.
+			Select("id", "globalcallid_clusterid", "origdevicename", "destdevicename", "datetimeorigination", "datetimedisconnect").
+			Where("datetimeorigination < ? AND datetimedisconnect > ? AND datetimeorigination > 0", to, from).
+			FindInBatches(&cdrs, int(ds.Config.Limit), func(_ *gorm.DB, _ int) error {
+				for _, cdr := range cdrs {
+					for _, span := range cucmTrunkSpans(cdr, cucmTrunks) {
+						counter.Add(span)
+					}
+				}
+				return nil
+			})
+		if rsp.Error != nil {
+			return 0, rsp.Error
+		}
+	}
+
+	utilizations := counter.Utilizations()
+
+	err := ds.Transaction(func(tx DataService) error {
+		if rsp := tx.Session.Where("interval_start >= ? AND interval_start < ?", from, to).Delete(&models.TrunkUtilization{}); rsp.Error != nil {
+			return rsp.Error
+		}
+		if len(utilizations) == 0 {
+			return nil
+		}
+		return tx.upsertInBatches(&utilizations, trunkUtilizationKey...)
+	})
+
+	return len(utilizations), err
+}
+
+// cucmTrunkSpans returns the spans of a CUCM call on the trunks among its
+// devices, and on all trunks of its cluster if there are any. A call whose
+// orig and dest devices are the same trunk is counted once on it.
+func cucmTrunkSpans(cdr *models.CucmCdr, cucmTrunks []string) []models.TrunkSpan {
+
+	cluster := firstString(cdr.Globalcallid_Clusterid)
+	spans := []models.TrunkSpan{}
+	trunks := map[string]bool{}
+	for _, device := range []*string{cdr.Origdevicename, cdr.Destdevicename} {
+		if device == nil || trunks[*device] || !matchesAny(*device, cucmTrunks) {
+			continue
+		}
+		trunks[*device] = true
+		spans = append(spans, models.TrunkSpan{
+			Source:  models.UtilizationSourceCucm,
+			Gateway: cluster,
+			Trunk:   *device,
+			Start:   *cdr.Datetimeorigination,
+			End:     *cdr.Datetimedisconnect,
+		})
+	}
+	if len(trunks) > 0 {
+		spans = append(spans, models.TrunkSpan{
+			Source:  models.UtilizationSourceCucm,
+			Gateway: cluster,
+			Trunk:   models.UtilizationAllTrunks,
+			Start:   *cdr.Datetimeorigination,
+			End:     *cdr.Datetimedisconnect,
+		})
+	}
+	return spans
+}
+
+func firstString(values ...*string) string {
+	for _, value := range values {
+		if value != nil && *value != "" {
+			return *value
+		}
+	}
+	return ""
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
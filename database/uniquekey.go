@@ -0,0 +1,153 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// uniqueKey is the unique index of a table on the natural key that its records
+// are written with, as Go field names.
+type uniqueKey struct {
+	model  interface{}
+	index  string
+	fields []string
+}
+
+var uniqueKeys = []uniqueKey{
+	{&models.CubeCDR{}, "cube_cdr_index", cubeCDRKey},
+	{&models.CubeCall{}, "cube_call_index", cubeCallKey},
+	{&models.CubeFeatureEvent{}, "cube_feature_event_index", cubeFeatureEventKey},
+	{&models.OracleCDR{}, "oracle_cdr_index", oracleCDRKey},
+	{&models.CallCharge{}, "call_charge_index", callChargeKey},
+	{&models.TrunkUtilization{}, "trunk_utilization_index", trunkUtilizationKey},
+	{&models.Alert{}, "alert_index", alertKey},
+	{&models.VoiceQualityHour{}, "voice_quality_hour_index", voiceQualityHourKey},
+}
+
+// prepareUniqueKeys readies the tables that already exist for their unique
+// index before AutoMigrate creates it and makes its columns not null. NULL keys
+// are set to their zero value, as coalesceKeys does for new records, and only
+// one of the rows that share a key is kept. Tables whose index exists on
+// columns that are not null are left alone.
+func prepareUniqueKeys(db *gorm.DB) error {
+	for _, key := range uniqueKeys {
+		if err := prepareUniqueKey(db, key); err != nil {
+			return fmt.Errorf("preparing %s: %w", key.index, err)
+		}
+	}
+	return nil
+}
+
+func prepareUniqueKey(db *gorm.DB, key uniqueKey) error {
+
+	migrator := db.Migrator()
+	if !migrator.HasTable(key.model) {
+		return nil
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(key.model); err != nil {
+		return err
+	}
+
+	fields := make([]*schema.Field, 0, len(key.fields))
+	for _, name := range key.fields {
+		field := stmt.Schema.LookUpField(name)
+		if field == nil {
+			return fmt.Errorf("natural key field %s not found on %s", name, stmt.Schema.Name)
+		}
+		if migrator.HasColumn(key.model, field.DBName) {
+			fields = append(fields, field)
+		}
+	}
+
+	ready, err := isUniqueKeyReady(db, key, fields)
+	if err != nil || ready {
+		return err
+	}
+
+	table := stmt.Quote(stmt.Table)
+	columns := make([]string, 0, len(fields))
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		column := stmt.Quote(field.DBName)
+		columns = append(columns, column)
+		keys = append(keys, "COALESCE("+column+", "+zeroLiteral(field)+")")
+	}
+
+	// Rows are grouped as their keys will be once NULLs are set, so no row
+	// collides with another when they are. The derived table lets MySQL
+	// delete from the table it selects from.
+	id := stmt.Quote(stmt.Schema.PrioritizedPrimaryField.DBName)
+	rsp := db.Exec("DELETE FROM " + table + " WHERE " + id + " NOT IN (SELECT " + id + " FROM (SELECT MIN(" + id + ") AS " + id + " FROM " + table + " GROUP BY " + strings.Join(keys, ", ") + ") AS kept)")
+	if rsp.Error != nil {
+		return rsp.Error
+	}
+	if rsp.RowsAffected > 0 {
+		logger.Info("Removed %d rows from %s that share a key with another row", rsp.RowsAffected, stmt.Table)
+	}
+
+	for i, field := range fields {
+		if rsp := db.Exec("UPDATE " + table + " SET " + columns[i] + " = " + zeroLiteral(field) + " WHERE " + columns[i] + " IS NULL"); rsp.Error != nil {
+			return rsp.Error
+		}
+	}
+
+	return nil
+}
+
+// zeroLiteral is the zero value of the column of field in SQL.
+func zeroLiteral(field *schema.Field) string {
+	if field.DataType == schema.String {
+		return "''"
+	}
+	return "0"
+}
+
+// isUniqueKeyReady reports whether the unique index of key exists and all of
+// its columns are not null.
+func isUniqueKeyReady(db *gorm.DB, key uniqueKey, fields []*schema.Field) (bool, error) {
+
+	migrator := db.Migrator()
+	if len(fields) < len(key.fields) || !migrator.HasIndex(key.model, key.index) {
+		return false, nil
+	}
+
+	columnTypes, err := migrator.ColumnTypes(key.model)
+	if err != nil {
+		return false, err
+	}
+
+	for _, field := range fields {
+		for _, columnType := range columnTypes {
+			if columnType.Name() != field.DBName {
+				continue
+			}
+			if nullable, ok := columnType.Nullable(); !ok || nullable {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
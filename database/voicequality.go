@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"github.com/ziondials/go-cdr/models"
+	"github.com/ziondials/go-cdr/quality"
+	"gorm.io/gorm"
+)
+
+// voiceQualityHourKey is the natural key of voice_quality_hours.
+var voiceQualityHourKey = []string{"SLA", "Source", "IntervalStart"}
+
+// ComputeVoiceQuality replaces the voice_quality_hours rows from from to to, in
+// unix time with to excluded, with the voice quality of the CUCM CMRs and the
+// stop records of the CUBE VoIP legs stored in that time, and writes an alert
+// for every hour that broke its SLA for the first time. from should be a whole
+// hour. It returns the number of rows that were written and the new alerts.
+func (ds DataService) ComputeVoiceQuality(from int64, to int64) (int, []*models.Alert, error) {
+
+	summary := quality.NewSummary(from, to)
+
+	// Only the columns voice quality is computed from are read, in batches of
+	// Config.Limit rows, and each batch is summarized before the next is read.
+	var cmrs []*models.CucmCmr
+	rsp := ds.Session.
+		Select("id", "datetimestamp", "devicename", "jitter", "latency", "numberpacketsreceived", "numberpacketslost",
+			"vqmlqk", "cucm_cdr_id", "cucm_cdr_leg").
+		Where("datetimestamp >= ? AND datetimestamp < ?", from, to).
+		FindInBatches(&cmrs, int(ds.Config.Limit), func(_ *gorm.DB, _ int) error {
+			// The address of the device is on the leg of the CDR the CMR
+			// belongs to.
+			cdrIDs := []string{}
+			for _, cmr := range cmrs {
+				if cmr.CucmCdrID != nil {
+					cdrIDs = append(cdrIDs, *cmr.CucmCdrID)
+				}
+			}
+			cdrs := map[string]*models.CucmCdr{}
+			if len(cdrIDs) > 0 {
+				var batch []*models.CucmCdr
+				if err := ds.Session.Select("id", "origipv4v6addr", "destipv4v6addr").Where("id IN ?", cdrIDs).Find(&batch).Error; err != nil {
+					return err
+				}
+				for _, cdr := range batch {
+					cdrs[cdr.ID] = cdr
+				}
+			}
+
+			for _, cmr := range cmrs {
+				var address *string
+				if cmr.CucmCdrID != nil && cmr.CucmCdrLeg != nil && cdrs[*cmr.CucmCdrID] != nil {
+					cdr := cdrs[*cmr.CucmCdrID]
+					if *cmr.CucmCdrLeg == models.CucmCdrLegOrig {
+						address = cdr.Origipv4v6addr
+					} else if *cmr.CucmCdrLeg == models.CucmCdrLegDest {
+						address = cdr.Destipv4v6addr
+					}
+				}
+				summary.Add(&quality.Leg{
+					Source:      models.VoiceQualitySourceCucm,
+					Time:        *cmr.Datetimestamp,
+					Names:       []*string{cmr.Devicename},
+					Address:     address,
+					Jitter:      cmr.Jitter,
+					Latency:     cmr.Latency,
+					LossPercent: quality.LossPercent(cmr.Numberpacketsreceived, cmr.Numberpacketslost),
+					Mos:         cmr.VQMLQK,
+				})
+			}
+			return nil
+		})
+	if rsp.Error != nil {
+		return 0, nil, rsp.Error
+	}
+
+	var legs []*models.CubeCDR
+	rsp = ds.Session.
+		Select("id", "hostname", "in_trunkgroup_label", "out_trunkgroup_label", "remote_media_address", "h323_setup_time",
+			"paks_in", "lost_packets", "receive_delay", "round_trip_delay").
+		Where("h323_setup_time >= ? AND h323_setup_time < ? AND cdr_type = ? AND leg_type = ?", from, to, models.CubeCdrTypeStop, models.CubeLegTypeVoIP).
+		FindInBatches(&legs, int(ds.Config.Limit), func(_ *gorm.DB, _ int) error {
+			for _, leg := range legs {
+				summary.Add(&quality.Leg{
+					Source:         models.VoiceQualitySourceCube,
+					Time:           *leg.H323SetupTime,
+					Names:          []*string{leg.Hostname, leg.InTrunkgroupLabel, leg.OutTrunkgroupLabel},
+					Address:        leg.RemoteMediaAddress,
+					ReceiveDelay:   leg.ReceiveDelay,
+					RoundTripDelay: leg.RoundTripDelay,
+					LossPercent:    quality.LossPercent(leg.PaksIn, leg.LostPackets),
+				})
+			}
+			return nil
+		})
+	if rsp.Error != nil {
+		return 0, nil, rsp.Error
+	}
+
+	hours, alerts := summary.Hours()
+
+	err := ds.Transaction(func(tx DataService) error {
+		if rsp := tx.Session.Where("interval_start >= ? AND interval_start < ?", from, to).Delete(&models.VoiceQualityHour{}); rsp.Error != nil {
+			return rsp.Error
+		}
+		if len(hours) > 0 {
+			if err := tx.upsertInBatches(&hours, voiceQualityHourKey...); err != nil {
+				return err
+			}
+		}
+		var err error
+		alerts, err = tx.SaveAlerts(alerts)
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return len(hours), alerts, nil
+}
@@ -0,0 +1,298 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package dialplan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/logger"
+)
+
+// Direction of a call, as returned by Classify.
+const (
+	CallDirectionInternal      = "internal"
+	CallDirectionInbound       = "inbound"
+	CallDirectionLocal         = "outbound-local"
+	CallDirectionNational      = "national"
+	CallDirectionInternational = "international"
+	CallDirectionEmergency     = "emergency"
+	CallDirectionTollFree      = "toll-free"
+)
+
+// DialPlan is the dial plan of the site, or nil if none is configured.
+var DialPlan *Plan
+
+func InitDialPlan() {
+
+	conf := config.GetDialPlanFromGlobalConfig()
+	if conf == nil {
+		return
+	}
+
+	plan, err := NewPlan(conf)
+	if err != nil {
+		logger.Fatal("Dial Plan Error: %s", err)
+	}
+
+	DialPlan = plan
+}
+
+// Plan normalizes numbers to E.164 and classifies the direction of calls. The
+// methods of a nil Plan return nil.
+type Plan struct {
+	conf       *config.DialPlanConfig
+	extensions []numberPattern
+}
+
+// NewPlan creates a Plan from conf.
+func NewPlan(conf *config.DialPlanConfig) (*Plan, error) {
+
+	if conf.CountryCode == "" {
+		return nil, fmt.Errorf("countryCode is required")
+	}
+
+	plan := &Plan{conf: conf}
+	for _, extension := range conf.Extensions {
+		pattern, err := parseNumberPattern(extension)
+		if err != nil {
+			return nil, fmt.Errorf("extension %s: %w", extension, err)
+		}
+		plan.extensions = append(plan.extensions, pattern)
+	}
+
+	return plan, nil
+}
+
+// Normalize returns number in E.164, or nil if number is an extension, an
+// emergency number or cannot be normalized.
+func (p *Plan) Normalize(number *string) *string {
+	if p == nil || number == nil {
+		return nil
+	}
+	e164, _ := p.resolve(*number)
+	return e164
+}
+
+// Classify returns the direction of a call from calling to called, or nil if
+// it cannot be told.
+func (p *Plan) Classify(calling *string, called *string) *string {
+	if p == nil || called == nil {
+		return nil
+	}
+
+	calledE164, calledKind := p.resolve(*called)
+	if calledKind == numberEmergency {
+		return direction(CallDirectionEmergency)
+	}
+
+	callingInternal := false
+	if calling != nil {
+		_, callingKind := p.resolve(*calling)
+		callingInternal = callingKind == numberInternal
+	}
+
+	switch {
+	case calledKind == numberInternal && callingInternal:
+		return direction(CallDirectionInternal)
+	case calledKind == numberInternal:
+		return direction(CallDirectionInbound)
+	case calledE164 == nil:
+		return nil
+	}
+
+	national, ok := strings.CutPrefix(*calledE164, "+"+p.conf.CountryCode)
+	switch {
+	case !ok:
+		return direction(CallDirectionInternational)
+	case hasAnyPrefix(national, p.conf.TollFreePrefixes):
+		return direction(CallDirectionTollFree)
+	case p.conf.AreaCode != "" && strings.HasPrefix(national, p.conf.AreaCode),
+		hasAnyPrefix(national, p.conf.LocalAreaCodes):
+		return direction(CallDirectionLocal)
+	}
+	return direction(CallDirectionNational)
+}
+
+// direction returns a new pointer to d, so no two calls share one.
+func direction(d string) *string {
+	return &d
+}
+
+type numberKind int
+
+const (
+	numberUnknown numberKind = iota
+	numberInternal
+	numberEmergency
+	numberExternal
+)
+
+// resolve returns the E.164 form of number, if it has one, along with its kind.
+func (p *Plan) resolve(number string) (*string, numberKind) {
+
+	digits, ok := cleanNumber(number)
+	if !ok {
+		return nil, numberUnknown
+	}
+
+	e164 := p.toE164(digits)
+	if p.isExtension(digits) || (e164 != nil && p.isExtension(*e164)) {
+		return e164, numberInternal
+	}
+	if p.isEmergency(digits) {
+		return nil, numberEmergency
+	}
+	if e164 != nil {
+		return e164, numberExternal
+	}
+	return nil, numberUnknown
+}
+
+// toE164 returns digits in E.164 after removing the access code, if there is
+// one.
+func (p *Plan) toE164(digits string) *string {
+
+	if strings.HasPrefix(digits, "+") {
+		return &digits
+	}
+	digits = p.trimAccessCode(digits)
+
+	var e164 string
+	national := p.conf.NationalNumberLength
+	switch {
+	case p.conf.InternationalPrefix != "" && strings.HasPrefix(digits, p.conf.InternationalPrefix):
+		e164 = "+" + strings.TrimPrefix(digits, p.conf.InternationalPrefix)
+	case p.conf.NationalPrefix != "" && strings.HasPrefix(digits, p.conf.NationalPrefix) &&
+		(national == 0 || len(digits)-len(p.conf.NationalPrefix) == national):
+		e164 = "+" + p.conf.CountryCode + strings.TrimPrefix(digits, p.conf.NationalPrefix)
+	case national != 0 && len(digits) == national:
+		e164 = "+" + p.conf.CountryCode + digits
+	case national != 0 && strings.HasPrefix(digits, p.conf.CountryCode) && len(digits)-len(p.conf.CountryCode) == national:
+		e164 = "+" + digits
+	case p.conf.LocalNumberLength != 0 && p.conf.AreaCode != "" && len(digits) == p.conf.LocalNumberLength:
+		e164 = "+" + p.conf.CountryCode + p.conf.AreaCode + digits
+	default:
+		return nil
+	}
+
+	if len(e164) < 3 {
+		return nil
+	}
+	return &e164
+}
+
+func (p *Plan) trimAccessCode(digits string) string {
+	longest := ""
+	for _, code := range p.conf.AccessCodes {
+		if len(code) > len(longest) && len(code) < len(digits) && strings.HasPrefix(digits, code) {
+			longest = code
+		}
+	}
+	return strings.TrimPrefix(digits, longest)
+}
+
+func (p *Plan) isExtension(number string) bool {
+	for _, pattern := range p.extensions {
+		if pattern.matches(number) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plan) isEmergency(digits string) bool {
+	for _, candidate := range []string{digits, p.trimAccessCode(digits)} {
+		for _, emergency := range p.conf.EmergencyNumbers {
+			if candidate == emergency {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cleanNumber strips the separators from number and reports whether what is
+// left is a number, i.e. digits with an optional leading plus.
+func cleanNumber(number string) (string, bool) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(number) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && b.Len() == 0:
+			b.WriteRune(r)
+		case r == '\\' && i == 0, r == ' ', r == '-', r == '.', r == '(', r == ')':
+		default:
+			return "", false
+		}
+	}
+	digits := b.String()
+	return digits, digits != "" && digits != "+"
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// numberPattern is a range of numbers of the same length, such as 1000-1999,
+// or a pattern in which X matches any digit, such as 2XXX or +1212555XXXX.
+type numberPattern struct {
+	low  string
+	high string
+	mask string
+}
+
+func parseNumberPattern(s string) (numberPattern, error) {
+	s = strings.TrimSpace(s)
+	if low, high, ok := strings.Cut(s, "-"); ok {
+		low, high = strings.TrimSpace(low), strings.TrimSpace(high)
+		if len(low) != len(high) || low > high || !isNumber(low) || !isNumber(high) {
+			return numberPattern{}, fmt.Errorf("invalid range")
+		}
+		return numberPattern{low: low, high: high}, nil
+	}
+	if !isNumber(strings.ReplaceAll(strings.ToUpper(s), "X", "0")) {
+		return numberPattern{}, fmt.Errorf("invalid pattern")
+	}
+	return numberPattern{mask: strings.ToUpper(s)}, nil
+}
+
+func (n numberPattern) matches(number string) bool {
+	if n.mask == "" {
+		return len(number) == len(n.low) && number >= n.low && number <= n.high
+	}
+	if len(number) != len(n.mask) {
+		return false
+	}
+	for i := 0; i < len(number); i++ {
+		if n.mask[i] != 'X' && n.mask[i] != number[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumber(s string) bool {
+	digits, ok := cleanNumber(s)
+	return ok && digits == s
+}
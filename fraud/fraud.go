@@ -0,0 +1,426 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package fraud
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/dialplan"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
+	"github.com/ziondials/go-cdr/webhook"
+)
+
+// FraudEngine checks the calls that are loaded, or is nil if no rules are
+// configured.
+var FraudEngine *Engine
+
+func InitFraud() {
+
+	conf := config.GetFraudFromGlobalConfig()
+	if conf == nil {
+		return
+	}
+
+	engine, err := NewEngine(conf)
+	if err != nil {
+		logger.Fatal("Fraud Error: %s", err)
+	}
+
+	FraudEngine = engine
+}
+
+// CheckCucmCdrs checks cdrs against the configured rules.
+func CheckCucmCdrs(history History, cdrs []*models.CucmCdr) ([]*models.Alert, error) {
+	return FraudEngine.CheckCucmCdrs(history, cdrs)
+}
+
+// CheckCubeCDRs checks cdrs against the configured rules.
+func CheckCubeCDRs(history History, cdrs []*models.CubeCDR) ([]*models.Alert, error) {
+	return FraudEngine.CheckCubeCDRs(history, cdrs)
+}
+
+// Notify posts alerts to the configured webhook.
+func Notify(alerts []*models.Alert) {
+	FraudEngine.Notify(alerts)
+}
+
+// History looks up the calls that were loaded before, including the calls
+// written in the same transaction as the ones being checked.
+type History interface {
+	// CountShortCalls returns the number of calls of at most maxDuration
+	// seconds to the E.164 numbers starting with one of prefixes that started
+	// between from and to, in unix time with both included.
+	CountShortCalls(source string, prefixes []string, maxDuration int64, from int64, to int64) (int64, error)
+}
+
+// Engine checks calls against fraud rules and raises alerts. The methods of a
+// nil Engine raise no alerts.
+type Engine struct {
+	location *time.Location
+	rules    []*rule
+	webhook  string
+}
+
+type rule struct {
+	conf       *config.FraudRuleConfig
+	band       *helpers.TimeBand
+	directions map[string]bool
+	peers      []*net.IPNet
+}
+
+// call is what the rules look at in a CucmCdr or a CubeCDR.
+type call struct {
+	source    *string
+	key       *string
+	start     *int64
+	calling   *string
+	called    *string
+	e164      *string // E.164 form of called
+	direction *string
+	device    *string
+	peer      *string
+	duration  *int64
+}
+
+// NewEngine creates an Engine from conf.
+func NewEngine(conf *config.FraudConfig) (*Engine, error) {
+
+	location := time.Local
+	if conf.Timezone != "" {
+		var err error
+		location, err = time.LoadLocation(conf.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("timezone: %w", err)
+		}
+	}
+
+	engine := &Engine{location: location, webhook: conf.Webhook}
+
+	names := map[string]bool{}
+	for i := range conf.Rules {
+		r, err := newRule(&conf.Rules[i])
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", conf.Rules[i].Name, err)
+		}
+		if names[r.conf.Name] {
+			return nil, fmt.Errorf("rule %s: name is used more than once", r.conf.Name)
+		}
+		names[r.conf.Name] = true
+		engine.rules = append(engine.rules, r)
+	}
+
+	return engine, nil
+}
+
+func newRule(conf *config.FraudRuleConfig) (*rule, error) {
+
+	if conf.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	r := &rule{conf: conf}
+
+	directions := conf.Directions
+	if len(directions) == 0 && conf.Type == models.AlertTypeAfterHours {
+		directions = []string{dialplan.CallDirectionInternational}
+	}
+	for _, direction := range directions {
+		if r.directions == nil {
+			r.directions = map[string]bool{}
+		}
+		r.directions[strings.TrimSpace(direction)] = true
+	}
+
+	for _, device := range conf.Devices {
+		if _, err := path.Match(device, ""); err != nil {
+			return nil, fmt.Errorf("device %s: %w", device, err)
+		}
+	}
+
+	switch conf.Type {
+	case models.AlertTypeAfterHours:
+		band, err := helpers.ParseTimeBand(conf.Days, conf.From, conf.To)
+		if err != nil {
+			return nil, err
+		}
+		r.band = band
+	case models.AlertTypeBurst:
+		if len(conf.Prefixes) == 0 || conf.Count <= 0 || conf.Window <= 0 {
+			return nil, fmt.Errorf("prefixes, count and window are required")
+		}
+		if conf.MaxDuration < 0 {
+			return nil, fmt.Errorf("maxDuration must not be negative")
+		}
+	case models.AlertTypeUnexpectedPeer:
+		if len(conf.Peers) == 0 {
+			return nil, fmt.Errorf("peers are required")
+		}
+		for _, peer := range conf.Peers {
+			network, err := helpers.ParseIPNetwork(strings.TrimSpace(peer))
+			if err != nil {
+				return nil, fmt.Errorf("peer %s: %w", peer, err)
+			}
+			r.peers = append(r.peers, network)
+		}
+	case models.AlertTypeLongDuration:
+		if conf.MinDuration <= 0 {
+			return nil, fmt.Errorf("minDuration is required")
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %s", conf.Type)
+	}
+
+	return r, nil
+}
+
+// CheckCucmCdrs returns the alerts raised by the CDRs in cdrs.
+func (e *Engine) CheckCucmCdrs(history History, cdrs []*models.CucmCdr) ([]*models.Alert, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	alerts := []*models.Alert{}
+	for _, cdr := range cdrs {
+		if cdr.OriginPkid == nil {
+			continue
+		}
+		c := &call{
+			source:    &models.AlertSourceCucm,
+			key:       cdr.OriginPkid,
+			start:     cdr.Datetimeorigination,
+			calling:   firstString(cdr.Callingpartynumber_E164, cdr.Callingpartynumber),
+			called:    firstString(cdr.Finalcalledpartynumber_E164, cdr.Finalcalledpartynumber),
+			e164:      cdr.Finalcalledpartynumber_E164,
+			direction: cdr.CallDirection,
+			device:    cdr.Origdevicename,
+			peer:      cdr.Origipv4v6addr,
+			duration:  cdr.Duration,
+		}
+		callAlerts, err := e.check(history, c)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, callAlerts...)
+	}
+	return alerts, nil
+}
+
+// CheckCubeCDRs returns the alerts raised by the CDRs in cdrs. Only the legs
+// the gateway answered are checked, so that each call is checked once, and
+// their peer address is the address of the peer.
+func (e *Engine) CheckCubeCDRs(history History, cdrs []*models.CubeCDR) ([]*models.Alert, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	alerts := []*models.Alert{}
+	for _, cdr := range cdrs {
+		if cdr.Hostname == nil || cdr.H323ConfId == nil || cdr.H323CallOrigin == nil ||
+			!strings.EqualFold(*cdr.H323CallOrigin, models.CubeCallOriginAnswer) {
+			continue
+		}
+
+		key := *cdr.Hostname + "/" + *cdr.H323ConfId
+		c := &call{
+			source:    &models.AlertSourceCube,
+			key:       &key,
+			start:     cdr.H323SetupTime,
+			calling:   firstString(cdr.GwRxdCgnE164, cdr.ClidE164, cdr.GwRxdCgn, cdr.Clid),
+			called:    firstString(cdr.GwRxdCdnE164, cdr.DnisE164, cdr.GwRxdCdn, cdr.Dnis),
+			e164:      firstString(cdr.GwRxdCdnE164, cdr.DnisE164),
+			direction: cdr.CallDirection,
+			device:    cdr.Hostname,
+			peer:      cdr.PeerAddress,
+		}
+		// The duration is only known from the stop record of a leg. A leg that
+		// was never connected lasted 0 seconds, as in CUCM CDRs.
+		isStop := cdr.CdrType != nil && *cdr.CdrType == models.CubeCdrTypeStop
+		if isStop {
+			var duration int64
+			if cdr.H323ConnectTime != nil && cdr.H323DisconnectTime != nil &&
+				*cdr.H323ConnectTime > 0 && *cdr.H323DisconnectTime >= *cdr.H323ConnectTime {
+				duration = *cdr.H323DisconnectTime - *cdr.H323ConnectTime
+			}
+			c.duration = &duration
+		}
+
+		callAlerts, err := e.check(history, c)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, callAlerts...)
+	}
+	return alerts, nil
+}
+
+// Notify posts alerts to the webhook in the background. It should be called
+// once the alerts are committed, and does nothing if there is no webhook.
+func (e *Engine) Notify(alerts []*models.Alert) {
+	if e == nil {
+		return
+	}
+	webhook.Notify(e.webhook, alerts)
+}
+
+// check returns the alerts c raises.
+func (e *Engine) check(history History, c *call) ([]*models.Alert, error) {
+
+	if c.start == nil || *c.start <= 0 {
+		return nil, nil
+	}
+
+	alerts := []*models.Alert{}
+	for _, r := range e.rules {
+		if !r.applies(c) {
+			continue
+		}
+
+		var message string
+		switch r.conf.Type {
+		case models.AlertTypeAfterHours:
+			start := time.Unix(*c.start, 0).In(e.location)
+			if r.band.Contains(start) {
+				continue
+			}
+			message = fmt.Sprintf("%s call %s at %s outside business hours", valueOr(c.direction, "unknown"), describe(c), start.Format("2006-01-02 15:04 MST"))
+
+		case models.AlertTypeBurst:
+			if c.e164 == nil || c.duration == nil || *c.duration > r.conf.MaxDuration || !hasAnyPrefix(*c.e164, r.conf.Prefixes) {
+				continue
+			}
+			count, err := history.CountShortCalls(*c.source, r.conf.Prefixes, r.conf.MaxDuration, *c.start-r.conf.Window*60, *c.start)
+			if err != nil {
+				return nil, err
+			}
+			if count < r.conf.Count {
+				continue
+			}
+			message = fmt.Sprintf("%d calls of at most %ds to %s within %d minutes, the last %s", count, r.conf.MaxDuration, strings.Join(r.conf.Prefixes, ", "), r.conf.Window, describe(c))
+
+		case models.AlertTypeUnexpectedPeer:
+			if c.peer == nil {
+				continue
+			}
+			ip := net.ParseIP(*c.peer)
+			if ip == nil || ip.IsUnspecified() || r.allowsPeer(ip) {
+				continue
+			}
+			message = fmt.Sprintf("call %s from unexpected peer %s", describe(c), *c.peer)
+
+		case models.AlertTypeLongDuration:
+			if c.duration == nil || *c.duration <= r.conf.MinDuration {
+				continue
+			}
+			message = fmt.Sprintf("call %s lasted %ds, longer than %ds", describe(c), *c.duration, r.conf.MinDuration)
+		}
+
+		alerts = append(alerts, r.newAlert(c, message))
+	}
+	return alerts, nil
+}
+
+// applies reports whether c is in the directions and from the devices the rule
+// is limited to.
+func (r *rule) applies(c *call) bool {
+	if r.directions != nil && (c.direction == nil || !r.directions[*c.direction]) {
+		return false
+	}
+	if len(r.conf.Devices) == 0 {
+		return true
+	}
+	if c.device == nil {
+		return false
+	}
+	for _, pattern := range r.conf.Devices {
+		if ok, _ := path.Match(pattern, *c.device); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rule) allowsPeer(ip net.IP) bool {
+	for _, network := range r.peers {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rule) newAlert(c *call, message string) *models.Alert {
+
+	createdAt := time.Now().Unix()
+	severity := r.conf.Severity
+	if severity == "" {
+		severity = "warning"
+	}
+
+	return &models.Alert{
+		ID:              uuid.New().String(),
+		Rule:            &r.conf.Name,
+		Source:          c.source,
+		CallKey:         c.key,
+		Type:            &r.conf.Type,
+		Severity:        &severity,
+		CallTime:        c.start,
+		CallingNumber:   c.calling,
+		CalledNumber:    c.called,
+		CallDirection:   c.direction,
+		DeviceName:      c.device,
+		PeerAddress:     c.peer,
+		DurationSeconds: c.duration,
+		Message:         &message,
+		CreatedAt:       &createdAt,
+	}
+}
+
+func describe(c *call) string {
+	return fmt.Sprintf("from %s to %s", valueOr(c.calling, "unknown"), valueOr(c.called, "unknown"))
+}
+
+func valueOr(s *string, fallback string) string {
+	if s == nil || *s == "" {
+		return fallback
+	}
+	return *s
+}
+
+func firstString(values ...*string) *string {
+	for _, value := range values {
+		if value != nil && *value != "" {
+			return value
+		}
+	}
+	return nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
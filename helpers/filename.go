@@ -77,3 +77,27 @@ func ChangeFileNameToFailedAndMove(input, output string) error {
 	err := os.Rename(input, NewPath)
 	return err
 }
+
+// RejectsFilePath returns the path of the rejects file for input, which sits
+// next to input in the complete or failed directory named by status.
+func RejectsFilePath(input, output, status string) (string, error) {
+	directory := filepath.Join(filepath.Dir(output), status)
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return "", err
+	}
+	return filepath.Join(directory, filepath.Base(input)+".rejects.csv"), nil
+}
+
+// IsPartialUpload reports whether name is a hidden file, which the embedded
+// receivers use for uploads that are still in progress.
+func IsPartialUpload(name string) bool {
+	return strings.HasPrefix(filepath.Base(name), ".")
+}
+
+// IsCUBEFilename reports whether name follows the gw-accounting file naming
+// convention Filename.Hostname.Timestamp, e.g. cdr.gw1.01_16_2023_09_51_17.583,
+// which the CUBE parser reads the hostname and timestamp from.
+func IsCUBEFilename(name string) bool {
+	parts := strings.Split(filepath.Base(name), ".")
+	return len(parts) >= 4 && parts[1] != "" && parts[2] != ""
+}
@@ -18,6 +18,7 @@ package helpers
 import (
 	"encoding/binary"
 	"fmt"
+	"net"
 	"strings"
 )
 
@@ -26,7 +27,7 @@ func ConvertStringToIPCisco(ip *string) (*string, error) {
 		return nil, nil
 	}
 	stringInt, err := ConvertStringToInt(ip)
-	if err != nil {
+	if err != nil || stringInt == nil {
 		return nil, err
 	}
 	b := make([]byte, 8)
@@ -40,3 +41,45 @@ func ConvertStringToIPCisco(ip *string) (*string, error) {
 	}
 	return &newIPString, nil
 }
+
+func ConvertStringToIP(ip *string) (*string, error) {
+	if ip == nil {
+		return nil, nil
+	}
+	trimmedIP := strings.TrimSpace(*ip)
+	if trimmedIP == "" {
+		return nil, nil
+	}
+	parsedIP := net.ParseIP(trimmedIP)
+	if parsedIP == nil {
+		return nil, fmt.Errorf("error converting string to ip: %s", trimmedIP)
+	}
+	if parsedIP.IsUnspecified() {
+		return nil, nil
+	}
+	newIPString := parsedIP.String()
+	return &newIPString, nil
+}
+
+// ParseIPNetwork parses an IP address or a CIDR range. An IP address is parsed
+// as the range holding only that address.
+func ParseIPNetwork(address string) (*net.IPNet, error) {
+	if strings.Contains(address, "/") {
+		_, network, err := net.ParseCIDR(address)
+		if err != nil {
+			return nil, err
+		}
+		return network, nil
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, fmt.Errorf("%s is not an IP address or CIDR range", address)
+	}
+	bits := 8 * len(ip)
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
@@ -39,37 +39,25 @@ func ConvertHexadecimalToInt(s *string) *int64 {
 }
 
 func ConvertStringToInt64(s *string) (*int64, error) {
-	if s == nil {
+	if s == nil || strings.TrimSpace(*s) == "" {
 		return nil, nil
 	}
-	newString := stringToIntReg.ReplaceAllString(*s, "")
-	if newString != "" {
-		integer, err := strconv.ParseInt(strings.TrimSpace(newString), 10, 64)
-		if err != nil {
-			logger.Error("Error converting string to int: %s", err)
-			return nil, fmt.Errorf("error converting string to int: %s", err)
-		}
-		return &integer, nil
-	} else {
-		return nil, nil
+	integer, err := strconv.ParseInt(strings.TrimSpace(*s), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error converting string to int: %w", err)
 	}
+	return &integer, nil
 }
 
 func ConvertStringToInt(s *string) (*int, error) {
-	if s == nil {
+	if s == nil || strings.TrimSpace(*s) == "" {
 		return nil, nil
 	}
-	newString := stringToIntReg.ReplaceAllString(*s, "")
-	if newString != "" {
-		integer, err := strconv.Atoi(strings.TrimSpace(newString))
-		if err != nil {
-			logger.Error("Error converting string to int: %s", err)
-			return nil, fmt.Errorf("error converting string to int: %s", err)
-		}
-		return &integer, nil
-	} else {
-		return nil, nil
+	integer, err := strconv.Atoi(strings.TrimSpace(*s))
+	if err != nil {
+		return nil, fmt.Errorf("error converting string to int: %w", err)
 	}
+	return &integer, nil
 }
 
 func ExtractPhoneNumberFromString(s *string) *string {
@@ -86,18 +74,12 @@ func ExtractPhoneNumberFromString(s *string) *string {
 }
 
 func ConvertStringToFloat64(s *string) (*float64, error) {
-	if s == nil {
+	if s == nil || strings.TrimSpace(*s) == "" {
 		return nil, nil
 	}
-	newString := stringToIntReg.ReplaceAllString(*s, "")
-	if newString != "" {
-		integer, err := strconv.ParseFloat(strings.TrimSpace(newString), 64)
-		if err != nil {
-			logger.Error("Error converting string to int: %s", err)
-			return nil, fmt.Errorf("error converting string to int: %s", err)
-		}
-		return &integer, nil
-	} else {
-		return nil, nil
+	float, err := strconv.ParseFloat(strings.TrimSpace(*s), 64)
+	if err != nil {
+		return nil, fmt.Errorf("error converting string to float: %w", err)
 	}
+	return &float, nil
 }
@@ -9,6 +9,7 @@ var (
 	unixTimeTertiaryReg   = regexp.MustCompile(`^\d{2}\s\d{2}\s\d{4}\s\d{2}\s\d{2}\s\d{2}.\d{3}`)
 	unixTimeQuaternaryReg = regexp.MustCompile(`^\*\d{2}:\d{2}:\d{2}.\d{3}\s[A-Z]{3}\s[a-zA-Z]{3}\s[a-zA-Z]{3}\s\d{1,2}\s\d{4}`)
 	unixTimeQuinaryReg    = regexp.MustCompile(`^\.\d{2}:\d{2}:\d{2}.\d{3}\s[A-Z]{3}\s[a-zA-Z]{3}\s[a-zA-Z]{3}\s\d{1,2}\s\d{4}`)
+	unixTimeSenaryReg     = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}.\d{3}\s[A-Z]{3}\s[a-zA-Z]{3}\s\d{1,2}\s\d{4}`)
 
 	// ExtractTimeLocationFromString
 	extractTimeLocationPrimaryReg    = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}.\d{3}\s[A-Z]{3}\s[a-zA-Z]{3}\s[a-zA-Z]{3}\s\d{1,2}\s\d{4}`)
@@ -17,8 +18,8 @@ var (
 	extractTimeLocationQuaternaryReg = regexp.MustCompile(`^\*\d{2}:\d{2}:\d{2}.\d{3}\s[A-Z]{3}\s[a-zA-Z]{3}\s[a-zA-Z]{3}\s\d{1,2}\s\d{4}`)
 	extractTimeLocationQuinaryReg    = regexp.MustCompile(`^\.\d{2}:\d{2}:\d{2}.\d{3}\s[A-Z]{3}\s[a-zA-Z]{3}\s[a-zA-Z]{3}\s\d{1,2}\s\d{4}`)
 
-	// ConvertStringToInt64
-	stringToIntReg = regexp.MustCompile("[^0-9]+")
+	// ParseCucmCDRFile, ParseCucmCMRFile
+	CUCMTypeRowReg = regexp.MustCompile(`^[A-Z]+(\(\d+\))?$`)
 
 	// ParseCUCMCDRs
 	CMRReg = regexp.MustCompile(`^cmr_.*`)
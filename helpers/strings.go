@@ -15,7 +15,11 @@
 
 package helpers
 
-import "strings"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // Take String, Delimiter, and Equality Charachter and return a map of key value pairs
 func ConvertStringToKeyValuePairs(s *string, d string, e string) map[string]string {
@@ -32,3 +36,15 @@ func ConvertStringToKeyValuePairs(s *string, d string, e string) map[string]stri
 	}
 	return m
 }
+
+// ConvertStringToBool parses true or false, ignoring surrounding space.
+func ConvertStringToBool(s *string) (*bool, error) {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(*s))
+	if err != nil {
+		return nil, fmt.Errorf("error converting string to bool: %s", err)
+	}
+	return &b, nil
+}
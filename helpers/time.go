@@ -77,6 +77,14 @@ func ConvertStringToUnixTime(s *string, t *time.Location) (*int64, error) {
 		parsedTime := t.UTC().Unix()
 		return &parsedTime, ErrInvalidNTPReferencePeriod
 	}
+	if unixTimeSenaryReg.MatchString(*s) {
+		t, err := time.Parse("15:04:05.000 MST Jan 2 2006", *s)
+		if err != nil {
+			return nil, fmt.Errorf("ConvertStringToUnixTime senaryReg MatchString: %s", err)
+		}
+		parsedTime := t.UTC().Unix()
+		return &parsedTime, nil
+	}
 	return nil, ErrInvalidTimeFormat
 
 }
@@ -129,3 +137,29 @@ func ExtractTimeLocationFromString(s *string) (*time.Location, error) {
 	}
 	return nil, ErrInvalidTimeFormat
 }
+
+// ConvertRFC3339ToUnixTime parses a timestamp such as 2023-01-16T09:51:17Z.
+func ConvertRFC3339ToUnixTime(s *string) (*int64, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		return nil, fmt.Errorf("ConvertRFC3339ToUnixTime: %s", err)
+	}
+	parsedTime := t.UTC().Unix()
+	return &parsedTime, nil
+}
+
+// ExponentialBackoff returns min doubled for every failure after the first,
+// capped at max.
+func ExponentialBackoff(failures int, min time.Duration, max time.Duration) time.Duration {
+	backoff := min
+	for i := 1; i < failures && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
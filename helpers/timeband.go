@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package helpers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// TimeBand is a range of times of day on some days of the week, such as
+// business hours. A band whose From is after its To wraps around midnight.
+type TimeBand struct {
+	days map[time.Weekday]bool // Every day if nil
+	from int                   // Minutes since midnight, -1 for any time
+	to   int
+}
+
+// ParseTimeBand parses a time band from days (mon-sun, or names starting with
+// them) and from and to (15:04, to is exclusive). Empty days stand for every
+// day and empty from and to for the whole day.
+func ParseTimeBand(days []string, from string, to string) (*TimeBand, error) {
+
+	band := &TimeBand{from: -1, to: -1}
+
+	for _, day := range days {
+		name := strings.ToLower(strings.TrimSpace(day))
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		weekday, ok := weekdays[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown day %s", day)
+		}
+		if band.days == nil {
+			band.days = map[time.Weekday]bool{}
+		}
+		band.days[weekday] = true
+	}
+
+	if from != "" || to != "" {
+		fromTime, err := time.Parse("15:04", from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		toTime, err := time.Parse("15:04", to)
+		if err != nil {
+			return nil, fmt.Errorf("to: %w", err)
+		}
+		band.from = fromTime.Hour()*60 + fromTime.Minute()
+		band.to = toTime.Hour()*60 + toTime.Minute()
+	}
+
+	return band, nil
+}
+
+// Contains reports whether t falls within the band, in the location of t.
+func (b *TimeBand) Contains(t time.Time) bool {
+	if b.days != nil && !b.days[t.Weekday()] {
+		return false
+	}
+	if b.from < 0 {
+		return true
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if b.from <= b.to {
+		return minute >= b.from && minute < b.to
+	}
+	// The band wraps around midnight, e.g. 18:00 to 08:00.
+	return minute >= b.from || minute < b.to
+}
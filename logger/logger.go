@@ -25,7 +25,9 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var Logger *zap.Logger
+// Logger discards everything until InitLogger is called, so the packages that
+// log can be used as a library without the configuration.
+var Logger = zap.NewNop()
 
 func InitLogger() {
 
@@ -63,6 +65,10 @@ func Info(format string, a ...any) {
 	Logger.Info(fmt.Sprintf(format, a...))
 }
 
+func Warn(format string, a ...any) {
+	Logger.Warn(fmt.Sprintf(format, a...))
+}
+
 func Error(format string, a ...any) {
 	Logger.Error(fmt.Sprintf(format, a...))
 }
@@ -0,0 +1,53 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+var (
+	AlertSourceCucm = "cucm"
+	AlertSourceCube = "cube"
+
+	AlertTypeAfterHours     = "afterHours"
+	AlertTypeBurst          = "burst"
+	AlertTypeUnexpectedPeer = "unexpectedPeer"
+	AlertTypeLongDuration   = "longDuration"
+	AlertTypeVoiceQuality   = "voiceQuality"
+)
+
+// Alert is a call flagged by a fraud rule, or an hour that broke a voice
+// quality SLA. Rule is the name of the rule or SLA and Type its type. A call is
+// identified by its Source and CallKey, which is the OriginPkid of a CucmCdr or
+// the Hostname and H323ConfId of a CubeCDR joined by a slash, so a rule raises
+// a single alert for a call however often its CDRs are loaded. CallTime is the
+// Datetimeorigination of a CucmCdr or the H323SetupTime of a CubeCDR. For voice
+// quality, CallKey and CallTime are the start of the hour, in RFC 3339 and in
+// unix time.
+type Alert struct {
+	ID              string
+	Rule            *string `gorm:"size:255;not null;uniqueIndex:alert_index"`
+	Source          *string `gorm:"size:16;not null;uniqueIndex:alert_index"`
+	CallKey         *string `gorm:"size:255;not null;uniqueIndex:alert_index"`
+	Type            *string
+	Severity        *string
+	CallTime        *int64 `gorm:"index"`
+	CallingNumber   *string
+	CalledNumber    *string
+	CallDirection   *string
+	DeviceName      *string
+	PeerAddress     *string
+	DurationSeconds *int64
+	Message         *string
+	CreatedAt       *int64
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+var (
+	CallChargeSourceCucm = "cucm"
+	CallChargeSourceCube = "cube"
+)
+
+// CallCharge is the cost of a rated call. A call is identified by its Source
+// and CallKey, which is the OriginPkid of a CucmCdr or the Hostname and
+// H323ConfId of a CubeCall joined by a slash, so rating a call again replaces
+// its charge. StartTime is the Datetimeorigination of a CucmCdr or the
+// SetupTime of a CubeCall.
+//
+// A call through a CUBE gateway that is registered to CUCM is rated once for
+// each source, so charges should be summed for one source at a time.
+type CallCharge struct {
+	ID              string
+	Source          *string `gorm:"size:16;not null;uniqueIndex:call_charge_index"`
+	CallKey         *string `gorm:"size:255;not null;uniqueIndex:call_charge_index"`
+	StartTime       *int64  `gorm:"index"`
+	CallingNumber   *string
+	CalledNumber    *string
+	CallDirection   *string
+	DeviceName      *string
+	DurationSeconds *int64
+	BilledSeconds   *int64
+	Tariff          *string
+	Prefix          *string
+	Band            *string
+	RatePerMinute   *float64
+	Charge          *float64
+	Currency        *string
+	RatedAt         *int64
+}
+
+// CucmCdrCallKey returns the CallKey of the charge of cdr, or nil if it cannot
+// be charged.
+func CucmCdrCallKey(cdr *CucmCdr) *string {
+	return cdr.OriginPkid
+}
+
+// CubeCallCallKey returns the CallKey of the charge of call, or nil if it
+// cannot be charged.
+func CubeCallCallKey(call *CubeCall) *string {
+	if call.Hostname == nil || call.H323ConfId == nil {
+		return nil
+	}
+	key := *call.Hostname + "/" + *call.H323ConfId
+	return &key
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Categories of the Q.850 cause codes.
+var (
+	CauseCategoryNormal       = "normal clearing"
+	CauseCategoryBusy         = "user busy"
+	CauseCategoryNoAnswer     = "no answer"
+	CauseCategoryRejected     = "call rejected"
+	CauseCategoryNumber       = "number problem"
+	CauseCategoryNetwork      = "network failure"
+	CauseCategoryResource     = "resource unavailable"
+	CauseCategoryService      = "service unavailable"
+	CauseCategoryInvalid      = "invalid message"
+	CauseCategoryProtocol     = "protocol error"
+	CauseCategoryInterworking = "interworking"
+)
+
+// Q850Cause is a row of the q850_causes lookup table, which is seeded by the
+// migration. CUCM writes the cause of a CDR as a decimal Code and CUBE writes
+// the H.323 disconnect cause as the same value in Hex.
+type Q850Cause struct {
+	Code        int64 `gorm:"primaryKey;autoIncrement:false"`
+	Hex         string
+	Description string
+	Category    string
+}
+
+// Q850Causes are the Q.850 cause codes, along with the values CUCM and IOS use
+// outside of the Q.850 range.
+var Q850Causes = []*Q850Cause{
+	{Code: 0, Description: "No error", Category: CauseCategoryNormal},
+	{Code: 1, Description: "Unallocated (unassigned) number", Category: CauseCategoryNumber},
+	{Code: 2, Description: "No route to specified transit network", Category: CauseCategoryNetwork},
+	{Code: 3, Description: "No route to destination", Category: CauseCategoryNetwork},
+	{Code: 4, Description: "Send special information tone", Category: CauseCategoryNumber},
+	{Code: 5, Description: "Misdialed trunk prefix", Category: CauseCategoryNumber},
+	{Code: 6, Description: "Channel unacceptable", Category: CauseCategoryNetwork},
+	{Code: 7, Description: "Call awarded and being delivered in an established channel", Category: CauseCategoryNormal},
+	{Code: 8, Description: "Preemption", Category: CauseCategoryRejected},
+	{Code: 9, Description: "Preemption, circuit reserved for reuse", Category: CauseCategoryRejected},
+	{Code: 16, Description: "Normal call clearing", Category: CauseCategoryNormal},
+	{Code: 17, Description: "User busy", Category: CauseCategoryBusy},
+	{Code: 18, Description: "No user responding", Category: CauseCategoryNoAnswer},
+	{Code: 19, Description: "No answer from user (user alerted)", Category: CauseCategoryNoAnswer},
+	{Code: 20, Description: "Subscriber absent", Category: CauseCategoryNoAnswer},
+	{Code: 21, Description: "Call rejected", Category: CauseCategoryRejected},
+	{Code: 22, Description: "Number changed", Category: CauseCategoryNumber},
+	{Code: 23, Description: "Redirection to new destination", Category: CauseCategoryNormal},
+	{Code: 24, Description: "Call rejected due to feature at the destination", Category: CauseCategoryRejected},
+	{Code: 25, Description: "Exchange routing error", Category: CauseCategoryNetwork},
+	{Code: 26, Description: "Non-selected user clearing", Category: CauseCategoryNormal},
+	{Code: 27, Description: "Destination out of order", Category: CauseCategoryNetwork},
+	{Code: 28, Description: "Invalid number format (address incomplete)", Category: CauseCategoryNumber},
+	{Code: 29, Description: "Facility rejected", Category: CauseCategoryRejected},
+	{Code: 30, Description: "Response to STATUS ENQUIRY", Category: CauseCategoryNormal},
+	{Code: 31, Description: "Normal, unspecified", Category: CauseCategoryNormal},
+	{Code: 34, Description: "No circuit/channel available", Category: CauseCategoryResource},
+	{Code: 38, Description: "Network out of order", Category: CauseCategoryNetwork},
+	{Code: 39, Description: "Permanent frame mode connection out of service", Category: CauseCategoryNetwork},
+	{Code: 40, Description: "Permanent frame mode connection operational", Category: CauseCategoryNormal},
+	{Code: 41, Description: "Temporary failure", Category: CauseCategoryNetwork},
+	{Code: 42, Description: "Switching equipment congestion", Category: CauseCategoryNetwork},
+	{Code: 43, Description: "Access information discarded", Category: CauseCategoryResource},
+	{Code: 44, Description: "Requested circuit/channel not available", Category: CauseCategoryResource},
+	{Code: 46, Description: "Precedence call blocked", Category: CauseCategoryRejected},
+	{Code: 47, Description: "Resource unavailable, unspecified", Category: CauseCategoryResource},
+	{Code: 49, Description: "Quality of service not available", Category: CauseCategoryService},
+	{Code: 50, Description: "Requested facility not subscribed", Category: CauseCategoryService},
+	{Code: 52, Description: "Outgoing calls barred", Category: CauseCategoryRejected},
+	{Code: 53, Description: "Outgoing calls barred within CUG", Category: CauseCategoryRejected},
+	{Code: 54, Description: "Incoming calls barred", Category: CauseCategoryRejected},
+	{Code: 55, Description: "Incoming calls barred within CUG", Category: CauseCategoryRejected},
+	{Code: 57, Description: "Bearer capability not authorized", Category: CauseCategoryService},
+	{Code: 58, Description: "Bearer capability not presently available", Category: CauseCategoryService},
+	{Code: 62, Description: "Inconsistency in designated outgoing access information and subscriber class", Category: CauseCategoryService},
+	{Code: 63, Description: "Service or option not available, unspecified", Category: CauseCategoryService},
+	{Code: 65, Description: "Bearer capability not implemented", Category: CauseCategoryService},
+	{Code: 66, Description: "Channel type not implemented", Category: CauseCategoryService},
+	{Code: 69, Description: "Requested facility not implemented", Category: CauseCategoryService},
+	{Code: 70, Description: "Only restricted digital information bearer capability is available", Category: CauseCategoryService},
+	{Code: 79, Description: "Service or option not implemented, unspecified", Category: CauseCategoryService},
+	{Code: 81, Description: "Invalid call reference value", Category: CauseCategoryInvalid},
+	{Code: 82, Description: "Identified channel does not exist", Category: CauseCategoryInvalid},
+	{Code: 83, Description: "A suspended call exists, but this call identity does not", Category: CauseCategoryInvalid},
+	{Code: 84, Description: "Call identity in use", Category: CauseCategoryInvalid},
+	{Code: 85, Description: "No call suspended", Category: CauseCategoryInvalid},
+	{Code: 86, Description: "Call having the requested call identity has been cleared", Category: CauseCategoryInvalid},
+	{Code: 87, Description: "User not member of CUG", Category: CauseCategoryInvalid},
+	{Code: 88, Description: "Incompatible destination", Category: CauseCategoryInvalid},
+	{Code: 90, Description: "Non-existent CUG", Category: CauseCategoryInvalid},
+	{Code: 91, Description: "Invalid transit network selection", Category: CauseCategoryInvalid},
+	{Code: 95, Description: "Invalid message, unspecified", Category: CauseCategoryInvalid},
+	{Code: 96, Description: "Mandatory information element is missing", Category: CauseCategoryProtocol},
+	{Code: 97, Description: "Message type non-existent or not implemented", Category: CauseCategoryProtocol},
+	{Code: 98, Description: "Message not compatible with call state or message type non-existent or not implemented", Category: CauseCategoryProtocol},
+	{Code: 99, Description: "Information element or parameter non-existent or not implemented", Category: CauseCategoryProtocol},
+	{Code: 100, Description: "Invalid information element contents", Category: CauseCategoryProtocol},
+	{Code: 101, Description: "Message not compatible with call state", Category: CauseCategoryProtocol},
+	{Code: 102, Description: "Recovery on timer expiry", Category: CauseCategoryProtocol},
+	{Code: 103, Description: "Parameter non-existent or not implemented, passed on", Category: CauseCategoryProtocol},
+	{Code: 110, Description: "Message with unrecognized parameter, discarded", Category: CauseCategoryProtocol},
+	{Code: 111, Description: "Protocol error, unspecified", Category: CauseCategoryProtocol},
+	{Code: 127, Description: "Interworking, unspecified", Category: CauseCategoryInterworking},
+	{Code: 128, Description: "Next node unreachable", Category: CauseCategoryNetwork},
+	{Code: 262144, Description: "Conference drop any party or drop last conference party", Category: CauseCategoryNormal},
+	{Code: 393216, Description: "Call split", Category: CauseCategoryNormal},
+	{Code: 458752, Description: "Conference full", Category: CauseCategoryResource},
+}
+
+var q850CausesByCode = map[int64]*Q850Cause{}
+
+func init() {
+	for _, cause := range Q850Causes {
+		cause.Hex = strings.ToUpper(strconv.FormatInt(cause.Code, 16))
+		q850CausesByCode[cause.Code] = cause
+	}
+}
+
+// DecodeQ850Cause returns the description and category of a decimal cause code,
+// or nil for codes that are not known.
+func DecodeQ850Cause(code *int64) (description *string, category *string) {
+	if code == nil {
+		return nil, nil
+	}
+	cause, ok := q850CausesByCode[*code]
+	if !ok {
+		return nil, nil
+	}
+	return &cause.Description, &cause.Category
+}
+
+// DecodeH323Cause returns the description and category of a hexadecimal H.323
+// disconnect cause, or nil for causes that are not known.
+func DecodeH323Cause(hex *string) (description *string, category *string) {
+	if hex == nil {
+		return nil, nil
+	}
+	code, err := strconv.ParseInt(strings.TrimSpace(*hex), 16, 64)
+	if err != nil {
+		return nil, nil
+	}
+	return DecodeQ850Cause(&code)
+}
@@ -0,0 +1,303 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"errors"
+
+	"github.com/ziondials/go-cdr/helpers"
+)
+
+// Types of the CDR records posted by Cisco Meeting Server.
+const (
+	CmsRecordTypeCallStart     = "callStart"
+	CmsRecordTypeCallEnd       = "callEnd"
+	CmsRecordTypeCallLegStart  = "callLegStart"
+	CmsRecordTypeCallLegUpdate = "callLegUpdate"
+	CmsRecordTypeCallLegEnd    = "callLegEnd"
+)
+
+var ErrCmsRecordWithoutID = errors.New("record has no id")
+
+// RawCmsRecord is a single event of a call or call leg. Each record only
+// carries the fields that are known when the event happens.
+type RawCmsRecord struct {
+	Type            *string        `xml:"type,attr"`
+	Time            *string        `xml:"time,attr"`
+	RecordIndex     *string        `xml:"recordIndex,attr"`
+	CorrelatorIndex *string        `xml:"correlatorIndex,attr"`
+	Call            *RawCmsCall    `xml:"call"`
+	CallLeg         *RawCmsCallLeg `xml:"callLeg"`
+}
+
+type RawCmsCall struct {
+	Id                *string `xml:"id,attr"`
+	CallCorrelator    *string `xml:"callCorrelator"`
+	CallLegsCompleted *string `xml:"callLegsCompleted"`
+	CallLegsMaxActive *string `xml:"callLegsMaxActive"`
+	CallType          *string `xml:"callType"`
+	CdrTag            *string `xml:"cdrTag"`
+	CoSpace           *string `xml:"coSpace"`
+	DurationSeconds   *string `xml:"durationSeconds"`
+	Name              *string `xml:"name"`
+	OwnerName         *string `xml:"ownerName"`
+}
+
+type RawCmsCallLeg struct {
+	Id                *string `xml:"id,attr"`
+	ActivatedDuration *string `xml:"activatedDuration"`
+	Call              *string `xml:"call"`
+	CanMove           *string `xml:"canMove"`
+	CdrTag            *string `xml:"cdrTag"`
+	Direction         *string `xml:"direction"`
+	DisplayName       *string `xml:"displayName"`
+	DurationSeconds   *string `xml:"durationSeconds"`
+	GroupId           *string `xml:"groupId"`
+	GuestConnection   *string `xml:"guestConnection"`
+	LocalAddress      *string `xml:"localAddress"`
+	LyncSubType       *string `xml:"lyncSubType"`
+	OwnerId           *string `xml:"ownerId"`
+	Reason            *string `xml:"reason"`
+	Recording         *string `xml:"recording"`
+	RemoteAddress     *string `xml:"remoteAddress"`
+	RemoteParty       *string `xml:"remoteParty"`
+	RemoteTeardown    *string `xml:"remoteTeardown"`
+	ReplacesSipCallId *string `xml:"replacesSipCallId"`
+	RxAudioCodec      *string `xml:"rxAudio>codec"`
+	RxVideoCodec      *string `xml:"rxVideo>codec"`
+	SipCallId         *string `xml:"sipCallId"`
+	State             *string `xml:"state"`
+	Streaming         *string `xml:"streaming"`
+	SubType           *string `xml:"subType"`
+	TxAudioCodec      *string `xml:"txAudio>codec"`
+	TxVideoCodec      *string `xml:"txVideo>codec"`
+	Type              *string `xml:"type"`
+}
+
+// CmsCall is a call on Cisco Meeting Server, built up from its callStart and
+// callEnd records. ID is the call ID assigned by the server.
+type CmsCall struct {
+	ID                string
+	Session           *string
+	CallCorrelator    *string `gorm:"index"`
+	CallLegsCompleted *int64
+	CallLegsMaxActive *int64
+	CallType          *string
+	CdrTag            *string
+	CoSpace           *string
+	DurationSeconds   *int64
+	EndTime           *int64
+	Name              *string
+	OwnerName         *string
+	StartTime         *int64
+}
+
+// CmsCallLeg is a participant of a CmsCall, built up from its callLegStart,
+// callLegUpdate and callLegEnd records. ID is the call leg ID assigned by the
+// server, and CallID is the ID of the CmsCall it belongs to.
+type CmsCallLeg struct {
+	ID                string
+	CallID            *string `gorm:"index"`
+	Session           *string
+	ActivatedDuration *int64
+	CanMove           *bool
+	CdrTag            *string
+	Direction         *string
+	DisplayName       *string
+	DurationSeconds   *int64
+	EndTime           *int64
+	GroupId           *string
+	GuestConnection   *bool
+	LocalAddress      *string
+	LyncSubType       *string
+	OwnerId           *string
+	Reason            *string
+	Recording         *bool
+	RemoteAddress     *string
+	RemoteParty       *string
+	RemoteTeardown    *bool
+	ReplacesSipCallId *string
+	RxAudioCodec      *string
+	RxVideoCodec      *string
+	SipCallId         *string
+	StartTime         *int64
+	State             *string
+	Streaming         *bool
+	SubType           *string
+	TxAudioCodec      *string
+	TxVideoCodec      *string
+	Type              *string
+}
+
+// Parse converts the call of a callStart or callEnd record. Only the fields
+// carried by the record are set, so the result can be merged into the call.
+func (raw *RawCmsCall) Parse(record *RawCmsRecord, session *string) (*CmsCall, error) {
+
+	if raw.Id == nil || *raw.Id == "" {
+		return nil, ErrCmsRecordWithoutID
+	}
+
+	var ParsedCallLegsCompleted *int64
+	var ParsedCallLegsMaxActive *int64
+	var ParsedDurationSeconds *int64
+	var ParsedEndTime *int64
+	var ParsedStartTime *int64
+
+	ParsedTime, err := helpers.ConvertRFC3339ToUnixTime(record.Time)
+	if err != nil {
+		return nil, newConversionError("Time", record.Time, err)
+	}
+
+	if record.Type != nil && *record.Type == CmsRecordTypeCallStart {
+		ParsedStartTime = ParsedTime
+	} else {
+		ParsedEndTime = ParsedTime
+	}
+
+	ParsedCallLegsCompleted, err = helpers.ConvertStringToInt64(raw.CallLegsCompleted)
+	if err != nil {
+		return nil, newConversionError("CallLegsCompleted", raw.CallLegsCompleted, err)
+	}
+
+	ParsedCallLegsMaxActive, err = helpers.ConvertStringToInt64(raw.CallLegsMaxActive)
+	if err != nil {
+		return nil, newConversionError("CallLegsMaxActive", raw.CallLegsMaxActive, err)
+	}
+
+	ParsedDurationSeconds, err = helpers.ConvertStringToInt64(raw.DurationSeconds)
+	if err != nil {
+		return nil, newConversionError("DurationSeconds", raw.DurationSeconds, err)
+	}
+
+	return &CmsCall{
+		ID:                *raw.Id,
+		Session:           session,
+		CallCorrelator:    raw.CallCorrelator,
+		CallLegsCompleted: ParsedCallLegsCompleted,
+		CallLegsMaxActive: ParsedCallLegsMaxActive,
+		CallType:          raw.CallType,
+		CdrTag:            raw.CdrTag,
+		CoSpace:           raw.CoSpace,
+		DurationSeconds:   ParsedDurationSeconds,
+		EndTime:           ParsedEndTime,
+		Name:              raw.Name,
+		OwnerName:         raw.OwnerName,
+		StartTime:         ParsedStartTime,
+	}, nil
+}
+
+// Parse converts the call leg of a callLegStart, callLegUpdate or callLegEnd
+// record. Only the fields carried by the record are set, so the result can be
+// merged into the call leg.
+func (raw *RawCmsCallLeg) Parse(record *RawCmsRecord, session *string) (*CmsCallLeg, error) {
+
+	if raw.Id == nil || *raw.Id == "" {
+		return nil, ErrCmsRecordWithoutID
+	}
+
+	var ParsedActivatedDuration *int64
+	var ParsedCanMove *bool
+	var ParsedDurationSeconds *int64
+	var ParsedEndTime *int64
+	var ParsedGuestConnection *bool
+	var ParsedRecording *bool
+	var ParsedRemoteTeardown *bool
+	var ParsedStartTime *int64
+	var ParsedStreaming *bool
+
+	ParsedTime, err := helpers.ConvertRFC3339ToUnixTime(record.Time)
+	if err != nil {
+		return nil, newConversionError("Time", record.Time, err)
+	}
+
+	if record.Type != nil {
+		switch *record.Type {
+		case CmsRecordTypeCallLegStart:
+			ParsedStartTime = ParsedTime
+		case CmsRecordTypeCallLegEnd:
+			ParsedEndTime = ParsedTime
+		}
+	}
+
+	ParsedActivatedDuration, err = helpers.ConvertStringToInt64(raw.ActivatedDuration)
+	if err != nil {
+		return nil, newConversionError("ActivatedDuration", raw.ActivatedDuration, err)
+	}
+
+	ParsedCanMove, err = helpers.ConvertStringToBool(raw.CanMove)
+	if err != nil {
+		return nil, newConversionError("CanMove", raw.CanMove, err)
+	}
+
+	ParsedDurationSeconds, err = helpers.ConvertStringToInt64(raw.DurationSeconds)
+	if err != nil {
+		return nil, newConversionError("DurationSeconds", raw.DurationSeconds, err)
+	}
+
+	ParsedGuestConnection, err = helpers.ConvertStringToBool(raw.GuestConnection)
+	if err != nil {
+		return nil, newConversionError("GuestConnection", raw.GuestConnection, err)
+	}
+
+	ParsedRecording, err = helpers.ConvertStringToBool(raw.Recording)
+	if err != nil {
+		return nil, newConversionError("Recording", raw.Recording, err)
+	}
+
+	ParsedRemoteTeardown, err = helpers.ConvertStringToBool(raw.RemoteTeardown)
+	if err != nil {
+		return nil, newConversionError("RemoteTeardown", raw.RemoteTeardown, err)
+	}
+
+	ParsedStreaming, err = helpers.ConvertStringToBool(raw.Streaming)
+	if err != nil {
+		return nil, newConversionError("Streaming", raw.Streaming, err)
+	}
+
+	return &CmsCallLeg{
+		ID:                *raw.Id,
+		CallID:            raw.Call,
+		Session:           session,
+		ActivatedDuration: ParsedActivatedDuration,
+		CanMove:           ParsedCanMove,
+		CdrTag:            raw.CdrTag,
+		Direction:         raw.Direction,
+		DisplayName:       raw.DisplayName,
+		DurationSeconds:   ParsedDurationSeconds,
+		EndTime:           ParsedEndTime,
+		GroupId:           raw.GroupId,
+		GuestConnection:   ParsedGuestConnection,
+		LocalAddress:      raw.LocalAddress,
+		LyncSubType:       raw.LyncSubType,
+		OwnerId:           raw.OwnerId,
+		Reason:            raw.Reason,
+		Recording:         ParsedRecording,
+		RemoteAddress:     raw.RemoteAddress,
+		RemoteParty:       raw.RemoteParty,
+		RemoteTeardown:    ParsedRemoteTeardown,
+		ReplacesSipCallId: raw.ReplacesSipCallId,
+		RxAudioCodec:      raw.RxAudioCodec,
+		RxVideoCodec:      raw.RxVideoCodec,
+		SipCallId:         raw.SipCallId,
+		StartTime:         ParsedStartTime,
+		State:             raw.State,
+		Streaming:         ParsedStreaming,
+		SubType:           raw.SubType,
+		TxAudioCodec:      raw.TxAudioCodec,
+		TxVideoCodec:      raw.TxVideoCodec,
+		Type:              raw.Type,
+	}, nil
+}
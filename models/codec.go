@@ -0,0 +1,251 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+var (
+	CodecMediaTypeAudio = "audio"
+	CodecMediaTypeVideo = "video"
+	CodecMediaTypeData  = "data"
+)
+
+// Codec is a row of the codecs lookup table, which is seeded by the migration.
+// Name is the normalized name parsed records are given, and codecs that only
+// differ in their bitrate share a Family, e.g. G.711 or G.729. Bitrate is in
+// bits per second and is nil for variable bitrate codecs.
+type Codec struct {
+	Name      string `gorm:"primaryKey"`
+	Family    string
+	MediaType string
+	Bitrate   *int64
+}
+
+func bitrate(bps int64) *int64 {
+	return &bps
+}
+
+// Codecs are the codecs CUCM, CUBE and the CMRs report.
+var Codecs = []*Codec{
+	{Name: "Non-standard", Family: "Non-standard", MediaType: CodecMediaTypeAudio},
+	{Name: "G.711 A-law 64k", Family: "G.711", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(64000)},
+	{Name: "G.711 A-law 56k", Family: "G.711", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(56000)},
+	{Name: "G.711 u-law 64k", Family: "G.711", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(64000)},
+	{Name: "G.711 u-law 56k", Family: "G.711", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(56000)},
+	{Name: "G.722 64k", Family: "G.722", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(64000)},
+	{Name: "G.722 56k", Family: "G.722", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(56000)},
+	{Name: "G.722 48k", Family: "G.722", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(48000)},
+	{Name: "G.722.1 32k", Family: "G.722.1", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(32000)},
+	{Name: "G.722.1 24k", Family: "G.722.1", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(24000)},
+	{Name: "G.723.1", Family: "G.723.1", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(6300)},
+	{Name: "G.723.1 5.3k", Family: "G.723.1", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(5300)},
+	{Name: "G.726 32k", Family: "G.726", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(32000)},
+	{Name: "G.726 24k", Family: "G.726", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(24000)},
+	{Name: "G.726 16k", Family: "G.726", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(16000)},
+	{Name: "G.728", Family: "G.728", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(16000)},
+	{Name: "G.729", Family: "G.729", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(8000)},
+	{Name: "G.729 Annex A", Family: "G.729", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(8000)},
+	{Name: "G.729 Annex B", Family: "G.729", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(8000)},
+	{Name: "G.729 Annex A and B", Family: "G.729", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(8000)},
+	{Name: "XV150 MR G.729 Annex A", Family: "G.729", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(8000)},
+	{Name: "NSE VBD G.729 Annex A", Family: "G.729", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(8000)},
+	{Name: "GSM", Family: "GSM", MediaType: CodecMediaTypeAudio},
+	{Name: "GSM Full Rate", Family: "GSM", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(13000)},
+	{Name: "GSM Half Rate", Family: "GSM", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(5600)},
+	{Name: "GSM Enhanced Full Rate", Family: "GSM", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(12200)},
+	{Name: "MPEG-1 Audio", Family: "MPEG-1 Audio", MediaType: CodecMediaTypeAudio},
+	{Name: "MPEG-2 Audio", Family: "MPEG-2 Audio", MediaType: CodecMediaTypeAudio},
+	{Name: "Wideband 256k", Family: "Wideband", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(256000)},
+	{Name: "AAC-LD", Family: "AAC-LD", MediaType: CodecMediaTypeAudio},
+	{Name: "MP4A-LATM", Family: "MP4A-LATM", MediaType: CodecMediaTypeAudio},
+	{Name: "MP4A-LATM 128k", Family: "MP4A-LATM", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(128000)},
+	{Name: "MP4A-LATM 64k", Family: "MP4A-LATM", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(64000)},
+	{Name: "MP4A-LATM 56k", Family: "MP4A-LATM", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(56000)},
+	{Name: "MP4A-LATM 48k", Family: "MP4A-LATM", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(48000)},
+	{Name: "MP4A-LATM 32k", Family: "MP4A-LATM", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(32000)},
+	{Name: "MP4A-LATM 24k", Family: "MP4A-LATM", MediaType: CodecMediaTypeAudio, Bitrate: bitrate(24000)},
+	{Name: "iLBC", Family: "iLBC", MediaType: CodecMediaTypeAudio},
+	{Name: "iSAC", Family: "iSAC", MediaType: CodecMediaTypeAudio},
+	{Name: "Opus", Family: "Opus", MediaType: CodecMediaTypeAudio},
+	{Name: "Data 64k", Family: "Data", MediaType: CodecMediaTypeData, Bitrate: bitrate(64000)},
+	{Name: "Data 56k", Family: "Data", MediaType: CodecMediaTypeData, Bitrate: bitrate(56000)},
+	{Name: "Clear Channel", Family: "Clear Channel", MediaType: CodecMediaTypeData, Bitrate: bitrate(64000)},
+	{Name: "H.261", Family: "H.261", MediaType: CodecMediaTypeVideo},
+	{Name: "H.263", Family: "H.263", MediaType: CodecMediaTypeVideo},
+	{Name: "H.264", Family: "H.264", MediaType: CodecMediaTypeVideo},
+	{Name: "Vieo", Family: "Vieo", MediaType: CodecMediaTypeVideo},
+}
+
+// The payload capabilities CUCM writes in the *mediacap_Payloadcapability and
+// *videocap_Codec fields.
+var cucmCodecTypes = map[int64]string{
+	1:   "Non-standard",
+	2:   "G.711 A-law 64k",
+	3:   "G.711 A-law 56k",
+	4:   "G.711 u-law 64k",
+	5:   "G.711 u-law 56k",
+	6:   "G.722 64k",
+	7:   "G.722 56k",
+	8:   "G.722 48k",
+	9:   "G.723.1",
+	10:  "G.728",
+	11:  "G.729",
+	12:  "G.729 Annex A",
+	13:  "MPEG-1 Audio",
+	14:  "MPEG-2 Audio",
+	15:  "G.729 Annex B",
+	16:  "G.729 Annex A and B",
+	18:  "GSM Full Rate",
+	19:  "GSM Half Rate",
+	20:  "GSM Enhanced Full Rate",
+	25:  "Wideband 256k",
+	32:  "Data 64k",
+	33:  "Data 56k",
+	40:  "G.722.1 32k",
+	41:  "G.722.1 24k",
+	42:  "AAC-LD",
+	43:  "MP4A-LATM 128k",
+	44:  "MP4A-LATM 64k",
+	45:  "MP4A-LATM 56k",
+	46:  "MP4A-LATM 48k",
+	47:  "MP4A-LATM 32k",
+	48:  "MP4A-LATM 24k",
+	49:  "MP4A-LATM",
+	80:  "GSM",
+	81:  "G.726 32k",
+	82:  "G.726 24k",
+	83:  "G.726 16k",
+	86:  "iLBC",
+	89:  "iSAC",
+	90:  "XV150 MR G.729 Annex A",
+	91:  "NSE VBD G.729 Annex A",
+	100: "H.261",
+	101: "H.263",
+	102: "Vieo",
+	103: "H.264",
+}
+
+// The codec names CUBE writes in CodecTypeRate and the CMRs in VoRxCodec, as
+// normalized by codecAlias.
+var codecAliases = map[string]string{
+	"g711ulaw":     "G.711 u-law 64k",
+	"g711mulaw":    "G.711 u-law 64k",
+	"g711u":        "G.711 u-law 64k",
+	"pcmu":         "G.711 u-law 64k",
+	"g711alaw":     "G.711 A-law 64k",
+	"g711a":        "G.711 A-law 64k",
+	"pcma":         "G.711 A-law 64k",
+	"g722":         "G.722 64k",
+	"g72264":       "G.722 64k",
+	"g7221":        "G.722.1 32k",
+	"g723":         "G.723.1",
+	"g7231":        "G.723.1",
+	"g723r63":      "G.723.1",
+	"g723ar63":     "G.723.1",
+	"g723r53":      "G.723.1 5.3k",
+	"g723ar53":     "G.723.1 5.3k",
+	"g726r32":      "G.726 32k",
+	"g726r24":      "G.726 24k",
+	"g726r16":      "G.726 16k",
+	"g728":         "G.728",
+	"g729":         "G.729",
+	"g729r8":       "G.729",
+	"g729a":        "G.729 Annex A",
+	"g729ar8":      "G.729 Annex A",
+	"g729b":        "G.729 Annex B",
+	"g729br8":      "G.729 Annex B",
+	"g729ab":       "G.729 Annex A and B",
+	"g729abr8":     "G.729 Annex A and B",
+	"gsm":          "GSM",
+	"gsmfr":        "GSM Full Rate",
+	"gsmhr":        "GSM Half Rate",
+	"gsmefr":       "GSM Enhanced Full Rate",
+	"aacld":        "AAC-LD",
+	"mp4alatm":     "MP4A-LATM",
+	"ilbc":         "iLBC",
+	"isac":         "iSAC",
+	"opus":         "Opus",
+	"clearchannel": "Clear Channel",
+	"h261":         "H.261",
+	"h263":         "H.263",
+	"h264":         "H.264",
+}
+
+var codecsByName = map[string]*Codec{}
+
+func init() {
+	for _, codec := range Codecs {
+		codecsByName[codec.Name] = codec
+	}
+}
+
+// CucmCodecType is a row of the cucm_codec_types lookup table, which maps the
+// payload capabilities of CUCM to the codecs table.
+type CucmCodecType struct {
+	Code  int64 `gorm:"primaryKey;autoIncrement:false"`
+	Codec string
+}
+
+func CucmCodecTypes() []*CucmCodecType {
+	rows := []*CucmCodecType{}
+	for code, name := range cucmCodecTypes {
+		rows = append(rows, &CucmCodecType{Code: code, Codec: name})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Code < rows[j].Code })
+	return rows
+}
+
+// DecodeCucmCodec returns the name and family of a CUCM payload capability, or
+// nil if the code is not known.
+func DecodeCucmCodec(code *int64) (name *string, family *string) {
+	if code == nil {
+		return nil, nil
+	}
+	codec, ok := codecsByName[cucmCodecTypes[*code]]
+	if !ok {
+		return nil, nil
+	}
+	return &codec.Name, &codec.Family
+}
+
+// DecodeCodec returns the name and family of a codec written as text, e.g.
+// g729r8 by CUBE or G.711u in a CMR, or nil if the codec is not known.
+func DecodeCodec(value *string) (name *string, family *string) {
+	if value == nil {
+		return nil, nil
+	}
+	codec, ok := codecsByName[codecAliases[codecAlias(*value)]]
+	if !ok {
+		return nil, nil
+	}
+	return &codec.Name, &codec.Family
+}
+
+// codecAlias lowercases value and strips everything but letters and digits.
+func codecAlias(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, value)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ziondials/go-cdr/helpers"
+)
+
+// ConversionError reports a raw field whose value cannot be converted to the
+// type of its column. Parse returns it so the record is rejected instead of
+// being stored with the field set to NULL.
+type ConversionError struct {
+	Field string
+	Value string
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("Error parsing %s %q: %s", e.Field, e.Value, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+func newConversionError(field string, value *string, err error) *ConversionError {
+	conversionError := &ConversionError{Field: field, Err: err}
+	if value != nil {
+		conversionError.Value = *value
+	}
+	return conversionError
+}
+
+// isInvalidNTPReference reports whether err only flags a time stamped by a
+// device without a valid NTP reference. The time itself is still converted.
+func isInvalidNTPReference(err error) bool {
+	return errors.Is(err, helpers.ErrInvalidNTPReferenceAsterisk) || errors.Is(err, helpers.ErrInvalidNTPReferencePeriod)
+}
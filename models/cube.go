@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ziondials/go-cdr/dialplan"
 	"github.com/ziondials/go-cdr/helpers"
 	"github.com/ziondials/go-cdr/logger"
 )
@@ -150,12 +151,12 @@ type CubeCDRs []*CubeCDR
 type CubeCDR struct {
 	ID                  string
 	InvalidNTPReference bool
-	Hostname            *string // `gorm:"uniqueIndex:cube_cdr_index"`
+	Hostname            *string `gorm:"size:255;not null;uniqueIndex:cube_cdr_index"`
 	Filename            *string
 	FileTimestamp       *int64
 	RecordTimestamp     *int64
-	CallId              *int64 // `gorm:"uniqueIndex:cube_cdr_index"`
-	CdrType             *int64
+	CallId              *int64 `gorm:"not null;uniqueIndex:cube_cdr_index"`
+	CdrType             *int64 `gorm:"not null;uniqueIndex:cube_cdr_index"` // Start and stop records share the rest of the key
 
 	AccountCode                     *string
 	AcomLevel                       *int64
@@ -178,11 +179,16 @@ type CubeCDR struct {
 	ChargeNumber                    *string
 	ChargedUnits                    *int64
 	Clid                            *string
+	ClidE164                        *string
 	CodecBytes                      *int64
 	CodecTypeRate                   *string
+	CodecName                       *string
+	CodecFamily                     *string
+	CallDirection                   *string
 	CustBizGrpId                    *string
 	DisconnectText                  *string
 	Dnis                            *string
+	DnisE164                        *string
 	DspId                           *string
 	EarlyPackets                    *int64
 	FacDigit                        *string
@@ -204,8 +210,8 @@ type CubeCDR struct {
 	FaxrelayStopTime                *string
 	FaxrelayTxPackets               *int64
 	FeatureId                       *string
-	FeatureIdField1                 *string // `gorm:"uniqueIndex:cube_cdr_index"`
-	FeatureIdField2                 *int64  // `gorm:"uniqueIndex:cube_cdr_index"`
+	FeatureIdField1                 *string
+	FeatureIdField2                 *int64
 	FeatureOpStatus                 *string
 	FeatureOpTime                   *string
 	FeatureOperation                *string
@@ -224,12 +230,16 @@ type CubeCDR struct {
 	GwFinalXlatedCgn                *string
 	GwFinalXlatedRdn                *string
 	GwRxdCdn                        *string
+	GwRxdCdnE164                    *string
 	GwRxdCgn                        *string
+	GwRxdCgnE164                    *string
 	GwRxdRdn                        *string
 	H323CallOrigin                  *string
-	H323ConfId                      *string // `gorm:"uniqueIndex:cube_cdr_index"`
+	H323ConfId                      *string `gorm:"size:255;not null;uniqueIndex:cube_cdr_index"`
 	H323ConnectTime                 *int64
 	H323DisconnectCause             *string
+	H323DisconnectCauseDescription  *string
+	H323DisconnectCauseCategory     *string
 	H323DisconnectTime              *int64
 	H323IvrOut                      *string
 	H323SetupTime                   *int64
@@ -256,7 +266,7 @@ type CubeCDR struct {
 	IpPbxMode                       *string
 	IpPhoneInfo                     *string
 	LatePackets                     *int64
-	LegType                         *int // `gorm:"uniqueIndex:cube_cdr_index"`
+	LegType                         *int `gorm:"not null;uniqueIndex:cube_cdr_index"`
 	LocalHostname                   *string
 	LogicalIfIndex                  *int64
 	LostPackets                     *int64
@@ -313,9 +323,13 @@ type CubeCDR struct {
 	VadEnable                       *bool
 	VoiceFeature                    *string
 	VoiceTxDuration                 *int64
+
+	FeatureEvents []*CubeFeatureEvent `gorm:"-"` // Written to cube_feature_events
 }
 
-func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
+// Parse converts the CDR. Numbers are normalized and the call is classified
+// with plan, and left empty if plan is nil.
+func (raw *RawCubeCDR) Parse(filename string, plan *dialplan.Plan) (*CubeCDR, error) {
 
 	var ParsedAccountCode *string
 	var ParsedAcomLevel *int64
@@ -553,143 +567,141 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 	}
 
 	ParsedTimeLocation, err := helpers.ExtractTimeLocationFromString(raw.H323SetupTime)
-	if err == helpers.ErrInvalidNTPReferenceAsterisk || err == helpers.ErrInvalidNTPReferencePeriod {
+	if isInvalidNTPReference(err) {
 		logger.Error("Error parsing Time Location From H323SetupTime: %s in %s", err, filename)
 		InvalidNTPReference = true
 	} else if err != nil {
-		logger.Error("Error parsing Time Location From H323SetupTime: %s in %s", err, filename)
-		InvalidNTPReference = true
+		return nil, newConversionError("H323SetupTime", raw.H323SetupTime, err)
 	}
 
 	ParsedAlertTime, err = helpers.ConvertStringToUnixTime(raw.AlertTime, ParsedTimeLocation)
-	if err != nil {
-		logger.Error("Error parsing AlertTime: %s in %s", err, filename)
+	if err != nil && !isInvalidNTPReference(err) {
+		return nil, newConversionError("AlertTime", raw.AlertTime, err)
 	}
 
 	ParsedAcomLevel, err = helpers.ConvertStringToInt64(raw.AcomLevel)
 	if err != nil {
-		logger.Error("Error parsing AcomLevel: %s in %s", err, filename)
+		return nil, newConversionError("AcomLevel", raw.AcomLevel, err)
 	}
 
 	ParsedBytesIn, err = helpers.ConvertStringToInt64(raw.BytesIn)
 	if err != nil {
-		logger.Error("Error parsing BytesIn: %s in %s", err, filename)
+		return nil, newConversionError("BytesIn", raw.BytesIn, err)
 	}
 
 	ParsedBytesOut, err = helpers.ConvertStringToInt64(raw.BytesOut)
 	if err != nil {
-		logger.Error("Error parsing BytesOut: %s in %s", err, filename)
+		return nil, newConversionError("BytesOut", raw.BytesOut, err)
 	}
 
 	ParsedCallId, err = helpers.ConvertStringToInt64(raw.CallId)
 	if err != nil {
-		logger.Error("Error parsing CallId: %s in %s", err, filename)
+		return nil, newConversionError("CallId", raw.CallId, err)
 	}
 
 	ParsedCdrType, err = helpers.ConvertStringToInt64(raw.CdrType)
 	if err != nil {
-		logger.Error("Error parsing CdrType: %s in %s", err, filename)
+		return nil, newConversionError("CdrType", raw.CdrType, err)
 	}
 
 	ParsedChargedUnits, err = helpers.ConvertStringToInt64(raw.ChargedUnits)
 	if err != nil {
-		logger.Error("Error parsing ChargedUnits: %s in %s", err, filename)
+		return nil, newConversionError("ChargedUnits", raw.ChargedUnits, err)
 	}
 
 	ParsedCodecBytes, err = helpers.ConvertStringToInt64(raw.CodecBytes)
 	if err != nil {
-		logger.Error("Error parsing CodecBytes: %s in %s", err, filename)
+		return nil, newConversionError("CodecBytes", raw.CodecBytes, err)
 	}
 
 	ParsedEarlyPackets, err = helpers.ConvertStringToInt64(raw.EarlyPackets)
 	if err != nil {
-		logger.Error("Error parsing EarlyPackets: %s in %s", err, filename)
+		return nil, newConversionError("EarlyPackets", raw.EarlyPackets, err)
 	}
 
 	ParsedFaxrelayInitHsMod, err = helpers.ConvertStringToInt64(raw.FaxrelayInitHsMod)
 	if err != nil {
-		logger.Error("Error parsing FaxrelayInitHsMod: %s in %s", err, filename)
+		return nil, newConversionError("FaxrelayInitHsMod", raw.FaxrelayInitHsMod, err)
 	}
 
 	ParsedFaxrelayJitBufOvflow, err = helpers.ConvertStringToInt64(raw.FaxrelayJitBufOvflow)
 	if err != nil {
-		logger.Error("Error parsing FaxrelayJitBufOvflow: %s in %s", err, filename)
+		return nil, newConversionError("FaxrelayJitBufOvflow", raw.FaxrelayJitBufOvflow, err)
 	}
 
 	ParsedFaxrelayMaxJitBufDepth, err = helpers.ConvertStringToInt64(raw.FaxrelayMaxJitBufDepth)
 	if err != nil {
-		logger.Error("Error parsing FaxrelayMaxJitBufDepth: %s in %s", err, filename)
+		return nil, newConversionError("FaxrelayMaxJitBufDepth", raw.FaxrelayMaxJitBufDepth, err)
 	}
 
 	ParsedFaxrelayMrHsMod, err = helpers.ConvertStringToInt64(raw.FaxrelayMrHsMod)
 	if err != nil {
-		logger.Error("Error parsing FaxrelayMrHsMod: %s in %s", err, filename)
+		return nil, newConversionError("FaxrelayMrHsMod", raw.FaxrelayMrHsMod, err)
 	}
 
 	ParsedFaxrelayNumPages, err = helpers.ConvertStringToInt64(raw.FaxrelayNumPages)
 	if err != nil {
-		logger.Error("Error parsing FaxrelayNumPages: %s in %s", err, filename)
+		return nil, newConversionError("FaxrelayNumPages", raw.FaxrelayNumPages, err)
 	}
 
 	ParsedFaxrelayPktConceal, err = helpers.ConvertStringToInt64(raw.FaxrelayPktConceal)
 	if err != nil {
-		logger.Error("Error parsing FaxrelayPktConceal: %s in %s", err, filename)
+		return nil, newConversionError("FaxrelayPktConceal", raw.FaxrelayPktConceal, err)
 	}
 
 	ParsedFaxrelayRxPackets, err = helpers.ConvertStringToInt64(raw.FaxrelayRxPackets)
 	if err != nil {
-		logger.Error("Error parsing FaxrelayRxPackets: %s in %s", err, filename)
+		return nil, newConversionError("FaxrelayRxPackets", raw.FaxrelayRxPackets, err)
 	}
 
 	ParsedFaxrelayTxPackets, err = helpers.ConvertStringToInt64(raw.FaxrelayTxPackets)
 	if err != nil {
-		logger.Error("Error parsing FaxrelayTxPackets: %s in %s", err, filename)
+		return nil, newConversionError("FaxrelayTxPackets", raw.FaxrelayTxPackets, err)
 	}
 
 	ParsedGapfillWithInterpolation, err = helpers.ConvertStringToInt64(raw.GapfillWithInterpolation)
 	if err != nil {
-		logger.Error("Error parsing GapfillWithInterpolation: %s in %s", err, filename)
+		return nil, newConversionError("GapfillWithInterpolation", raw.GapfillWithInterpolation, err)
 	}
 
 	ParsedGapfillWithPrediction, err = helpers.ConvertStringToInt64(raw.GapfillWithPrediction)
 	if err != nil {
-		logger.Error("Error parsing GapfillWithPrediction: %s in %s", err, filename)
+		return nil, newConversionError("GapfillWithPrediction", raw.GapfillWithPrediction, err)
 	}
 
 	ParsedGapfillWithRedundancy, err = helpers.ConvertStringToInt64(raw.GapfillWithRedundancy)
 	if err != nil {
-		logger.Error("Error parsing GapfillWithRedundancy: %s in %s", err, filename)
+		return nil, newConversionError("GapfillWithRedundancy", raw.GapfillWithRedundancy, err)
 	}
 
 	ParsedGapfillWithSilence, err = helpers.ConvertStringToInt64(raw.GapfillWithSilence)
 	if err != nil {
-		logger.Error("Error parsing GapfillWithSilence: %s in %s", err, filename)
+		return nil, newConversionError("GapfillWithSilence", raw.GapfillWithSilence, err)
 	}
 
 	ParsedH323VoiceQuality, err = helpers.ConvertStringToInt64(raw.H323VoiceQuality)
 	if err != nil {
-		logger.Error("Error parsing H323VoiceQuality: %s in %s", err, filename)
+		return nil, newConversionError("H323VoiceQuality", raw.H323VoiceQuality, err)
 	}
 
 	ParsedHiwaterPlayoutDelay, err = helpers.ConvertStringToInt64(raw.HiwaterPlayoutDelay)
 	if err != nil {
-		logger.Error("Error parsing HiwaterPlayoutDelay: %s in %s", err, filename)
+		return nil, newConversionError("HiwaterPlayoutDelay", raw.HiwaterPlayoutDelay, err)
 	}
 
 	ParsedIPHop, err = helpers.ConvertStringToInt64(raw.IPHop)
 	if err != nil {
-		logger.Error("Error parsing IPHop: %s in %s", err, filename)
+		return nil, newConversionError("IPHop", raw.IPHop, err)
 	}
 
 	ParsedLatePackets, err = helpers.ConvertStringToInt64(raw.LatePackets)
 	if err != nil {
-		logger.Error("Error parsing LatePackets: %s in %s", err, filename)
+		return nil, newConversionError("LatePackets", raw.LatePackets, err)
 	}
 
 	ConvertedLegType, err := helpers.ConvertStringToInt(raw.LegType)
 	if err != nil {
-		logger.Error("Error parsing LegType: %d in %s", *ConvertedLegType, filename)
-		ParsedLegType = nil
+		return nil, newConversionError("LegType", raw.LegType, err)
 	}
 
 	if ConvertedLegType != nil {
@@ -705,106 +717,106 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 
 	ParsedLogicalIfIndex, err = helpers.ConvertStringToInt64(raw.LogicalIfIndex)
 	if err != nil {
-		logger.Error("Error parsing LogicalIfIndex: %s in %s", err, filename)
+		return nil, newConversionError("LogicalIfIndex", raw.LogicalIfIndex, err)
 	}
 
 	ParsedLostPackets, err = helpers.ConvertStringToInt64(raw.LostPackets)
 	if err != nil {
-		logger.Error("Error parsing LostPackets: %s in %s", err, filename)
+		return nil, newConversionError("LostPackets", raw.LostPackets, err)
 	}
 
 	ParsedLowaterPlayoutDelay, err = helpers.ConvertStringToInt64(raw.LowaterPlayoutDelay)
 	if err != nil {
-		logger.Error("Error parsing LowaterPlayoutDelay: %s in %s", err, filename)
+		return nil, newConversionError("LowaterPlayoutDelay", raw.LowaterPlayoutDelay, err)
 	}
 
 	ParsedNoiseLevel, err = helpers.ConvertStringToInt64(raw.NoiseLevel)
 	if err != nil {
-		logger.Error("Error parsing NoiseLevel: %s in %s", err, filename)
+		return nil, newConversionError("NoiseLevel", raw.NoiseLevel, err)
 	}
 
 	ParsedOntimeRvPlayout, err = helpers.ConvertStringToInt64(raw.OntimeRvPlayout)
 	if err != nil {
-		logger.Error("Error parsing OntimeRvPlayout: %s in %s", err, filename)
+		return nil, newConversionError("OntimeRvPlayout", raw.OntimeRvPlayout, err)
 	}
 
 	ParsedOverrideSessionTime, err = helpers.ConvertStringToInt64(raw.OverrideSessionTime)
 	if err != nil {
-		logger.Error("Error parsing OverrideSessionTime: %s in %s", err, filename)
+		return nil, newConversionError("OverrideSessionTime", raw.OverrideSessionTime, err)
 	}
 
 	ParsedPaksIn, err = helpers.ConvertStringToInt64(raw.PaksIn)
 	if err != nil {
-		logger.Error("Error parsing PaksIn: %s in %s", err, filename)
+		return nil, newConversionError("PaksIn", raw.PaksIn, err)
 	}
 
 	ParsedPaksOut, err = helpers.ConvertStringToInt64(raw.PaksOut)
 	if err != nil {
-		logger.Error("Error parsing PaksOut: %s in %s", err, filename)
+		return nil, newConversionError("PaksOut", raw.PaksOut, err)
 	}
 
 	ParsedPeerId, err = helpers.ConvertStringToInt64(raw.PeerId)
 	if err != nil {
-		logger.Error("Error parsing PeerId: %s in %s", err, filename)
+		return nil, newConversionError("PeerId", raw.PeerId, err)
 	}
 
 	ParsedPeerIfIndex, err = helpers.ConvertStringToInt64(raw.PeerIfIndex)
 	if err != nil {
-		logger.Error("Error parsing PeerIfIndex: %s in %s", err, filename)
+		return nil, newConversionError("PeerIfIndex", raw.PeerIfIndex, err)
 	}
 
 	ParsedReceiveDelay, err = helpers.ConvertStringToInt64(raw.ReceiveDelay)
 	if err != nil {
-		logger.Error("Error parsing ReceiveDelay: %s in %s", err, filename)
+		return nil, newConversionError("ReceiveDelay", raw.ReceiveDelay, err)
 	}
 
 	ParsedRecordTimestamp, err = helpers.ConvertStringToInt64(raw.RecordTimestamp)
 	if err != nil {
-		logger.Error("Error parsing RecordTimestamp: %s in %s", err, filename)
+		return nil, newConversionError("RecordTimestamp", raw.RecordTimestamp, err)
 	}
 
 	ParsedRemoteMediaUdpPort, err = helpers.ConvertStringToInt64(raw.RemoteMediaUdpPort)
 	if err != nil {
-		logger.Error("Error parsing RemoteMediaUdpPort: %s in %s", err, filename)
+		return nil, newConversionError("RemoteMediaUdpPort", raw.RemoteMediaUdpPort, err)
 	}
 
 	ParsedRoundTripDelay, err = helpers.ConvertStringToInt64(raw.RoundTripDelay)
 	if err != nil {
-		logger.Error("Error parsing RoundTripDelay: %s in %s", err, filename)
+		return nil, newConversionError("RoundTripDelay", raw.RoundTripDelay, err)
 	}
 
 	ParsedTxDuration, err = helpers.ConvertStringToInt64(raw.TxDuration)
 	if err != nil {
-		logger.Error("Error parsing TxDuration: %s in %s", err, filename)
+		return nil, newConversionError("TxDuration", raw.TxDuration, err)
 	}
 
 	ParsedVoiceTxDuration, err = helpers.ConvertStringToInt64(raw.VoiceTxDuration)
 	if err != nil {
-		logger.Error("Error parsing VoiceTxDuration: %s in %s", err, filename)
+		return nil, newConversionError("VoiceTxDuration", raw.VoiceTxDuration, err)
 	}
 
 	ParsedFeatureIdField2, err = helpers.ConvertStringToUnixTime(raw.FeatureIdField2, ParsedTimeLocation)
-	if err != nil {
-		logger.Error("Error parsing FeatureIdField2: %s in %s", err, filename)
+	if err != nil && !isInvalidNTPReference(err) {
+		return nil, newConversionError("FeatureIdField2", raw.FeatureIdField2, err)
 	}
 
 	ParsedFiletimestamp, err = helpers.ConvertStringToUnixTime(raw.FileTimestamp, ParsedTimeLocation)
-	if err != nil {
-		logger.Error("Error parsing FileTimestamp: %s in %s", err, filename)
+	if err != nil && !isInvalidNTPReference(err) {
+		return nil, newConversionError("FileTimestamp", raw.FileTimestamp, err)
 	}
 	ParsedH323ConnectTime, err = helpers.ConvertStringToUnixTime(raw.H323ConnectTime, ParsedTimeLocation)
-	if err != nil {
-		logger.Error("Error parsing H323ConnectTime: %s in %s", err, filename)
+	if err != nil && !isInvalidNTPReference(err) {
+		return nil, newConversionError("H323ConnectTime", raw.H323ConnectTime, err)
 	}
 
 	ParsedH323DisconnectTime, err = helpers.ConvertStringToUnixTime(raw.H323DisconnectTime, ParsedTimeLocation)
-	if err != nil {
-		logger.Error("Error parsing H323DisconnectTime: %s in %s", err, filename)
+	if err != nil && !isInvalidNTPReference(err) {
+		return nil, newConversionError("H323DisconnectTime", raw.H323DisconnectTime, err)
 	}
 
 	ParsedH323SetupTime, err = helpers.ConvertStringToUnixTime(raw.H323SetupTime, ParsedTimeLocation)
-	if err != nil {
-		logger.Error("Error parsing H323SetupTime: %s in %s", err, filename)
+	if err != nil && !isInvalidNTPReference(err) {
+		return nil, newConversionError("H323SetupTime", raw.H323SetupTime, err)
 	}
 
 	TrimmedSessionProtocol := helpers.RemoveSpaceFromString(raw.SessionProtocol)
@@ -824,8 +836,25 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 		if !(helpers.ContainsString(&H323CauseCodes, TrimmedH323DisconnectCause)) {
 			ParsedH323DisconnectCause = nil
 			logger.Error("Error parsing H323DisconnectCause: %s in %s", *TrimmedH323DisconnectCause, filename)
+		} else {
+			ParsedH323DisconnectCause = TrimmedH323DisconnectCause
 		}
 	}
+	ParsedH323DisconnectCauseDescription, ParsedH323DisconnectCauseCategory := DecodeH323Cause(ParsedH323DisconnectCause)
+	ParsedCodecName, ParsedCodecFamily := DecodeCodec(ParsedCodecTypeRate)
+
+	ParsedClidE164 := plan.Normalize(ParsedClid)
+	ParsedDnisE164 := plan.Normalize(ParsedDnis)
+	ParsedGwRxdCgnE164 := plan.Normalize(ParsedGwRxdCgn)
+	ParsedGwRxdCdnE164 := plan.Normalize(ParsedGwRxdCdn)
+	CallingNumber, CalledNumber := ParsedGwRxdCgn, ParsedGwRxdCdn
+	if CallingNumber == nil {
+		CallingNumber = ParsedClid
+	}
+	if CalledNumber == nil {
+		CalledNumber = ParsedDnis
+	}
+	ParsedCallDirection := plan.Classify(CallingNumber, CalledNumber)
 
 	if helpers.ContainsString(&TwoWayCallTypes, raw.FeatureIdField1) {
 		TWCCallingNumber := raw.FeatureIdField3
@@ -836,7 +865,7 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 
 		ParsedTWCFeatureStatus, err = helpers.ConvertStringToInt64(raw.FeatureIdField5)
 		if err != nil {
-			logger.Error("Error parsing TWCFeatureStatus: %s in %s", err, filename)
+			return nil, newConversionError("TWCFeatureStatus", raw.FeatureIdField5, err)
 		}
 
 		TWCFeatureCorrelationId := raw.FeatureIdField6
@@ -862,7 +891,7 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 
 		ParsedCallForwardLegID, err = helpers.ConvertStringToInt64(raw.FeatureIdField6)
 		if err != nil {
-			logger.Error("Error parsing CallForwardLegID: %s in %s", err, filename)
+			return nil, newConversionError("CallForwardLegID", raw.FeatureIdField6, err)
 		}
 
 		CallForwardReason := raw.FeatureIdField5
@@ -896,7 +925,7 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 
 		ParsedTransferConsultationID, err = helpers.ConvertStringToInt64(raw.FeatureIdField6)
 		if err != nil {
-			logger.Error("Error parsing TransferConsultationID: %s in %s", err, filename)
+			return nil, newConversionError("TransferConsultationID", raw.FeatureIdField6, err)
 		}
 
 		TransferLegID := raw.FeatureIdField7
@@ -907,7 +936,7 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 
 		ParsedTransferStatus, err = helpers.ConvertStringToInt64(raw.FeatureIdField9)
 		if err != nil {
-			logger.Error("Error parsing TransferStatus: %s in %s", err, filename)
+			return nil, newConversionError("TransferStatus", raw.FeatureIdField9, err)
 		}
 
 		TransferredFromPart := raw.FeatureIdField10
@@ -938,17 +967,17 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 
 		ParsedHoldingDN, err = helpers.ConvertStringToInt64(raw.FeatureIdField8)
 		if err != nil {
-			logger.Error("Error parsing HoldingDN: %s in %s", err, filename)
+			return nil, newConversionError("HoldingDN", raw.FeatureIdField8, err)
 		}
 
 		ParsedHeldDN, err = helpers.ConvertStringToInt64(raw.FeatureIdField9)
 		if err != nil {
-			logger.Error("Error parsing HeldDN: %s in %s", err, filename)
+			return nil, newConversionError("HeldDN", raw.FeatureIdField9, err)
 		}
 
 		ParsedHoldSharedLine, err = helpers.ConvertStringToInt64(raw.FeatureIdField10)
 		if err != nil {
-			logger.Error("Error parsing HoldSharedLine: %s in %s", err, filename)
+			return nil, newConversionError("HoldSharedLine", raw.FeatureIdField10, err)
 		}
 
 		HoldUsername := raw.FeatureIdField11
@@ -958,7 +987,7 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 		ParsedHoldPhoneTag = HoldPhoneTag
 	}
 
-	return &CubeCDR{
+	cdr := &CubeCDR{
 		ID:                              uuid.New().String(),
 		FileTimestamp:                   ParsedFiletimestamp,
 		RecordTimestamp:                 ParsedRecordTimestamp,
@@ -1002,10 +1031,14 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 		GwFinalXlatedCgn:                ParsedGwFinalXlatedCgn,
 		GwFinalXlatedRdn:                ParsedGwFinalXlatedRdn,
 		GwRxdCdn:                        ParsedGwRxdCdn,
+		GwRxdCdnE164:                    ParsedGwRxdCdnE164,
 		GwRxdCgn:                        ParsedGwRxdCgn,
+		GwRxdCgnE164:                    ParsedGwRxdCgnE164,
 		GwRxdRdn:                        ParsedGwRxdRdn,
 		H323ConnectTime:                 ParsedH323ConnectTime,
 		H323DisconnectCause:             ParsedH323DisconnectCause,
+		H323DisconnectCauseDescription:  ParsedH323DisconnectCauseDescription,
+		H323DisconnectCauseCategory:     ParsedH323DisconnectCauseCategory,
 		H323DisconnectTime:              ParsedH323DisconnectTime,
 		H323SetupTime:                   ParsedH323SetupTime,
 		H323VoiceQuality:                ParsedH323VoiceQuality,
@@ -1062,10 +1095,15 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 		CarrierId:                       ParsedCarrierId,
 		ChargeNumber:                    ParsedChargeNumber,
 		Clid:                            ParsedClid,
+		ClidE164:                        ParsedClidE164,
 		CodecTypeRate:                   ParsedCodecTypeRate,
+		CodecName:                       ParsedCodecName,
+		CodecFamily:                     ParsedCodecFamily,
+		CallDirection:                   ParsedCallDirection,
 		CustBizGrpId:                    ParsedCustBizGrpId,
 		DisconnectText:                  raw.DisconnectText,
 		Dnis:                            ParsedDnis,
+		DnisE164:                        ParsedDnisE164,
 		DspId:                           ParsedDspId,
 		FacDigit:                        ParsedFacDigit,
 		FacStatus:                       ParsedFacStatus,
@@ -1115,7 +1153,21 @@ func (raw *RawCubeCDR) Parse(filename string) (*CubeCDR, error) {
 		Username:                        ParsedUsername,
 		VadEnable:                       ParsedVadEnable,
 		VoiceFeature:                    ParsedVoiceFeature,
-	}, nil
+	}
+
+	featureVSAs := append([]RawCubeFeatureVSA{{
+		raw.FeatureIdField1, raw.FeatureIdField2, raw.FeatureIdField3, raw.FeatureIdField4,
+		raw.FeatureIdField5, raw.FeatureIdField6, raw.FeatureIdField7, raw.FeatureIdField8,
+		raw.FeatureIdField9, raw.FeatureIdField10, raw.FeatureIdField11, raw.FeatureIdField12,
+	}}, raw.AdditionalFeatureVSAs...)
+
+	for _, featureVSA := range featureVSAs {
+		if event := featureVSA.Parse(cdr, ParsedTimeLocation, filename); event != nil {
+			cdr.FeatureEvents = append(cdr.FeatureEvents, event)
+		}
+	}
+
+	return cdr, nil
 }
 
 type RawCubeCDR struct {
@@ -1252,4 +1304,8 @@ type RawCubeCDR struct {
 	Hostname                 *string
 	Filename                 *string
 	FileTimestamp            *string
+
+	// A RADIUS request can carry several feature VSAs. The first is held in
+	// FeatureIdField1..12 like in a gw-accounting file, and the others here.
+	AdditionalFeatureVSAs []RawCubeFeatureVSA
 }
@@ -0,0 +1,242 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	CubeCdrTypeStart int64 = 1
+	CubeCdrTypeStop  int64 = 2
+
+	// H323CallOrigin of the leg the gateway answered and of the leg it
+	// originated.
+	CubeCallOriginAnswer    = "answer"
+	CubeCallOriginOriginate = "originate"
+)
+
+// CubeCall is a call through a CUBE gateway, stitched together from the
+// CubeCDRs of its legs, which share Hostname and H323ConfId. The ingress leg is
+// the leg the gateway answered and the egress leg is the leg it originated.
+type CubeCall struct {
+	ID         string
+	Hostname   *string `gorm:"size:255;not null;uniqueIndex:cube_call_index"`
+	H323ConfId *string `gorm:"size:255;not null;uniqueIndex:cube_call_index"`
+	LegCount   *int64
+	Completed  bool // Every leg has a stop record
+
+	CallingNumber              *string
+	DialedNumber               *string
+	CallingNumberE164          *string
+	DialedNumberE164           *string
+	CallDirection              *string
+	TranslatedCallingNumber    *string
+	TranslatedNumber           *string
+	SetupTime                  *int64
+	ConnectTime                *int64
+	DisconnectTime             *int64
+	DurationSeconds            *int64
+	DisconnectCause            *string
+	DisconnectCauseDescription *string
+	DisconnectCauseCategory    *string
+	DisconnectText             *string
+
+	IngressCallId             *int64
+	IngressLegType            *int
+	IngressPeerId             *int64
+	IngressPeerAddress        *string
+	IngressRemoteMediaAddress *string
+	IngressSessionProtocol    *string
+	IngressTrunkgroupLabel    *string
+	IngressCodecTypeRate      *string
+	IngressCodecName          *string
+	IngressCodecFamily        *string
+	IngressDisconnectCause    *string
+	IngressPaksIn             *int64
+	IngressPaksOut            *int64
+	IngressBytesIn            *int64
+	IngressBytesOut           *int64
+	IngressLostPackets        *int64
+	IngressEarlyPackets       *int64
+	IngressLatePackets        *int64
+	IngressReceiveDelay       *int64
+	IngressRoundTripDelay     *int64
+	IngressVoiceQuality       *int64
+
+	EgressCallId             *int64
+	EgressLegType            *int
+	EgressPeerId             *int64
+	EgressPeerAddress        *string
+	EgressRemoteMediaAddress *string
+	EgressSessionProtocol    *string
+	EgressTrunkgroupLabel    *string
+	EgressCodecTypeRate      *string
+	EgressCodecName          *string
+	EgressCodecFamily        *string
+	EgressDisconnectCause    *string
+	EgressPaksIn             *int64
+	EgressPaksOut            *int64
+	EgressBytesIn            *int64
+	EgressBytesOut           *int64
+	EgressLostPackets        *int64
+	EgressEarlyPackets       *int64
+	EgressLatePackets        *int64
+	EgressReceiveDelay       *int64
+	EgressRoundTripDelay     *int64
+	EgressVoiceQuality       *int64
+}
+
+// NewCubeCall stitches the CubeCDRs of a single call into a CubeCall. The stop
+// record of a leg is used when there is one, since it carries everything the
+// start record does. When a call has several answered or originated legs, e.g.
+// after a transfer, the first answered leg is the ingress leg and the last
+// originated leg is the egress leg.
+func NewCubeCall(cdrs []*CubeCDR) *CubeCall {
+
+	if len(cdrs) == 0 {
+		return nil
+	}
+
+	legs := map[int64]*CubeCDR{}
+	stopped := map[int64]bool{}
+	for _, cdr := range cdrs {
+		if cdr.CallId == nil {
+			continue
+		}
+		isStop := cdr.CdrType != nil && *cdr.CdrType == CubeCdrTypeStop
+		if _, ok := legs[*cdr.CallId]; !ok || isStop {
+			legs[*cdr.CallId] = cdr
+		}
+		stopped[*cdr.CallId] = stopped[*cdr.CallId] || isStop
+	}
+
+	callIds := make([]int64, 0, len(legs))
+	for callId := range legs {
+		callIds = append(callIds, callId)
+	}
+	sort.Slice(callIds, func(i, j int) bool { return callIds[i] < callIds[j] })
+
+	var ingress, egress *CubeCDR
+	completed := len(callIds) > 0
+	for _, callId := range callIds {
+		leg := legs[callId]
+		completed = completed && stopped[callId]
+		switch {
+		case leg.H323CallOrigin != nil && strings.EqualFold(*leg.H323CallOrigin, CubeCallOriginAnswer):
+			if ingress == nil {
+				ingress = leg
+			}
+		case leg.H323CallOrigin != nil && strings.EqualFold(*leg.H323CallOrigin, CubeCallOriginOriginate):
+			egress = leg
+		}
+	}
+
+	legCount := int64(len(callIds))
+	call := &CubeCall{
+		ID:         uuid.New().String(),
+		Hostname:   cdrs[0].Hostname,
+		H323ConfId: cdrs[0].H323ConfId,
+		LegCount:   &legCount,
+		Completed:  completed,
+	}
+
+	if ingress != nil {
+		call.CallingNumber = ingress.GwRxdCgn
+		call.DialedNumber = ingress.GwRxdCdn
+		call.CallingNumberE164 = ingress.GwRxdCgnE164
+		call.DialedNumberE164 = ingress.GwRxdCdnE164
+		call.CallDirection = ingress.CallDirection
+		call.SetupTime = ingress.H323SetupTime
+		call.ConnectTime = ingress.H323ConnectTime
+		call.DisconnectTime = ingress.H323DisconnectTime
+		call.DisconnectCause = ingress.H323DisconnectCause
+		call.DisconnectCauseDescription = ingress.H323DisconnectCauseDescription
+		call.DisconnectCauseCategory = ingress.H323DisconnectCauseCategory
+		call.DisconnectText = ingress.DisconnectText
+
+		call.IngressCallId = ingress.CallId
+		call.IngressLegType = ingress.LegType
+		call.IngressPeerId = ingress.PeerId
+		call.IngressPeerAddress = ingress.PeerAddress
+		call.IngressRemoteMediaAddress = ingress.RemoteMediaAddress
+		call.IngressSessionProtocol = ingress.SessionProtocol
+		call.IngressTrunkgroupLabel = ingress.InTrunkgroupLabel
+		call.IngressCodecTypeRate = ingress.CodecTypeRate
+		call.IngressCodecName = ingress.CodecName
+		call.IngressCodecFamily = ingress.CodecFamily
+		call.IngressDisconnectCause = ingress.H323DisconnectCause
+		call.IngressPaksIn = ingress.PaksIn
+		call.IngressPaksOut = ingress.PaksOut
+		call.IngressBytesIn = ingress.BytesIn
+		call.IngressBytesOut = ingress.BytesOut
+		call.IngressLostPackets = ingress.LostPackets
+		call.IngressEarlyPackets = ingress.EarlyPackets
+		call.IngressLatePackets = ingress.LatePackets
+		call.IngressReceiveDelay = ingress.ReceiveDelay
+		call.IngressRoundTripDelay = ingress.RoundTripDelay
+		call.IngressVoiceQuality = ingress.H323VoiceQuality
+	}
+
+	if egress != nil {
+		call.TranslatedCallingNumber = egress.GwFinalXlatedCgn
+		call.TranslatedNumber = egress.GwFinalXlatedCdn
+
+		call.EgressCallId = egress.CallId
+		call.EgressLegType = egress.LegType
+		call.EgressPeerId = egress.PeerId
+		call.EgressPeerAddress = egress.PeerAddress
+		call.EgressRemoteMediaAddress = egress.RemoteMediaAddress
+		call.EgressSessionProtocol = egress.SessionProtocol
+		call.EgressTrunkgroupLabel = egress.OutTrunkgroupLabel
+		call.EgressCodecTypeRate = egress.CodecTypeRate
+		call.EgressCodecName = egress.CodecName
+		call.EgressCodecFamily = egress.CodecFamily
+		call.EgressDisconnectCause = egress.H323DisconnectCause
+		call.EgressPaksIn = egress.PaksIn
+		call.EgressPaksOut = egress.PaksOut
+		call.EgressBytesIn = egress.BytesIn
+		call.EgressBytesOut = egress.BytesOut
+		call.EgressLostPackets = egress.LostPackets
+		call.EgressEarlyPackets = egress.EarlyPackets
+		call.EgressLatePackets = egress.LatePackets
+		call.EgressReceiveDelay = egress.ReceiveDelay
+		call.EgressRoundTripDelay = egress.RoundTripDelay
+		call.EgressVoiceQuality = egress.H323VoiceQuality
+
+		// Calls the gateway did not answer itself only have an egress leg.
+		if ingress == nil {
+			call.SetupTime = egress.H323SetupTime
+			call.ConnectTime = egress.H323ConnectTime
+			call.DisconnectTime = egress.H323DisconnectTime
+			call.DisconnectCause = egress.H323DisconnectCause
+			call.DisconnectCauseDescription = egress.H323DisconnectCauseDescription
+			call.DisconnectCauseCategory = egress.H323DisconnectCauseCategory
+			call.DisconnectText = egress.DisconnectText
+			call.CallDirection = egress.CallDirection
+		}
+	}
+
+	if call.ConnectTime != nil && call.DisconnectTime != nil && *call.DisconnectTime >= *call.ConnectTime {
+		duration := *call.DisconnectTime - *call.ConnectTime
+		call.DurationSeconds = &duration
+	}
+
+	return call
+}
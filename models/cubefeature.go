@@ -0,0 +1,148 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+)
+
+// CubeFeatureEvent is a single feature VSA of a CUBE call leg, e.g. one hold or
+// one transfer. The leg is identified by Hostname, H323ConfId and CallId, as in
+// cube_cdrs and cube_calls. Events of the same transfer or forward share their
+// CorrelationId, so a chain can be rebuilt by ordering them on FeatureTime.
+//
+// FromNumber and ToNumber are the calling and called numbers of a TWC, the
+// forwarding and forwarded-to numbers of a call forward, the transferring and
+// transferred-to numbers of a transfer and the holding and held numbers of a
+// hold. RedirectedNumber is the number that was forwarded or transferred.
+type CubeFeatureEvent struct {
+	ID               string
+	Hostname         *string `gorm:"size:255;not null;uniqueIndex:cube_feature_event_index"`
+	H323ConfId       *string `gorm:"size:255;not null;uniqueIndex:cube_feature_event_index"`
+	CallId           *int64  `gorm:"not null;uniqueIndex:cube_feature_event_index"`
+	Feature          *string `gorm:"size:64;not null;uniqueIndex:cube_feature_event_index"` // Short, so the key fits the index size limits of MySQL and SQL Server
+	FeatureTime      *int64  `gorm:"not null;uniqueIndex:cube_feature_event_index"`
+	FeatureId        *string `gorm:"size:64;not null;uniqueIndex:cube_feature_event_index"`
+	Status           *string
+	CorrelationId    *string `gorm:"index"`
+	LegId            *string
+	ConsultationId   *string
+	Reason           *string
+	TransferStatus   *string
+	ForwardCount     *string
+	FromNumber       *string
+	ToNumber         *string
+	RedirectedNumber *string
+	OriginalNumber   *string
+	SharedLine       *string
+	Username         *string
+	PhoneTag         *string
+}
+
+// RawCubeFeatureVSA holds the values of a feature VSA in the order the gateway
+// sends them, as in the feature-id columns of a gw-accounting file. The first
+// value is the feature name and the second the time of the feature.
+type RawCubeFeatureVSA [12]*string
+
+// Parse converts a feature VSA of cdr. Feature VSAs without a known feature
+// name are skipped.
+func (raw *RawCubeFeatureVSA) Parse(cdr *CubeCDR, location *time.Location, filename string) *CubeFeatureEvent {
+
+	field := func(position int) *string {
+		value := raw[position-1]
+		if value == nil || strings.TrimSpace(*value) == "" {
+			return nil
+		}
+		trimmed := strings.TrimSpace(*value)
+		return &trimmed
+	}
+
+	feature := helpers.RemoveSpaceFromString(raw[0])
+	if feature == nil {
+		return nil
+	}
+
+	ParsedFeatureTime, err := helpers.ConvertStringToUnixTime(raw[1], location)
+	if err != nil {
+		logger.Error("Error parsing FeatureTime: %s in %s", err, filename)
+	}
+
+	event := &CubeFeatureEvent{
+		ID:          uuid.New().String(),
+		Hostname:    cdr.Hostname,
+		H323ConfId:  cdr.H323ConfId,
+		CallId:      cdr.CallId,
+		Feature:     feature,
+		FeatureTime: ParsedFeatureTime,
+	}
+
+	switch {
+	case helpers.ContainsString(&TwoWayCallTypes, feature):
+		event.FromNumber = field(3)
+		event.ToNumber = field(4)
+		event.Status = field(5)
+		event.CorrelationId = field(6)
+		event.FeatureId = field(7)
+		event.LegId = field(8)
+
+	case helpers.ContainsString(&CallForwardTypes, feature):
+		event.Status = field(3)
+		event.FeatureId = field(4)
+		event.CorrelationId = field(5)
+		event.LegId = field(6)
+		event.Reason = field(7)
+		event.ForwardCount = field(8)
+		event.FromNumber = field(9)
+		event.RedirectedNumber = field(10)
+		event.ToNumber = field(11)
+		event.OriginalNumber = field(12)
+
+	case helpers.ContainsString(&TransferTypes, feature):
+		event.Status = field(3)
+		event.FeatureId = field(4)
+		event.CorrelationId = field(5)
+		event.ConsultationId = field(6)
+		event.LegId = field(7)
+		event.Reason = field(8)
+		event.TransferStatus = field(9)
+		event.FromNumber = field(10)
+		event.RedirectedNumber = field(11)
+		event.ToNumber = field(12)
+
+	case helpers.ContainsString(&HoldTypes, feature):
+		event.Status = field(3)
+		event.FeatureId = field(4)
+		event.CorrelationId = field(5)
+		event.LegId = field(6)
+		event.Reason = field(7)
+		event.FromNumber = field(8)
+		event.ToNumber = field(9)
+		event.SharedLine = field(10)
+		event.Username = field(11)
+		event.PhoneTag = field(12)
+
+	default:
+		logger.Error("Error parsing FeatureIdField1: unknown feature %s in %s", *feature, filename)
+		return nil
+	}
+
+	return event
+}
@@ -23,8 +23,8 @@ type CucmCdr struct {
 	FileDateTime                            *int64
 	FileSequenceNumber                      *int64
 	Cdrrecordtype                           *int64
-	Globalcallid_Callmanagerid              *int64
-	Globalcallid_Callid                     *int64
+	Globalcallid_Callmanagerid              *int64 `gorm:"index:idx_cucm_cdrs_globalcallid"`
+	Globalcallid_Callid                     *int64 `gorm:"index:idx_cucm_cdrs_globalcallid"`
 	Origlegcallidentifier                   *int64
 	Datetimeorigination                     *int64
 	Orignodeid                              *int64
@@ -34,6 +34,8 @@ type CucmCdr struct {
 	Callingpartyunicodeloginuserid          *string
 	Origcause_Location                      *int64
 	Origcause_Value                         *int64
+	Origcause_Description                   *string
+	Origcause_Category                      *string
 	Origprecedencelevel                     *int64
 	Origmediatransportaddress_IP            *string
 	Origmediatransportaddress_Port          *int64
@@ -56,6 +58,8 @@ type CucmCdr struct {
 	Finalcalledpartyunicodeloginuserid      *string
 	Destcause_Location                      *int64
 	Destcause_Value                         *int64
+	Destcause_Description                   *string
+	Destcause_Category                      *string
 	Destprecedencelevel                     *int64
 	Destmediatransportaddress_IP            *string
 	Destmediatransportaddress_Port          *int64
@@ -154,4 +158,36 @@ type CucmCdr struct {
 	Destdevicetype                          *string
 	Origdevicesessionid                     *string
 	Destdevicesessionid                     *string
+
+	// Descriptions of the redirect, on behalf of and routing reason codes
+	Origcallterminationonbehalfof_Description     *string
+	Destcallterminationonbehalfof_Description     *string
+	Origcalledpartyredirectonbehalfof_Description *string
+	Lastredirectredirectonbehalfof_Description    *string
+	Origcalledpartyredirectreason_Description     *string
+	Lastredirectredirectreason_Description        *string
+	Joinonbehalfof_Description                    *string
+	Currentroutingreason_Description              *string
+	Origroutingreason_Description                 *string
+	Lastredirectingroutingreason_Description      *string
+
+	// Normalized names and families of the codecs, see the codecs table
+	Origmediacap_Payloadcapability_Name   *string
+	Origmediacap_Payloadcapability_Family *string
+	Destmediacap_Payloadcapability_Name   *string
+	Destmediacap_Payloadcapability_Family *string
+	Origvideocap_Codec_Name               *string
+	Origvideocap_Codec_Family             *string
+	Destvideocap_Codec_Name               *string
+	Destvideocap_Codec_Family             *string
+	Origvideocap_Codec_Channel2_Name      *string
+	Origvideocap_Codec_Channel2_Family    *string
+	Destvideocap_Codec_Channel2_Name      *string
+	Destvideocap_Codec_Channel2_Family    *string
+
+	// E.164 forms of the numbers and the direction of the call, see the dial plan
+	Callingpartynumber_E164        *string
+	Originalcalledpartynumber_E164 *string
+	Finalcalledpartynumber_E164    *string
+	CallDirection                  *string
 }
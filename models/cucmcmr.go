@@ -15,6 +15,15 @@
 
 package models
 
+// Legs of a CucmCdr that a CucmCmr can be attached to.
+var (
+	CucmCdrLegOrig = "orig"
+	CucmCdrLegDest = "dest"
+)
+
+// CucmCmr is a call management record, which carries the voice quality of one
+// leg of a call. CucmCdrID and CucmCdrLeg are set by the correlation step once
+// the CucmCdr of the leg has been loaded, whichever file arrives first.
 type CucmCmr struct {
 	ID                                  string
 	Originpkid                          *string `gorm:"unique;not null"`
@@ -23,8 +32,8 @@ type CucmCmr struct {
 	FileDateTime                        *int64
 	FileSequenceNumber                  *int64
 	Cdrrecordtype                       *int64
-	Globalcallid_Callmanagerid          *int64
-	Globalcallid_Callid                 *int64
+	Globalcallid_Callmanagerid          *int64 `gorm:"index:idx_cucm_cmrs_globalcallid"`
+	Globalcallid_Callid                 *int64 `gorm:"index:idx_cucm_cmrs_globalcallid"`
 	Nodeid                              *int64
 	Directorynum                        *string
 	Callidentifier                      *int64
@@ -75,6 +84,8 @@ type CucmCmr struct {
 	VQSCS                               *int64
 	Vqver                               *float64
 	Vqvorxcodec                         *string
+	Vqvorxcodec_Name                    *string
+	Vqvorxcodec_Family                  *string
 	VQCID                               *int64
 	Vqvopktsizems                       *int64
 	Vqvopktlost                         *int64
@@ -86,4 +97,7 @@ type CucmCmr struct {
 	Vqmlqkmn                            *float64
 	Vqmlqkmx                            *float64
 	Vqmlqkvr                            *float64
+	CucmCdrID                           *string `gorm:"index"`
+	CucmCdrLeg                          *string
+	CucmCdr                             *CucmCdr `gorm:"constraint:OnDelete:SET NULL"`
 }
@@ -0,0 +1,273 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"strconv"
+)
+
+// CucmRedirectReason is the reason of a redirect in Origcalledpartyredirectreason
+// and Lastredirectredirectreason.
+type CucmRedirectReason int64
+
+const (
+	CucmRedirectReasonUnknown              CucmRedirectReason = 0
+	CucmRedirectReasonCallForwardBusy      CucmRedirectReason = 1
+	CucmRedirectReasonCallForwardNoAnswer  CucmRedirectReason = 2
+	CucmRedirectReasonCallTransfer         CucmRedirectReason = 4
+	CucmRedirectReasonCallPickup           CucmRedirectReason = 5
+	CucmRedirectReasonCallPark             CucmRedirectReason = 7
+	CucmRedirectReasonCallParkPickup       CucmRedirectReason = 8
+	CucmRedirectReasonCPEOutOfOrder        CucmRedirectReason = 9
+	CucmRedirectReasonCallForward          CucmRedirectReason = 10
+	CucmRedirectReasonCallParkReversion    CucmRedirectReason = 11
+	CucmRedirectReasonCallForwardAll       CucmRedirectReason = 15
+	CucmRedirectReasonCallDeflection       CucmRedirectReason = 18
+	CucmRedirectReasonBlindTransfer        CucmRedirectReason = 34
+	CucmRedirectReasonCallImmediateDivert  CucmRedirectReason = 50
+	CucmRedirectReasonCallForwardAlternate CucmRedirectReason = 66
+	CucmRedirectReasonCallForwardOnFailure CucmRedirectReason = 82
+	CucmRedirectReasonConference           CucmRedirectReason = 98
+	CucmRedirectReasonBarge                CucmRedirectReason = 114
+	CucmRedirectReasonAAR                  CucmRedirectReason = 129
+	CucmRedirectReasonRefer                CucmRedirectReason = 130
+	CucmRedirectReasonReplaces             CucmRedirectReason = 146
+	CucmRedirectReasonRedirection          CucmRedirectReason = 162
+	CucmRedirectReasonRecording            CucmRedirectReason = 354
+	CucmRedirectReasonMonitoring           CucmRedirectReason = 370
+)
+
+var cucmRedirectReasonNames = map[CucmRedirectReason]string{
+	CucmRedirectReasonUnknown:              "Unknown",
+	CucmRedirectReasonCallForwardBusy:      "Call Forward Busy",
+	CucmRedirectReasonCallForwardNoAnswer:  "Call Forward No Answer",
+	CucmRedirectReasonCallTransfer:         "Call Transfer",
+	CucmRedirectReasonCallPickup:           "Call Pickup",
+	CucmRedirectReasonCallPark:             "Call Park",
+	CucmRedirectReasonCallParkPickup:       "Call Park Pickup",
+	CucmRedirectReasonCPEOutOfOrder:        "CPE Out of Order",
+	CucmRedirectReasonCallForward:          "Call Forward",
+	CucmRedirectReasonCallParkReversion:    "Call Park Reversion",
+	CucmRedirectReasonCallForwardAll:       "Call Forward All",
+	CucmRedirectReasonCallDeflection:       "Call Deflection",
+	CucmRedirectReasonBlindTransfer:        "Blind Transfer",
+	CucmRedirectReasonCallImmediateDivert:  "Call Immediate Divert",
+	CucmRedirectReasonCallForwardAlternate: "Call Forward Alternate Party",
+	CucmRedirectReasonCallForwardOnFailure: "Call Forward On Failure",
+	CucmRedirectReasonConference:           "Conference",
+	CucmRedirectReasonBarge:                "Barge",
+	CucmRedirectReasonAAR:                  "AAR",
+	CucmRedirectReasonRefer:                "Refer",
+	CucmRedirectReasonReplaces:             "Replaces",
+	CucmRedirectReasonRedirection:          "Redirection (3xx)",
+	CucmRedirectReasonRecording:            "Recording",
+	CucmRedirectReasonMonitoring:           "Monitoring",
+}
+
+func (r CucmRedirectReason) String() string {
+	if name, ok := cucmRedirectReasonNames[r]; ok {
+		return name
+	}
+	return "CucmRedirectReason(" + strconv.FormatInt(int64(r), 10) + ")"
+}
+
+// CucmOnBehalfOf is the feature that caused a redirect, a termination or a join
+// in the *onbehalfof fields.
+type CucmOnBehalfOf int64
+
+const (
+	CucmOnBehalfOfUnknown                    CucmOnBehalfOf = 0
+	CucmOnBehalfOfCCTILine                   CucmOnBehalfOf = 1
+	CucmOnBehalfOfUnicastSharedResource      CucmOnBehalfOf = 2
+	CucmOnBehalfOfCallPark                   CucmOnBehalfOf = 3
+	CucmOnBehalfOfConference                 CucmOnBehalfOf = 4
+	CucmOnBehalfOfCallForward                CucmOnBehalfOf = 5
+	CucmOnBehalfOfMeetMeConference           CucmOnBehalfOf = 6
+	CucmOnBehalfOfMeetMeConferenceIntercepts CucmOnBehalfOf = 7
+	CucmOnBehalfOfMessageWaiting             CucmOnBehalfOf = 8
+	CucmOnBehalfOfMulticastSharedResource    CucmOnBehalfOf = 9
+	CucmOnBehalfOfTransfer                   CucmOnBehalfOf = 10
+	CucmOnBehalfOfSSAPIManager               CucmOnBehalfOf = 11
+	CucmOnBehalfOfDevice                     CucmOnBehalfOf = 12
+	CucmOnBehalfOfCallControl                CucmOnBehalfOf = 13
+	CucmOnBehalfOfImmediateDivert            CucmOnBehalfOf = 14
+	CucmOnBehalfOfBarge                      CucmOnBehalfOf = 15
+	CucmOnBehalfOfPickup                     CucmOnBehalfOf = 16
+	CucmOnBehalfOfRefer                      CucmOnBehalfOf = 17
+	CucmOnBehalfOfReplaces                   CucmOnBehalfOf = 18
+	CucmOnBehalfOfRedirection                CucmOnBehalfOf = 19
+	CucmOnBehalfOfCallback                   CucmOnBehalfOf = 20
+	CucmOnBehalfOfPathReplacement            CucmOnBehalfOf = 21
+	CucmOnBehalfOfFacCmcManager              CucmOnBehalfOf = 22
+	CucmOnBehalfOfMaliciousCall              CucmOnBehalfOf = 23
+	CucmOnBehalfOfMobility                   CucmOnBehalfOf = 24
+	CucmOnBehalfOfAAR                        CucmOnBehalfOf = 25
+	CucmOnBehalfOfDirectedCallPark           CucmOnBehalfOf = 26
+	CucmOnBehalfOfRecording                  CucmOnBehalfOf = 27
+	CucmOnBehalfOfMonitoring                 CucmOnBehalfOf = 28
+	CucmOnBehalfOfCCMonitoring               CucmOnBehalfOf = 29
+	CucmOnBehalfOfIME                        CucmOnBehalfOf = 30
+)
+
+var cucmOnBehalfOfNames = map[CucmOnBehalfOf]string{
+	CucmOnBehalfOfUnknown:                    "Unknown",
+	CucmOnBehalfOfCCTILine:                   "CCTI Line",
+	CucmOnBehalfOfUnicastSharedResource:      "Unicast Shared Resource Provider",
+	CucmOnBehalfOfCallPark:                   "Call Park",
+	CucmOnBehalfOfConference:                 "Conference",
+	CucmOnBehalfOfCallForward:                "Call Forward",
+	CucmOnBehalfOfMeetMeConference:           "Meet-Me Conference",
+	CucmOnBehalfOfMeetMeConferenceIntercepts: "Meet-Me Conference Intercepts",
+	CucmOnBehalfOfMessageWaiting:             "Message Waiting",
+	CucmOnBehalfOfMulticastSharedResource:    "Multicast Shared Resource Provider",
+	CucmOnBehalfOfTransfer:                   "Transfer",
+	CucmOnBehalfOfSSAPIManager:               "SSAPI Manager",
+	CucmOnBehalfOfDevice:                     "Device",
+	CucmOnBehalfOfCallControl:                "Call Control",
+	CucmOnBehalfOfImmediateDivert:            "Immediate Divert",
+	CucmOnBehalfOfBarge:                      "Barge",
+	CucmOnBehalfOfPickup:                     "Pickup",
+	CucmOnBehalfOfRefer:                      "Refer",
+	CucmOnBehalfOfReplaces:                   "Replaces",
+	CucmOnBehalfOfRedirection:                "Redirection",
+	CucmOnBehalfOfCallback:                   "Callback",
+	CucmOnBehalfOfPathReplacement:            "Path Replacement",
+	CucmOnBehalfOfFacCmcManager:              "FAC/CMC Manager",
+	CucmOnBehalfOfMaliciousCall:              "Malicious Call",
+	CucmOnBehalfOfMobility:                   "Mobility",
+	CucmOnBehalfOfAAR:                        "AAR",
+	CucmOnBehalfOfDirectedCallPark:           "Directed Call Park",
+	CucmOnBehalfOfRecording:                  "Recording",
+	CucmOnBehalfOfMonitoring:                 "Monitoring",
+	CucmOnBehalfOfCCMonitoring:               "CC Monitoring",
+	CucmOnBehalfOfIME:                        "Intercompany Media Engine",
+}
+
+func (o CucmOnBehalfOf) String() string {
+	if name, ok := cucmOnBehalfOfNames[o]; ok {
+		return name
+	}
+	return "CucmOnBehalfOf(" + strconv.FormatInt(int64(o), 10) + ")"
+}
+
+// CucmRoutingReason is the decision of an External Call Control server in
+// Currentroutingreason, Origroutingreason and Lastredirectingroutingreason.
+type CucmRoutingReason int64
+
+const (
+	CucmRoutingReasonUnknown CucmRoutingReason = 0
+	CucmRoutingReasonPermit  CucmRoutingReason = 1
+	CucmRoutingReasonDeny    CucmRoutingReason = 2
+	CucmRoutingReasonDivert  CucmRoutingReason = 3
+)
+
+var cucmRoutingReasonNames = map[CucmRoutingReason]string{
+	CucmRoutingReasonUnknown: "Unknown",
+	CucmRoutingReasonPermit:  "Permit",
+	CucmRoutingReasonDeny:    "Deny",
+	CucmRoutingReasonDivert:  "Divert",
+}
+
+func (r CucmRoutingReason) String() string {
+	if name, ok := cucmRoutingReasonNames[r]; ok {
+		return name
+	}
+	return "CucmRoutingReason(" + strconv.FormatInt(int64(r), 10) + ")"
+}
+
+// Rows of the lookup tables seeded by the migration, so the codes can be joined
+// on in SQL.
+type CucmRedirectReasonCode struct {
+	Code        int64 `gorm:"primaryKey;autoIncrement:false"`
+	Description string
+}
+
+type CucmOnBehalfOfCode struct {
+	Code        int64 `gorm:"primaryKey;autoIncrement:false"`
+	Description string
+}
+
+type CucmRoutingReasonCode struct {
+	Code        int64 `gorm:"primaryKey;autoIncrement:false"`
+	Description string
+}
+
+func CucmRedirectReasonCodes() []*CucmRedirectReasonCode {
+	rows := []*CucmRedirectReasonCode{}
+	for code, name := range cucmRedirectReasonNames {
+		rows = append(rows, &CucmRedirectReasonCode{Code: int64(code), Description: name})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Code < rows[j].Code })
+	return rows
+}
+
+func CucmOnBehalfOfCodes() []*CucmOnBehalfOfCode {
+	rows := []*CucmOnBehalfOfCode{}
+	for code, name := range cucmOnBehalfOfNames {
+		rows = append(rows, &CucmOnBehalfOfCode{Code: int64(code), Description: name})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Code < rows[j].Code })
+	return rows
+}
+
+func CucmRoutingReasonCodes() []*CucmRoutingReasonCode {
+	rows := []*CucmRoutingReasonCode{}
+	for code, name := range cucmRoutingReasonNames {
+		rows = append(rows, &CucmRoutingReasonCode{Code: int64(code), Description: name})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Code < rows[j].Code })
+	return rows
+}
+
+// DecodeCucmRedirectReason returns the description of a redirect reason, or nil
+// if the code is not known.
+func DecodeCucmRedirectReason(code *int64) *string {
+	if code == nil {
+		return nil
+	}
+	name, ok := cucmRedirectReasonNames[CucmRedirectReason(*code)]
+	if !ok {
+		return nil
+	}
+	return &name
+}
+
+// DecodeCucmOnBehalfOf returns the description of an on behalf of code, or nil
+// if the code is not known.
+func DecodeCucmOnBehalfOf(code *int64) *string {
+	if code == nil {
+		return nil
+	}
+	name, ok := cucmOnBehalfOfNames[CucmOnBehalfOf(*code)]
+	if !ok {
+		return nil
+	}
+	return &name
+}
+
+// DecodeCucmRoutingReason returns the description of a routing reason, or nil
+// if the code is not known.
+func DecodeCucmRoutingReason(code *int64) *string {
+	if code == nil {
+		return nil
+	}
+	name, ok := cucmRoutingReasonNames[CucmRoutingReason(*code)]
+	if !ok {
+		return nil
+	}
+	return &name
+}
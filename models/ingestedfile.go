@@ -0,0 +1,38 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+// Status of a file in the ingestion ledger.
+const (
+	IngestionStatusComplete  = "complete"
+	IngestionStatusFailed    = "failed"
+	IngestionStatusDuplicate = "duplicate"
+)
+
+// IngestedFile is a row in the ingestion ledger. One row is written for every
+// file the parser picks up, so the content hash can be used to recognise a
+// file that has already been loaded under a different name.
+type IngestedFile struct {
+	ID            string
+	Filename      *string
+	Sha256        *string `gorm:"index"`
+	RecordCount   *int64
+	RejectedCount *int64
+	DirectoryType *string
+	StartTime     *int64
+	EndTime       *int64
+	Status        *string
+}
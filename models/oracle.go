@@ -1,104 +1,846 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
 package models
 
-type OracleCDR struct {
-	Accountingstatus *string
-	Nasipaddress *string
-	Nasport *string
-	Accountingsessionid *string
-	Ingresssessionid *string
-	Egresssessionid *string
-	Sessionprotocoltype *string
-	Callingstationid *string
-	Calledstationid *string
-	Accountingterminationcause *string
-	Accountingsessiontime *string
-	Ciscosetuptime *string
-	Ciscoconnecttime *string
-	Ciscodisconnecttime *string
-	Ciscodisconnectcause *string
-	Egressnetworkinterfaceid *string
-	Egressvlantagvalue *string
-	Ingressnetworkinterfaceid *string
-	Ingressvlantagvalue *string
-	Egressrealm *string
-	Ingressrealm *string
-	Flowidentifier *string
-	Flowtype *string
-	Flowinputrealm *string
-	Flowinputsrcaddr *string
-	Flowinputsrcport *string
-	Flowinputdestaddress *string
-	Flowinputdestport *string
-	Flowoutputrealm *string
-	Flowoutputsrcaddress *string
-	Flowoutputsrcport *string
-	Flowoutputdestaddr *string
-	Flowoutputdestport *string
-	Rtcpcallingpacketslost *string
-	Rtcpcallingavgjitter *string
-	Rtcpcallingavglatency *string
-	Rtcpcallingmaxjitter *string
-	Rtcpcallingmaxlatency *string
-	Rtpcallingpacketslost *string
-	Rtpcallingavgjitter *string
-	Rtpcallingmaxjitter *string
-	Rtpcallingoctets *string
-	Rtpcallingpackets *string
-	Callingrfactor *string
-	Callingmos *string
-	Flowidentifier2 *string
-	Flowtype2 *string
-	Flowinputrealm2 *string
-	Flowinputsrcaddr2 *string
-	Flowinputsrcport2 *string
-	Flowinputdestaddress2 *string
-	Flowinputdestport2 *string
-	Flowoutputrealm2 *string
-	Flowoutputsrcaddress2 *string
-	Flowoutputsrcport2 *string
-	Flowoutputdestaddr2 *string
-	Flowoutputdestport2 *string
-	Rtcpcalledpacketslost *string
-	Rtcpcalledavgjitter *string
-	Rtcpcalledavglatency *string
-	Rtcpcalledmaxjitter *string
-	Rtcpcalledmaxlatency *string
-	Rtpcalledpacketslost *string
-	Rtpcalledavgjitter *string
-	Rtpcalledmaxjitter *string
-	Rtpcalledoctets *string
-	Rtpcalledpackets *string
-	Calledrfactor *string
-	Calledmos *string
-	Firmwareversion *string
-	Localtimezone *string
-	Postdialdelay *string
-	Primaryroutingnumber *string
-	Ingresslocaladdress *string
-	Ingressremoteaddress *string
-	Egresslocaladdress *string
-	Egressremoteaddress *string
-	Sessiondisposition *string
-	Disconnectinitiator *string
-	Disconnectcause *string
-	Sipstatuscode *string
-	Egressroutingnumber *string
-	Callingmediastoptime *string
-	Calledmediastoptime *string
-	Flowmediatype *string
-	Flowmediatype2 *string
-	Rtpcallingoctetstransmitted *string
-	Rtpcallingpacketstransmitted *string
-	Rtpcalledoctetstransmitted *string
-	Rtpcalledpacketstransmitted *string
-	Msrpcalledoctets *string
-	Msrpcalledpackets *string
-	Msrpcalledoctetstransmitted *string
-	Msrpcalledpacketstransmitted *string
-	Msrpcallingoctets *string
-	Msrpcallingpackets *string
-	Msrpcallingoctetstransmitted *string
+import (
+	"github.com/google/uuid"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+)
+
+// OracleStopRecordFieldCount is the number of fields in an Acme Packet
+// Stop record. Start and Interim records carry fewer fields.
+const OracleStopRecordFieldCount = 100
+
+type RawOracleCDR struct {
+	Accountingstatus              *string
+	Nasipaddress                  *string
+	Nasport                       *string
+	Accountingsessionid           *string
+	Ingresssessionid              *string
+	Egresssessionid               *string
+	Sessionprotocoltype           *string
+	Callingstationid              *string
+	Calledstationid               *string
+	Accountingterminationcause    *string
+	Accountingsessiontime         *string
+	Ciscosetuptime                *string
+	Ciscoconnecttime              *string
+	Ciscodisconnecttime           *string
+	Ciscodisconnectcause          *string
+	Egressnetworkinterfaceid      *string
+	Egressvlantagvalue            *string
+	Ingressnetworkinterfaceid     *string
+	Ingressvlantagvalue           *string
+	Egressrealm                   *string
+	Ingressrealm                  *string
+	Flowidentifier                *string
+	Flowtype                      *string
+	Flowinputrealm                *string
+	Flowinputsrcaddr              *string
+	Flowinputsrcport              *string
+	Flowinputdestaddress          *string
+	Flowinputdestport             *string
+	Flowoutputrealm               *string
+	Flowoutputsrcaddress          *string
+	Flowoutputsrcport             *string
+	Flowoutputdestaddr            *string
+	Flowoutputdestport            *string
+	Rtcpcallingpacketslost        *string
+	Rtcpcallingavgjitter          *string
+	Rtcpcallingavglatency         *string
+	Rtcpcallingmaxjitter          *string
+	Rtcpcallingmaxlatency         *string
+	Rtpcallingpacketslost         *string
+	Rtpcallingavgjitter           *string
+	Rtpcallingmaxjitter           *string
+	Rtpcallingoctets              *string
+	Rtpcallingpackets             *string
+	Callingrfactor                *string
+	Callingmos                    *string
+	Flowidentifier2               *string
+	Flowtype2                     *string
+	Flowinputrealm2               *string
+	Flowinputsrcaddr2             *string
+	Flowinputsrcport2             *string
+	Flowinputdestaddress2         *string
+	Flowinputdestport2            *string
+	Flowoutputrealm2              *string
+	Flowoutputsrcaddress2         *string
+	Flowoutputsrcport2            *string
+	Flowoutputdestaddr2           *string
+	Flowoutputdestport2           *string
+	Rtcpcalledpacketslost         *string
+	Rtcpcalledavgjitter           *string
+	Rtcpcalledavglatency          *string
+	Rtcpcalledmaxjitter           *string
+	Rtcpcalledmaxlatency          *string
+	Rtpcalledpacketslost          *string
+	Rtpcalledavgjitter            *string
+	Rtpcalledmaxjitter            *string
+	Rtpcalledoctets               *string
+	Rtpcalledpackets              *string
+	Calledrfactor                 *string
+	Calledmos                     *string
+	Firmwareversion               *string
+	Localtimezone                 *string
+	Postdialdelay                 *string
+	Primaryroutingnumber          *string
+	Ingresslocaladdress           *string
+	Ingressremoteaddress          *string
+	Egresslocaladdress            *string
+	Egressremoteaddress           *string
+	Sessiondisposition            *string
+	Disconnectinitiator           *string
+	Disconnectcause               *string
+	Sipstatuscode                 *string
+	Egressroutingnumber           *string
+	Callingmediastoptime          *string
+	Calledmediastoptime           *string
+	Flowmediatype                 *string
+	Flowmediatype2                *string
+	Rtpcallingoctetstransmitted   *string
+	Rtpcallingpacketstransmitted  *string
+	Rtpcalledoctetstransmitted    *string
+	Rtpcalledpacketstransmitted   *string
+	Msrpcalledoctets              *string
+	Msrpcalledpackets             *string
+	Msrpcalledoctetstransmitted   *string
+	Msrpcalledpacketstransmitted  *string
+	Msrpcallingoctets             *string
+	Msrpcallingpackets            *string
+	Msrpcallingoctetstransmitted  *string
 	Msrpcallingpacketstransmitted *string
-	Nodefunctionality *string
-	Cdrsequencenumber *string
+	Nodefunctionality             *string
+	Cdrsequencenumber             *string
+	Filename                      *string
+}
+
+type OracleCDR struct {
+	ID                            string
+	InvalidNTPReference           bool
+	Filename                      *string
+	Accountingstatus              *int64
+	Nasipaddress                  *string `gorm:"size:255;not null;uniqueIndex:oracle_cdr_index"`
+	Nasport                       *int64
+	Accountingsessionid           *string `gorm:"size:255;not null;uniqueIndex:oracle_cdr_index"`
+	Ingresssessionid              *string
+	Egresssessionid               *string
+	Sessionprotocoltype           *string
+	Callingstationid              *string
+	Calledstationid               *string
+	Accountingterminationcause    *int64
+	Accountingsessiontime         *int64
+	Ciscosetuptime                *int64
+	Ciscoconnecttime              *int64
+	Ciscodisconnecttime           *int64
+	Ciscodisconnectcause          *string
+	Egressnetworkinterfaceid      *string
+	Egressvlantagvalue            *int64
+	Ingressnetworkinterfaceid     *string
+	Ingressvlantagvalue           *int64
+	Egressrealm                   *string
+	Ingressrealm                  *string
+	Flowidentifier                *string
+	Flowtype                      *string
+	Flowinputrealm                *string
+	Flowinputsrcaddr              *string
+	Flowinputsrcport              *int64
+	Flowinputdestaddress          *string
+	Flowinputdestport             *int64
+	Flowoutputrealm               *string
+	Flowoutputsrcaddress          *string
+	Flowoutputsrcport             *int64
+	Flowoutputdestaddr            *string
+	Flowoutputdestport            *int64
+	Rtcpcallingpacketslost        *int64
+	Rtcpcallingavgjitter          *int64
+	Rtcpcallingavglatency         *int64
+	Rtcpcallingmaxjitter          *int64
+	Rtcpcallingmaxlatency         *int64
+	Rtpcallingpacketslost         *int64
+	Rtpcallingavgjitter           *int64
+	Rtpcallingmaxjitter           *int64
+	Rtpcallingoctets              *int64
+	Rtpcallingpackets             *int64
+	Callingrfactor                *int64 // R-factor and MOS are reported by the SBC multiplied by 100
+	Callingmos                    *int64
+	Flowidentifier2               *string
+	Flowtype2                     *string
+	Flowinputrealm2               *string
+	Flowinputsrcaddr2             *string
+	Flowinputsrcport2             *int64
+	Flowinputdestaddress2         *string
+	Flowinputdestport2            *int64
+	Flowoutputrealm2              *string
+	Flowoutputsrcaddress2         *string
+	Flowoutputsrcport2            *int64
+	Flowoutputdestaddr2           *string
+	Flowoutputdestport2           *int64
+	Rtcpcalledpacketslost         *int64
+	Rtcpcalledavgjitter           *int64
+	Rtcpcalledavglatency          *int64
+	Rtcpcalledmaxjitter           *int64
+	Rtcpcalledmaxlatency          *int64
+	Rtpcalledpacketslost          *int64
+	Rtpcalledavgjitter            *int64
+	Rtpcalledmaxjitter            *int64
+	Rtpcalledoctets               *int64
+	Rtpcalledpackets              *int64
+	Calledrfactor                 *int64
+	Calledmos                     *int64
+	Firmwareversion               *string
+	Localtimezone                 *string
+	Postdialdelay                 *int64
+	Primaryroutingnumber          *string
+	Ingresslocaladdress           *string
+	Ingressremoteaddress          *string
+	Egresslocaladdress            *string
+	Egressremoteaddress           *string
+	Sessiondisposition            *int64
+	Disconnectinitiator           *int64
+	Disconnectcause               *int64
+	Sipstatuscode                 *int64
+	Egressroutingnumber           *string
+	Callingmediastoptime          *int64
+	Calledmediastoptime           *int64
+	Flowmediatype                 *string
+	Flowmediatype2                *string
+	Rtpcallingoctetstransmitted   *int64
+	Rtpcallingpacketstransmitted  *int64
+	Rtpcalledoctetstransmitted    *int64
+	Rtpcalledpacketstransmitted   *int64
+	Msrpcalledoctets              *int64
+	Msrpcalledpackets             *int64
+	Msrpcalledoctetstransmitted   *int64
+	Msrpcalledpacketstransmitted  *int64
+	Msrpcallingoctets             *int64
+	Msrpcallingpackets            *int64
+	Msrpcallingoctetstransmitted  *int64
+	Msrpcallingpacketstransmitted *int64
+	Nodefunctionality             *string
+	Cdrsequencenumber             *int64
+}
+
+func (raw *RawOracleCDR) Parse(filename string) (*OracleCDR, error) {
+
+	var ParsedAccountingstatus *int64
+	var ParsedNasipaddress *string
+	var ParsedNasport *int64
+	var ParsedAccountingsessionid *string
+	var ParsedIngresssessionid *string
+	var ParsedEgresssessionid *string
+	var ParsedSessionprotocoltype *string
+	var ParsedCallingstationid *string
+	var ParsedCalledstationid *string
+	var ParsedAccountingterminationcause *int64
+	var ParsedAccountingsessiontime *int64
+	var ParsedCiscosetuptime *int64
+	var ParsedCiscoconnecttime *int64
+	var ParsedCiscodisconnecttime *int64
+	var ParsedCiscodisconnectcause *string
+	var ParsedEgressnetworkinterfaceid *string
+	var ParsedEgressvlantagvalue *int64
+	var ParsedIngressnetworkinterfaceid *string
+	var ParsedIngressvlantagvalue *int64
+	var ParsedEgressrealm *string
+	var ParsedIngressrealm *string
+	var ParsedFlowidentifier *string
+	var ParsedFlowtype *string
+	var ParsedFlowinputrealm *string
+	var ParsedFlowinputsrcaddr *string
+	var ParsedFlowinputsrcport *int64
+	var ParsedFlowinputdestaddress *string
+	var ParsedFlowinputdestport *int64
+	var ParsedFlowoutputrealm *string
+	var ParsedFlowoutputsrcaddress *string
+	var ParsedFlowoutputsrcport *int64
+	var ParsedFlowoutputdestaddr *string
+	var ParsedFlowoutputdestport *int64
+	var ParsedRtcpcallingpacketslost *int64
+	var ParsedRtcpcallingavgjitter *int64
+	var ParsedRtcpcallingavglatency *int64
+	var ParsedRtcpcallingmaxjitter *int64
+	var ParsedRtcpcallingmaxlatency *int64
+	var ParsedRtpcallingpacketslost *int64
+	var ParsedRtpcallingavgjitter *int64
+	var ParsedRtpcallingmaxjitter *int64
+	var ParsedRtpcallingoctets *int64
+	var ParsedRtpcallingpackets *int64
+	var ParsedCallingrfactor *int64
+	var ParsedCallingmos *int64
+	var ParsedFlowidentifier2 *string
+	var ParsedFlowtype2 *string
+	var ParsedFlowinputrealm2 *string
+	var ParsedFlowinputsrcaddr2 *string
+	var ParsedFlowinputsrcport2 *int64
+	var ParsedFlowinputdestaddress2 *string
+	var ParsedFlowinputdestport2 *int64
+	var ParsedFlowoutputrealm2 *string
+	var ParsedFlowoutputsrcaddress2 *string
+	var ParsedFlowoutputsrcport2 *int64
+	var ParsedFlowoutputdestaddr2 *string
+	var ParsedFlowoutputdestport2 *int64
+	var ParsedRtcpcalledpacketslost *int64
+	var ParsedRtcpcalledavgjitter *int64
+	var ParsedRtcpcalledavglatency *int64
+	var ParsedRtcpcalledmaxjitter *int64
+	var ParsedRtcpcalledmaxlatency *int64
+	var ParsedRtpcalledpacketslost *int64
+	var ParsedRtpcalledavgjitter *int64
+	var ParsedRtpcalledmaxjitter *int64
+	var ParsedRtpcalledoctets *int64
+	var ParsedRtpcalledpackets *int64
+	var ParsedCalledrfactor *int64
+	var ParsedCalledmos *int64
+	var ParsedFirmwareversion *string
+	var ParsedLocaltimezone *string
+	var ParsedPostdialdelay *int64
+	var ParsedPrimaryroutingnumber *string
+	var ParsedIngresslocaladdress *string
+	var ParsedIngressremoteaddress *string
+	var ParsedEgresslocaladdress *string
+	var ParsedEgressremoteaddress *string
+	var ParsedSessiondisposition *int64
+	var ParsedDisconnectinitiator *int64
+	var ParsedDisconnectcause *int64
+	var ParsedSipstatuscode *int64
+	var ParsedEgressroutingnumber *string
+	var ParsedCallingmediastoptime *int64
+	var ParsedCalledmediastoptime *int64
+	var ParsedFlowmediatype *string
+	var ParsedFlowmediatype2 *string
+	var ParsedRtpcallingoctetstransmitted *int64
+	var ParsedRtpcallingpacketstransmitted *int64
+	var ParsedRtpcalledoctetstransmitted *int64
+	var ParsedRtpcalledpacketstransmitted *int64
+	var ParsedMsrpcalledoctets *int64
+	var ParsedMsrpcalledpackets *int64
+	var ParsedMsrpcalledoctetstransmitted *int64
+	var ParsedMsrpcalledpacketstransmitted *int64
+	var ParsedMsrpcallingoctets *int64
+	var ParsedMsrpcallingpackets *int64
+	var ParsedMsrpcallingoctetstransmitted *int64
+	var ParsedMsrpcallingpacketstransmitted *int64
+	var ParsedNodefunctionality *string
+	var ParsedCdrsequencenumber *int64
+	var ParsedFilename *string
+
+	ParsedAccountingsessionid = helpers.RemoveSpaceFromString(raw.Accountingsessionid)
+	ParsedIngresssessionid = helpers.RemoveSpaceFromString(raw.Ingresssessionid)
+	ParsedEgresssessionid = helpers.RemoveSpaceFromString(raw.Egresssessionid)
+	ParsedSessionprotocoltype = helpers.RemoveSpaceFromString(raw.Sessionprotocoltype)
+	ParsedCallingstationid = helpers.RemoveSpaceFromString(raw.Callingstationid)
+	ParsedCalledstationid = helpers.RemoveSpaceFromString(raw.Calledstationid)
+	ParsedCiscodisconnectcause = helpers.RemoveSpaceFromString(raw.Ciscodisconnectcause)
+	ParsedEgressnetworkinterfaceid = helpers.RemoveSpaceFromString(raw.Egressnetworkinterfaceid)
+	ParsedIngressnetworkinterfaceid = helpers.RemoveSpaceFromString(raw.Ingressnetworkinterfaceid)
+	ParsedEgressrealm = helpers.RemoveSpaceFromString(raw.Egressrealm)
+	ParsedIngressrealm = helpers.RemoveSpaceFromString(raw.Ingressrealm)
+	ParsedFlowidentifier = helpers.RemoveSpaceFromString(raw.Flowidentifier)
+	ParsedFlowtype = helpers.RemoveSpaceFromString(raw.Flowtype)
+	ParsedFlowinputrealm = helpers.RemoveSpaceFromString(raw.Flowinputrealm)
+	ParsedFlowoutputrealm = helpers.RemoveSpaceFromString(raw.Flowoutputrealm)
+	ParsedFlowidentifier2 = helpers.RemoveSpaceFromString(raw.Flowidentifier2)
+	ParsedFlowtype2 = helpers.RemoveSpaceFromString(raw.Flowtype2)
+	ParsedFlowinputrealm2 = helpers.RemoveSpaceFromString(raw.Flowinputrealm2)
+	ParsedFlowoutputrealm2 = helpers.RemoveSpaceFromString(raw.Flowoutputrealm2)
+	ParsedFirmwareversion = helpers.RemoveSpaceFromString(raw.Firmwareversion)
+	ParsedLocaltimezone = helpers.RemoveSpaceFromString(raw.Localtimezone)
+	ParsedPrimaryroutingnumber = helpers.RemoveSpaceFromString(raw.Primaryroutingnumber)
+	ParsedIngresslocaladdress = helpers.RemoveSpaceFromString(raw.Ingresslocaladdress)
+	ParsedIngressremoteaddress = helpers.RemoveSpaceFromString(raw.Ingressremoteaddress)
+	ParsedEgresslocaladdress = helpers.RemoveSpaceFromString(raw.Egresslocaladdress)
+	ParsedEgressremoteaddress = helpers.RemoveSpaceFromString(raw.Egressremoteaddress)
+	ParsedEgressroutingnumber = helpers.RemoveSpaceFromString(raw.Egressroutingnumber)
+	ParsedFlowmediatype = helpers.RemoveSpaceFromString(raw.Flowmediatype)
+	ParsedFlowmediatype2 = helpers.RemoveSpaceFromString(raw.Flowmediatype2)
+	ParsedNodefunctionality = helpers.RemoveSpaceFromString(raw.Nodefunctionality)
+	ParsedFilename = helpers.RemoveSpaceFromString(raw.Filename)
+
+	InvalidNTPReference := false
+
+	ParsedAccountingstatus, err := helpers.ConvertStringToInt64(raw.Accountingstatus)
+	if err != nil {
+		return nil, newConversionError("Accountingstatus", raw.Accountingstatus, err)
+	}
+
+	ParsedNasport, err = helpers.ConvertStringToInt64(raw.Nasport)
+	if err != nil {
+		return nil, newConversionError("Nasport", raw.Nasport, err)
+	}
+
+	ParsedAccountingterminationcause, err = helpers.ConvertStringToInt64(raw.Accountingterminationcause)
+	if err != nil {
+		return nil, newConversionError("Accountingterminationcause", raw.Accountingterminationcause, err)
+	}
+
+	ParsedAccountingsessiontime, err = helpers.ConvertStringToInt64(raw.Accountingsessiontime)
+	if err != nil {
+		return nil, newConversionError("Accountingsessiontime", raw.Accountingsessiontime, err)
+	}
+
+	ParsedEgressvlantagvalue, err = helpers.ConvertStringToInt64(raw.Egressvlantagvalue)
+	if err != nil {
+		return nil, newConversionError("Egressvlantagvalue", raw.Egressvlantagvalue, err)
+	}
+
+	ParsedIngressvlantagvalue, err = helpers.ConvertStringToInt64(raw.Ingressvlantagvalue)
+	if err != nil {
+		return nil, newConversionError("Ingressvlantagvalue", raw.Ingressvlantagvalue, err)
+	}
+
+	ParsedFlowinputsrcport, err = helpers.ConvertStringToInt64(raw.Flowinputsrcport)
+	if err != nil {
+		return nil, newConversionError("Flowinputsrcport", raw.Flowinputsrcport, err)
+	}
+
+	ParsedFlowinputdestport, err = helpers.ConvertStringToInt64(raw.Flowinputdestport)
+	if err != nil {
+		return nil, newConversionError("Flowinputdestport", raw.Flowinputdestport, err)
+	}
+
+	ParsedFlowoutputsrcport, err = helpers.ConvertStringToInt64(raw.Flowoutputsrcport)
+	if err != nil {
+		return nil, newConversionError("Flowoutputsrcport", raw.Flowoutputsrcport, err)
+	}
+
+	ParsedFlowoutputdestport, err = helpers.ConvertStringToInt64(raw.Flowoutputdestport)
+	if err != nil {
+		return nil, newConversionError("Flowoutputdestport", raw.Flowoutputdestport, err)
+	}
+
+	ParsedRtcpcallingpacketslost, err = helpers.ConvertStringToInt64(raw.Rtcpcallingpacketslost)
+	if err != nil {
+		return nil, newConversionError("Rtcpcallingpacketslost", raw.Rtcpcallingpacketslost, err)
+	}
+
+	ParsedRtcpcallingavgjitter, err = helpers.ConvertStringToInt64(raw.Rtcpcallingavgjitter)
+	if err != nil {
+		return nil, newConversionError("Rtcpcallingavgjitter", raw.Rtcpcallingavgjitter, err)
+	}
+
+	ParsedRtcpcallingavglatency, err = helpers.ConvertStringToInt64(raw.Rtcpcallingavglatency)
+	if err != nil {
+		return nil, newConversionError("Rtcpcallingavglatency", raw.Rtcpcallingavglatency, err)
+	}
+
+	ParsedRtcpcallingmaxjitter, err = helpers.ConvertStringToInt64(raw.Rtcpcallingmaxjitter)
+	if err != nil {
+		return nil, newConversionError("Rtcpcallingmaxjitter", raw.Rtcpcallingmaxjitter, err)
+	}
+
+	ParsedRtcpcallingmaxlatency, err = helpers.ConvertStringToInt64(raw.Rtcpcallingmaxlatency)
+	if err != nil {
+		return nil, newConversionError("Rtcpcallingmaxlatency", raw.Rtcpcallingmaxlatency, err)
+	}
+
+	ParsedRtpcallingpacketslost, err = helpers.ConvertStringToInt64(raw.Rtpcallingpacketslost)
+	if err != nil {
+		return nil, newConversionError("Rtpcallingpacketslost", raw.Rtpcallingpacketslost, err)
+	}
+
+	ParsedRtpcallingavgjitter, err = helpers.ConvertStringToInt64(raw.Rtpcallingavgjitter)
+	if err != nil {
+		return nil, newConversionError("Rtpcallingavgjitter", raw.Rtpcallingavgjitter, err)
+	}
+
+	ParsedRtpcallingmaxjitter, err = helpers.ConvertStringToInt64(raw.Rtpcallingmaxjitter)
+	if err != nil {
+		return nil, newConversionError("Rtpcallingmaxjitter", raw.Rtpcallingmaxjitter, err)
+	}
+
+	ParsedRtpcallingoctets, err = helpers.ConvertStringToInt64(raw.Rtpcallingoctets)
+	if err != nil {
+		return nil, newConversionError("Rtpcallingoctets", raw.Rtpcallingoctets, err)
+	}
+
+	ParsedRtpcallingpackets, err = helpers.ConvertStringToInt64(raw.Rtpcallingpackets)
+	if err != nil {
+		return nil, newConversionError("Rtpcallingpackets", raw.Rtpcallingpackets, err)
+	}
+
+	ParsedCallingrfactor, err = helpers.ConvertStringToInt64(raw.Callingrfactor)
+	if err != nil {
+		return nil, newConversionError("Callingrfactor", raw.Callingrfactor, err)
+	}
+
+	ParsedCallingmos, err = helpers.ConvertStringToInt64(raw.Callingmos)
+	if err != nil {
+		return nil, newConversionError("Callingmos", raw.Callingmos, err)
+	}
+
+	ParsedFlowinputsrcport2, err = helpers.ConvertStringToInt64(raw.Flowinputsrcport2)
+	if err != nil {
+		return nil, newConversionError("Flowinputsrcport2", raw.Flowinputsrcport2, err)
+	}
+
+	ParsedFlowinputdestport2, err = helpers.ConvertStringToInt64(raw.Flowinputdestport2)
+	if err != nil {
+		return nil, newConversionError("Flowinputdestport2", raw.Flowinputdestport2, err)
+	}
+
+	ParsedFlowoutputsrcport2, err = helpers.ConvertStringToInt64(raw.Flowoutputsrcport2)
+	if err != nil {
+		return nil, newConversionError("Flowoutputsrcport2", raw.Flowoutputsrcport2, err)
+	}
+
+	ParsedFlowoutputdestport2, err = helpers.ConvertStringToInt64(raw.Flowoutputdestport2)
+	if err != nil {
+		return nil, newConversionError("Flowoutputdestport2", raw.Flowoutputdestport2, err)
+	}
+
+	ParsedRtcpcalledpacketslost, err = helpers.ConvertStringToInt64(raw.Rtcpcalledpacketslost)
+	if err != nil {
+		return nil, newConversionError("Rtcpcalledpacketslost", raw.Rtcpcalledpacketslost, err)
+	}
+
+	ParsedRtcpcalledavgjitter, err = helpers.ConvertStringToInt64(raw.Rtcpcalledavgjitter)
+	if err != nil {
+		return nil, newConversionError("Rtcpcalledavgjitter", raw.Rtcpcalledavgjitter, err)
+	}
+
+	ParsedRtcpcalledavglatency, err = helpers.ConvertStringToInt64(raw.Rtcpcalledavglatency)
+	if err != nil {
+		return nil, newConversionError("Rtcpcalledavglatency", raw.Rtcpcalledavglatency, err)
+	}
+
+	ParsedRtcpcalledmaxjitter, err = helpers.ConvertStringToInt64(raw.Rtcpcalledmaxjitter)
+	if err != nil {
+		return nil, newConversionError("Rtcpcalledmaxjitter", raw.Rtcpcalledmaxjitter, err)
+	}
+
+	ParsedRtcpcalledmaxlatency, err = helpers.ConvertStringToInt64(raw.Rtcpcalledmaxlatency)
+	if err != nil {
+		return nil, newConversionError("Rtcpcalledmaxlatency", raw.Rtcpcalledmaxlatency, err)
+	}
+
+	ParsedRtpcalledpacketslost, err = helpers.ConvertStringToInt64(raw.Rtpcalledpacketslost)
+	if err != nil {
+		return nil, newConversionError("Rtpcalledpacketslost", raw.Rtpcalledpacketslost, err)
+	}
+
+	ParsedRtpcalledavgjitter, err = helpers.ConvertStringToInt64(raw.Rtpcalledavgjitter)
+	if err != nil {
+		return nil, newConversionError("Rtpcalledavgjitter", raw.Rtpcalledavgjitter, err)
+	}
+
+	ParsedRtpcalledmaxjitter, err = helpers.ConvertStringToInt64(raw.Rtpcalledmaxjitter)
+	if err != nil {
+		return nil, newConversionError("Rtpcalledmaxjitter", raw.Rtpcalledmaxjitter, err)
+	}
+
+	ParsedRtpcalledoctets, err = helpers.ConvertStringToInt64(raw.Rtpcalledoctets)
+	if err != nil {
+		return nil, newConversionError("Rtpcalledoctets", raw.Rtpcalledoctets, err)
+	}
+
+	ParsedRtpcalledpackets, err = helpers.ConvertStringToInt64(raw.Rtpcalledpackets)
+	if err != nil {
+		return nil, newConversionError("Rtpcalledpackets", raw.Rtpcalledpackets, err)
+	}
+
+	ParsedCalledrfactor, err = helpers.ConvertStringToInt64(raw.Calledrfactor)
+	if err != nil {
+		return nil, newConversionError("Calledrfactor", raw.Calledrfactor, err)
+	}
+
+	ParsedCalledmos, err = helpers.ConvertStringToInt64(raw.Calledmos)
+	if err != nil {
+		return nil, newConversionError("Calledmos", raw.Calledmos, err)
+	}
+
+	ParsedPostdialdelay, err = helpers.ConvertStringToInt64(raw.Postdialdelay)
+	if err != nil {
+		return nil, newConversionError("Postdialdelay", raw.Postdialdelay, err)
+	}
+
+	ParsedSessiondisposition, err = helpers.ConvertStringToInt64(raw.Sessiondisposition)
+	if err != nil {
+		return nil, newConversionError("Sessiondisposition", raw.Sessiondisposition, err)
+	}
+
+	ParsedDisconnectinitiator, err = helpers.ConvertStringToInt64(raw.Disconnectinitiator)
+	if err != nil {
+		return nil, newConversionError("Disconnectinitiator", raw.Disconnectinitiator, err)
+	}
+
+	ParsedDisconnectcause, err = helpers.ConvertStringToInt64(raw.Disconnectcause)
+	if err != nil {
+		return nil, newConversionError("Disconnectcause", raw.Disconnectcause, err)
+	}
+
+	ParsedSipstatuscode, err = helpers.ConvertStringToInt64(raw.Sipstatuscode)
+	if err != nil {
+		return nil, newConversionError("Sipstatuscode", raw.Sipstatuscode, err)
+	}
+
+	ParsedRtpcallingoctetstransmitted, err = helpers.ConvertStringToInt64(raw.Rtpcallingoctetstransmitted)
+	if err != nil {
+		return nil, newConversionError("Rtpcallingoctetstransmitted", raw.Rtpcallingoctetstransmitted, err)
+	}
+
+	ParsedRtpcallingpacketstransmitted, err = helpers.ConvertStringToInt64(raw.Rtpcallingpacketstransmitted)
+	if err != nil {
+		return nil, newConversionError("Rtpcallingpacketstransmitted", raw.Rtpcallingpacketstransmitted, err)
+	}
+
+	ParsedRtpcalledoctetstransmitted, err = helpers.ConvertStringToInt64(raw.Rtpcalledoctetstransmitted)
+	if err != nil {
+		return nil, newConversionError("Rtpcalledoctetstransmitted", raw.Rtpcalledoctetstransmitted, err)
+	}
+
+	ParsedRtpcalledpacketstransmitted, err = helpers.ConvertStringToInt64(raw.Rtpcalledpacketstransmitted)
+	if err != nil {
+		return nil, newConversionError("Rtpcalledpacketstransmitted", raw.Rtpcalledpacketstransmitted, err)
+	}
+
+	ParsedMsrpcalledoctets, err = helpers.ConvertStringToInt64(raw.Msrpcalledoctets)
+	if err != nil {
+		return nil, newConversionError("Msrpcalledoctets", raw.Msrpcalledoctets, err)
+	}
+
+	ParsedMsrpcalledpackets, err = helpers.ConvertStringToInt64(raw.Msrpcalledpackets)
+	if err != nil {
+		return nil, newConversionError("Msrpcalledpackets", raw.Msrpcalledpackets, err)
+	}
+
+	ParsedMsrpcalledoctetstransmitted, err = helpers.ConvertStringToInt64(raw.Msrpcalledoctetstransmitted)
+	if err != nil {
+		return nil, newConversionError("Msrpcalledoctetstransmitted", raw.Msrpcalledoctetstransmitted, err)
+	}
+
+	ParsedMsrpcalledpacketstransmitted, err = helpers.ConvertStringToInt64(raw.Msrpcalledpacketstransmitted)
+	if err != nil {
+		return nil, newConversionError("Msrpcalledpacketstransmitted", raw.Msrpcalledpacketstransmitted, err)
+	}
+
+	ParsedMsrpcallingoctets, err = helpers.ConvertStringToInt64(raw.Msrpcallingoctets)
+	if err != nil {
+		return nil, newConversionError("Msrpcallingoctets", raw.Msrpcallingoctets, err)
+	}
+
+	ParsedMsrpcallingpackets, err = helpers.ConvertStringToInt64(raw.Msrpcallingpackets)
+	if err != nil {
+		return nil, newConversionError("Msrpcallingpackets", raw.Msrpcallingpackets, err)
+	}
+
+	ParsedMsrpcallingoctetstransmitted, err = helpers.ConvertStringToInt64(raw.Msrpcallingoctetstransmitted)
+	if err != nil {
+		return nil, newConversionError("Msrpcallingoctetstransmitted", raw.Msrpcallingoctetstransmitted, err)
+	}
+
+	ParsedMsrpcallingpacketstransmitted, err = helpers.ConvertStringToInt64(raw.Msrpcallingpacketstransmitted)
+	if err != nil {
+		return nil, newConversionError("Msrpcallingpacketstransmitted", raw.Msrpcallingpacketstransmitted, err)
+	}
+
+	ParsedCdrsequencenumber, err = helpers.ConvertStringToInt64(raw.Cdrsequencenumber)
+	if err != nil {
+		return nil, newConversionError("Cdrsequencenumber", raw.Cdrsequencenumber, err)
+	}
+
+	ParsedNasipaddress, err = helpers.ConvertStringToIP(raw.Nasipaddress)
+	if err != nil {
+		return nil, newConversionError("Nasipaddress", raw.Nasipaddress, err)
+	}
+
+	ParsedFlowinputsrcaddr, err = helpers.ConvertStringToIP(raw.Flowinputsrcaddr)
+	if err != nil {
+		return nil, newConversionError("Flowinputsrcaddr", raw.Flowinputsrcaddr, err)
+	}
+
+	ParsedFlowinputdestaddress, err = helpers.ConvertStringToIP(raw.Flowinputdestaddress)
+	if err != nil {
+		return nil, newConversionError("Flowinputdestaddress", raw.Flowinputdestaddress, err)
+	}
+
+	ParsedFlowoutputsrcaddress, err = helpers.ConvertStringToIP(raw.Flowoutputsrcaddress)
+	if err != nil {
+		return nil, newConversionError("Flowoutputsrcaddress", raw.Flowoutputsrcaddress, err)
+	}
+
+	ParsedFlowoutputdestaddr, err = helpers.ConvertStringToIP(raw.Flowoutputdestaddr)
+	if err != nil {
+		return nil, newConversionError("Flowoutputdestaddr", raw.Flowoutputdestaddr, err)
+	}
+
+	ParsedFlowinputsrcaddr2, err = helpers.ConvertStringToIP(raw.Flowinputsrcaddr2)
+	if err != nil {
+		return nil, newConversionError("Flowinputsrcaddr2", raw.Flowinputsrcaddr2, err)
+	}
+
+	ParsedFlowinputdestaddress2, err = helpers.ConvertStringToIP(raw.Flowinputdestaddress2)
+	if err != nil {
+		return nil, newConversionError("Flowinputdestaddress2", raw.Flowinputdestaddress2, err)
+	}
+
+	ParsedFlowoutputsrcaddress2, err = helpers.ConvertStringToIP(raw.Flowoutputsrcaddress2)
+	if err != nil {
+		return nil, newConversionError("Flowoutputsrcaddress2", raw.Flowoutputsrcaddress2, err)
+	}
+
+	ParsedFlowoutputdestaddr2, err = helpers.ConvertStringToIP(raw.Flowoutputdestaddr2)
+	if err != nil {
+		return nil, newConversionError("Flowoutputdestaddr2", raw.Flowoutputdestaddr2, err)
+	}
+
+	ParsedCiscosetuptime, err = helpers.ConvertStringToUnixTime(raw.Ciscosetuptime, nil)
+	if isInvalidNTPReference(err) {
+		logger.Error("Error parsing Ciscosetuptime: %s in %s", err, filename)
+		InvalidNTPReference = true
+	} else if err != nil {
+		return nil, newConversionError("Ciscosetuptime", raw.Ciscosetuptime, err)
+	}
+
+	ParsedCiscoconnecttime, err = helpers.ConvertStringToUnixTime(raw.Ciscoconnecttime, nil)
+	if isInvalidNTPReference(err) {
+		logger.Error("Error parsing Ciscoconnecttime: %s in %s", err, filename)
+		InvalidNTPReference = true
+	} else if err != nil {
+		return nil, newConversionError("Ciscoconnecttime", raw.Ciscoconnecttime, err)
+	}
+
+	ParsedCiscodisconnecttime, err = helpers.ConvertStringToUnixTime(raw.Ciscodisconnecttime, nil)
+	if isInvalidNTPReference(err) {
+		logger.Error("Error parsing Ciscodisconnecttime: %s in %s", err, filename)
+		InvalidNTPReference = true
+	} else if err != nil {
+		return nil, newConversionError("Ciscodisconnecttime", raw.Ciscodisconnecttime, err)
+	}
+
+	ParsedCallingmediastoptime, err = helpers.ConvertStringToUnixTime(raw.Callingmediastoptime, nil)
+	if isInvalidNTPReference(err) {
+		logger.Error("Error parsing Callingmediastoptime: %s in %s", err, filename)
+		InvalidNTPReference = true
+	} else if err != nil {
+		return nil, newConversionError("Callingmediastoptime", raw.Callingmediastoptime, err)
+	}
+
+	ParsedCalledmediastoptime, err = helpers.ConvertStringToUnixTime(raw.Calledmediastoptime, nil)
+	if isInvalidNTPReference(err) {
+		logger.Error("Error parsing Calledmediastoptime: %s in %s", err, filename)
+		InvalidNTPReference = true
+	} else if err != nil {
+		return nil, newConversionError("Calledmediastoptime", raw.Calledmediastoptime, err)
+	}
+
+	return &OracleCDR{
+		ID:                            uuid.New().String(),
+		InvalidNTPReference:           InvalidNTPReference,
+		Filename:                      ParsedFilename,
+		Accountingstatus:              ParsedAccountingstatus,
+		Nasipaddress:                  ParsedNasipaddress,
+		Nasport:                       ParsedNasport,
+		Accountingsessionid:           ParsedAccountingsessionid,
+		Ingresssessionid:              ParsedIngresssessionid,
+		Egresssessionid:               ParsedEgresssessionid,
+		Sessionprotocoltype:           ParsedSessionprotocoltype,
+		Callingstationid:              ParsedCallingstationid,
+		Calledstationid:               ParsedCalledstationid,
+		Accountingterminationcause:    ParsedAccountingterminationcause,
+		Accountingsessiontime:         ParsedAccountingsessiontime,
+		Ciscosetuptime:                ParsedCiscosetuptime,
+		Ciscoconnecttime:              ParsedCiscoconnecttime,
+		Ciscodisconnecttime:           ParsedCiscodisconnecttime,
+		Ciscodisconnectcause:          ParsedCiscodisconnectcause,
+		Egressnetworkinterfaceid:      ParsedEgressnetworkinterfaceid,
+		Egressvlantagvalue:            ParsedEgressvlantagvalue,
+		Ingressnetworkinterfaceid:     ParsedIngressnetworkinterfaceid,
+		Ingressvlantagvalue:           ParsedIngressvlantagvalue,
+		Egressrealm:                   ParsedEgressrealm,
+		Ingressrealm:                  ParsedIngressrealm,
+		Flowidentifier:                ParsedFlowidentifier,
+		Flowtype:                      ParsedFlowtype,
+		Flowinputrealm:                ParsedFlowinputrealm,
+		Flowinputsrcaddr:              ParsedFlowinputsrcaddr,
+		Flowinputsrcport:              ParsedFlowinputsrcport,
+		Flowinputdestaddress:          ParsedFlowinputdestaddress,
+		Flowinputdestport:             ParsedFlowinputdestport,
+		Flowoutputrealm:               ParsedFlowoutputrealm,
+		Flowoutputsrcaddress:          ParsedFlowoutputsrcaddress,
+		Flowoutputsrcport:             ParsedFlowoutputsrcport,
+		Flowoutputdestaddr:            ParsedFlowoutputdestaddr,
+		Flowoutputdestport:            ParsedFlowoutputdestport,
+		Rtcpcallingpacketslost:        ParsedRtcpcallingpacketslost,
+		Rtcpcallingavgjitter:          ParsedRtcpcallingavgjitter,
+		Rtcpcallingavglatency:         ParsedRtcpcallingavglatency,
+		Rtcpcallingmaxjitter:          ParsedRtcpcallingmaxjitter,
+		Rtcpcallingmaxlatency:         ParsedRtcpcallingmaxlatency,
+		Rtpcallingpacketslost:         ParsedRtpcallingpacketslost,
+		Rtpcallingavgjitter:           ParsedRtpcallingavgjitter,
+		Rtpcallingmaxjitter:           ParsedRtpcallingmaxjitter,
+		Rtpcallingoctets:              ParsedRtpcallingoctets,
+		Rtpcallingpackets:             ParsedRtpcallingpackets,
+		Callingrfactor:                ParsedCallingrfactor,
+		Callingmos:                    ParsedCallingmos,
+		Flowidentifier2:               ParsedFlowidentifier2,
+		Flowtype2:                     ParsedFlowtype2,
+		Flowinputrealm2:               ParsedFlowinputrealm2,
+		Flowinputsrcaddr2:             ParsedFlowinputsrcaddr2,
+		Flowinputsrcport2:             ParsedFlowinputsrcport2,
+		Flowinputdestaddress2:         ParsedFlowinputdestaddress2,
+		Flowinputdestport2:            ParsedFlowinputdestport2,
+		Flowoutputrealm2:              ParsedFlowoutputrealm2,
+		Flowoutputsrcaddress2:         ParsedFlowoutputsrcaddress2,
+		Flowoutputsrcport2:            ParsedFlowoutputsrcport2,
+		Flowoutputdestaddr2:           ParsedFlowoutputdestaddr2,
+		Flowoutputdestport2:           ParsedFlowoutputdestport2,
+		Rtcpcalledpacketslost:         ParsedRtcpcalledpacketslost,
+		Rtcpcalledavgjitter:           ParsedRtcpcalledavgjitter,
+		Rtcpcalledavglatency:          ParsedRtcpcalledavglatency,
+		Rtcpcalledmaxjitter:           ParsedRtcpcalledmaxjitter,
+		Rtcpcalledmaxlatency:          ParsedRtcpcalledmaxlatency,
+		Rtpcalledpacketslost:          ParsedRtpcalledpacketslost,
+		Rtpcalledavgjitter:            ParsedRtpcalledavgjitter,
+		Rtpcalledmaxjitter:            ParsedRtpcalledmaxjitter,
+		Rtpcalledoctets:               ParsedRtpcalledoctets,
+		Rtpcalledpackets:              ParsedRtpcalledpackets,
+		Calledrfactor:                 ParsedCalledrfactor,
+		Calledmos:                     ParsedCalledmos,
+		Firmwareversion:               ParsedFirmwareversion,
+		Localtimezone:                 ParsedLocaltimezone,
+		Postdialdelay:                 ParsedPostdialdelay,
+		Primaryroutingnumber:          ParsedPrimaryroutingnumber,
+		Ingresslocaladdress:           ParsedIngresslocaladdress,
+		Ingressremoteaddress:          ParsedIngressremoteaddress,
+		Egresslocaladdress:            ParsedEgresslocaladdress,
+		Egressremoteaddress:           ParsedEgressremoteaddress,
+		Sessiondisposition:            ParsedSessiondisposition,
+		Disconnectinitiator:           ParsedDisconnectinitiator,
+		Disconnectcause:               ParsedDisconnectcause,
+		Sipstatuscode:                 ParsedSipstatuscode,
+		Egressroutingnumber:           ParsedEgressroutingnumber,
+		Callingmediastoptime:          ParsedCallingmediastoptime,
+		Calledmediastoptime:           ParsedCalledmediastoptime,
+		Flowmediatype:                 ParsedFlowmediatype,
+		Flowmediatype2:                ParsedFlowmediatype2,
+		Rtpcallingoctetstransmitted:   ParsedRtpcallingoctetstransmitted,
+		Rtpcallingpacketstransmitted:  ParsedRtpcallingpacketstransmitted,
+		Rtpcalledoctetstransmitted:    ParsedRtpcalledoctetstransmitted,
+		Rtpcalledpacketstransmitted:   ParsedRtpcalledpacketstransmitted,
+		Msrpcalledoctets:              ParsedMsrpcalledoctets,
+		Msrpcalledpackets:             ParsedMsrpcalledpackets,
+		Msrpcalledoctetstransmitted:   ParsedMsrpcalledoctetstransmitted,
+		Msrpcalledpacketstransmitted:  ParsedMsrpcalledpacketstransmitted,
+		Msrpcallingoctets:             ParsedMsrpcallingoctets,
+		Msrpcallingpackets:            ParsedMsrpcallingpackets,
+		Msrpcallingoctetstransmitted:  ParsedMsrpcallingoctetstransmitted,
+		Msrpcallingpacketstransmitted: ParsedMsrpcallingpacketstransmitted,
+		Nodefunctionality:             ParsedNodefunctionality,
+		Cdrsequencenumber:             ParsedCdrsequencenumber,
+	}, nil
 }
@@ -17,8 +17,8 @@ package models
 
 import (
 	"github.com/google/uuid"
+	"github.com/ziondials/go-cdr/dialplan"
 	"github.com/ziondials/go-cdr/helpers"
-	"github.com/ziondials/go-cdr/logger"
 )
 
 type RawCucmCdr struct {
@@ -161,7 +161,9 @@ type RawCucmCdr struct {
 	Destdevicesessionid                     *string
 }
 
-func (raw *RawCucmCdr) Parse(filename string) (*CucmCdr, error) {
+// Parse converts the CDR. Numbers are normalized and the call is classified
+// with plan, and left empty if plan is nil.
+func (raw *RawCucmCdr) Parse(filename string, plan *dialplan.Plan) (*CucmCdr, error) {
 
 	var ParsedCdrrecordtype *int64
 	var ParsedGlobalcallid_Callmanagerid *int64
@@ -298,303 +300,321 @@ func (raw *RawCucmCdr) Parse(filename string) (*CucmCdr, error) {
 
 	ParsedCdrrecordtype, err := helpers.ConvertStringToInt64(raw.Cdrrecordtype)
 	if err != nil {
-		logger.Error("Error parsing Cdrrecordtype: %s in %s", err, filename)
+		return nil, newConversionError("Cdrrecordtype", raw.Cdrrecordtype, err)
 	}
 	ParsedGlobalcallid_Callmanagerid, err = helpers.ConvertStringToInt64(raw.Globalcallid_Callmanagerid)
 	if err != nil {
-		logger.Error("Error parsing Globalcallid_Callmanagerid: %s in %s", err, filename)
+		return nil, newConversionError("Globalcallid_Callmanagerid", raw.Globalcallid_Callmanagerid, err)
 	}
 	ParsedGlobalcallid_Callid, err = helpers.ConvertStringToInt64(raw.Globalcallid_Callid)
 	if err != nil {
-		logger.Error("Error parsing Globalcallid_Callid: %s in %s", err, filename)
+		return nil, newConversionError("Globalcallid_Callid", raw.Globalcallid_Callid, err)
 	}
 	ParsedOriglegcallidentifier, err = helpers.ConvertStringToInt64(raw.Origlegcallidentifier)
 	if err != nil {
-		logger.Error("Error parsing Origlegcallidentifier: %s in %s", err, filename)
+		return nil, newConversionError("Origlegcallidentifier", raw.Origlegcallidentifier, err)
 	}
 	ParsedDatetimeorigination, err = helpers.ConvertStringToInt64(raw.Datetimeorigination)
 	if err != nil {
-		logger.Error("Error parsing Datetimeorigination: %s in %s", err, filename)
+		return nil, newConversionError("Datetimeorigination", raw.Datetimeorigination, err)
 	}
 	ParsedOrignodeid, err = helpers.ConvertStringToInt64(raw.Orignodeid)
 	if err != nil {
-		logger.Error("Error parsing Orignodeid: %s in %s", err, filename)
+		return nil, newConversionError("Orignodeid", raw.Orignodeid, err)
 	}
 	ParsedOrigspan, err = helpers.ConvertStringToInt64(raw.Origspan)
 	if err != nil {
-		logger.Error("Error parsing Origspan: %s in %s", err, filename)
+		return nil, newConversionError("Origspan", raw.Origspan, err)
 	}
 	ParsedOrigcause_Location, err = helpers.ConvertStringToInt64(raw.Origcause_Location)
 	if err != nil {
-		logger.Error("Error parsing Origcause_Location: %s in %s", err, filename)
+		return nil, newConversionError("Origcause_Location", raw.Origcause_Location, err)
 	}
 	ParsedOrigcause_Value, err = helpers.ConvertStringToInt64(raw.Origcause_Value)
 	if err != nil {
-		logger.Error("Error parsing Origcause_Value: %s in %s", err, filename)
+		return nil, newConversionError("Origcause_Value", raw.Origcause_Value, err)
 	}
+	ParsedOrigcause_Description, ParsedOrigcause_Category := DecodeQ850Cause(ParsedOrigcause_Value)
 	ParsedOrigprecedencelevel, err = helpers.ConvertStringToInt64(raw.Origprecedencelevel)
 	if err != nil {
-		logger.Error("Error parsing Origprecedencelevel: %s in %s", err, filename)
+		return nil, newConversionError("Origprecedencelevel", raw.Origprecedencelevel, err)
 	}
 	ParsedOrigmediatransportaddress_Port, err = helpers.ConvertStringToInt64(raw.Origmediatransportaddress_Port)
 	if err != nil {
-		logger.Error("Error parsing Origmediatransportaddress_Port: %s in %s", err, filename)
+		return nil, newConversionError("Origmediatransportaddress_Port", raw.Origmediatransportaddress_Port, err)
 	}
 	ParsedOrigmediacap_Payloadcapability, err = helpers.ConvertStringToInt64(raw.Origmediacap_Payloadcapability)
 	if err != nil {
-		logger.Error("Error parsing Origmediacap_Payloadcapability: %s in %s", err, filename)
+		return nil, newConversionError("Origmediacap_Payloadcapability", raw.Origmediacap_Payloadcapability, err)
 	}
+	ParsedOrigmediacap_Payloadcapability_Name, ParsedOrigmediacap_Payloadcapability_Family := DecodeCucmCodec(ParsedOrigmediacap_Payloadcapability)
 	ParsedOrigmediacap_Maxframesperpacket, err = helpers.ConvertStringToInt64(raw.Origmediacap_Maxframesperpacket)
 	if err != nil {
-		logger.Error("Error parsing Origmediacap_Maxframesperpacket: %s in %s", err, filename)
+		return nil, newConversionError("Origmediacap_Maxframesperpacket", raw.Origmediacap_Maxframesperpacket, err)
 	}
 	ParsedOrigmediacap_G723bitrate, err = helpers.ConvertStringToInt64(raw.Origmediacap_G723bitrate)
 	if err != nil {
-		logger.Error("Error parsing Origmediacap_G723bitrate: %s in %s", err, filename)
+		return nil, newConversionError("Origmediacap_G723bitrate", raw.Origmediacap_G723bitrate, err)
 	}
 	ParsedOrigvideocap_Codec, err = helpers.ConvertStringToInt64(raw.Origvideocap_Codec)
 	if err != nil {
-		logger.Error("Error parsing Origvideocap_Codec: %s in %s", err, filename)
+		return nil, newConversionError("Origvideocap_Codec", raw.Origvideocap_Codec, err)
 	}
+	ParsedOrigvideocap_Codec_Name, ParsedOrigvideocap_Codec_Family := DecodeCucmCodec(ParsedOrigvideocap_Codec)
 	ParsedOrigvideocap_Bandwidth, err = helpers.ConvertStringToInt64(raw.Origvideocap_Bandwidth)
 	if err != nil {
-		logger.Error("Error parsing Origvideocap_Bandwidth: %s in %s", err, filename)
+		return nil, newConversionError("Origvideocap_Bandwidth", raw.Origvideocap_Bandwidth, err)
 	}
 	ParsedOrigvideocap_Resolution, err = helpers.ConvertStringToInt64(raw.Origvideocap_Resolution)
 	if err != nil {
-		logger.Error("Error parsing Origvideocap_Resolution: %s in %s", err, filename)
+		return nil, newConversionError("Origvideocap_Resolution", raw.Origvideocap_Resolution, err)
 	}
 	ParsedOrigvideotransportaddress_Port, err = helpers.ConvertStringToInt64(raw.Origvideotransportaddress_Port)
 	if err != nil {
-		logger.Error("Error parsing Origvideotransportaddress_Port: %s in %s", err, filename)
+		return nil, newConversionError("Origvideotransportaddress_Port", raw.Origvideotransportaddress_Port, err)
 	}
 	ParsedOrigrsvpaudiostat, err = helpers.ConvertStringToInt64(raw.Origrsvpaudiostat)
 	if err != nil {
-		logger.Error("Error parsing Origrsvpaudiostat: %s in %s", err, filename)
+		return nil, newConversionError("Origrsvpaudiostat", raw.Origrsvpaudiostat, err)
 	}
 	ParsedOrigrsvpvideostat, err = helpers.ConvertStringToInt64(raw.Origrsvpvideostat)
 	if err != nil {
-		logger.Error("Error parsing Origrsvpvideostat: %s in %s", err, filename)
+		return nil, newConversionError("Origrsvpvideostat", raw.Origrsvpvideostat, err)
 	}
 	ParsedDestlegcallidentifier, err = helpers.ConvertStringToInt64(raw.Destlegcallidentifier)
 	if err != nil {
-		logger.Error("Error parsing Destlegcallidentifier: %s in %s", err, filename)
+		return nil, newConversionError("Destlegcallidentifier", raw.Destlegcallidentifier, err)
 	}
 	ParsedDestnodeid, err = helpers.ConvertStringToInt64(raw.Destnodeid)
 	if err != nil {
-		logger.Error("Error parsing Destnodeid: %s in %s", err, filename)
+		return nil, newConversionError("Destnodeid", raw.Destnodeid, err)
 	}
 	ParsedDestspan, err = helpers.ConvertStringToInt64(raw.Destspan)
 	if err != nil {
-		logger.Error("Error parsing Destspan: %s in %s", err, filename)
+		return nil, newConversionError("Destspan", raw.Destspan, err)
 	}
 	ParsedDestcause_Location, err = helpers.ConvertStringToInt64(raw.Destcause_Location)
 	if err != nil {
-		logger.Error("Error parsing Destcause_Location: %s in %s", err, filename)
+		return nil, newConversionError("Destcause_Location", raw.Destcause_Location, err)
 	}
 	ParsedDestcause_Value, err = helpers.ConvertStringToInt64(raw.Destcause_Value)
 	if err != nil {
-		logger.Error("Error parsing Destcause_Value: %s in %s", err, filename)
+		return nil, newConversionError("Destcause_Value", raw.Destcause_Value, err)
 	}
+	ParsedDestcause_Description, ParsedDestcause_Category := DecodeQ850Cause(ParsedDestcause_Value)
 	ParsedDestprecedencelevel, err = helpers.ConvertStringToInt64(raw.Destprecedencelevel)
 	if err != nil {
-		logger.Error("Error parsing Destprecedencelevel: %s in %s", err, filename)
+		return nil, newConversionError("Destprecedencelevel", raw.Destprecedencelevel, err)
 	}
 	ParsedDestmediatransportaddress_Port, err = helpers.ConvertStringToInt64(raw.Destmediatransportaddress_Port)
 	if err != nil {
-		logger.Error("Error parsing Destmediatransportaddress_Port: %s in %s", err, filename)
+		return nil, newConversionError("Destmediatransportaddress_Port", raw.Destmediatransportaddress_Port, err)
 	}
 	ParsedDestmediacap_Payloadcapability, err = helpers.ConvertStringToInt64(raw.Destmediacap_Payloadcapability)
 	if err != nil {
-		logger.Error("Error parsing Destmediacap_Payloadcapability: %s in %s", err, filename)
+		return nil, newConversionError("Destmediacap_Payloadcapability", raw.Destmediacap_Payloadcapability, err)
 	}
+	ParsedDestmediacap_Payloadcapability_Name, ParsedDestmediacap_Payloadcapability_Family := DecodeCucmCodec(ParsedDestmediacap_Payloadcapability)
 	ParsedDestmediacap_Maxframesperpacket, err = helpers.ConvertStringToInt64(raw.Destmediacap_Maxframesperpacket)
 	if err != nil {
-		logger.Error("Error parsing Destmediacap_Maxframesperpacket: %s in %s", err, filename)
+		return nil, newConversionError("Destmediacap_Maxframesperpacket", raw.Destmediacap_Maxframesperpacket, err)
 	}
 	ParsedDestmediacap_G723bitrate, err = helpers.ConvertStringToInt64(raw.Destmediacap_G723bitrate)
 	if err != nil {
-		logger.Error("Error parsing Destmediacap_G723bitrate: %s in %s", err, filename)
+		return nil, newConversionError("Destmediacap_G723bitrate", raw.Destmediacap_G723bitrate, err)
 	}
 	ParsedDestvideocap_Codec, err = helpers.ConvertStringToInt64(raw.Destvideocap_Codec)
 	if err != nil {
-		logger.Error("Error parsing Destvideocap_Codec: %s in %s", err, filename)
+		return nil, newConversionError("Destvideocap_Codec", raw.Destvideocap_Codec, err)
 	}
+	ParsedDestvideocap_Codec_Name, ParsedDestvideocap_Codec_Family := DecodeCucmCodec(ParsedDestvideocap_Codec)
 	ParsedDestvideocap_Bandwidth, err = helpers.ConvertStringToInt64(raw.Destvideocap_Bandwidth)
 	if err != nil {
-		logger.Error("Error parsing Destvideocap_Bandwidth: %s in %s", err, filename)
+		return nil, newConversionError("Destvideocap_Bandwidth", raw.Destvideocap_Bandwidth, err)
 	}
 	ParsedDestvideocap_Resolution, err = helpers.ConvertStringToInt64(raw.Destvideocap_Resolution)
 	if err != nil {
-		logger.Error("Error parsing Destvideocap_Resolution: %s in %s", err, filename)
+		return nil, newConversionError("Destvideocap_Resolution", raw.Destvideocap_Resolution, err)
 	}
 	ParsedDestvideotransportaddress_Port, err = helpers.ConvertStringToInt64(raw.Destvideotransportaddress_Port)
 	if err != nil {
-		logger.Error("Error parsing Destvideotransportaddress_Port: %s in %s", err, filename)
+		return nil, newConversionError("Destvideotransportaddress_Port", raw.Destvideotransportaddress_Port, err)
 	}
 	ParsedDestrsvpaudiostat, err = helpers.ConvertStringToInt64(raw.Destrsvpaudiostat)
 	if err != nil {
-		logger.Error("Error parsing Destrsvpaudiostat: %s in %s", err, filename)
+		return nil, newConversionError("Destrsvpaudiostat", raw.Destrsvpaudiostat, err)
 	}
 	ParsedDestrsvpvideostat, err = helpers.ConvertStringToInt64(raw.Destrsvpvideostat)
 	if err != nil {
-		logger.Error("Error parsing Destrsvpvideostat: %s in %s", err, filename)
+		return nil, newConversionError("Destrsvpvideostat", raw.Destrsvpvideostat, err)
 	}
 	ParsedDatetimeconnect, err = helpers.ConvertStringToInt64(raw.Datetimeconnect)
 	if err != nil {
-		logger.Error("Error parsing Datetimeconnect: %s in %s", err, filename)
+		return nil, newConversionError("Datetimeconnect", raw.Datetimeconnect, err)
 	}
 	ParsedDatetimedisconnect, err = helpers.ConvertStringToInt64(raw.Datetimedisconnect)
 	if err != nil {
-		logger.Error("Error parsing Datetimedisconnect: %s in %s", err, filename)
+		return nil, newConversionError("Datetimedisconnect", raw.Datetimedisconnect, err)
 	}
 	ParsedDuration, err = helpers.ConvertStringToInt64(raw.Duration)
 	if err != nil {
-		logger.Error("Error parsing Duration: %s in %s", err, filename)
+		return nil, newConversionError("Duration", raw.Duration, err)
 	}
 	ParsedOrigcallterminationonbehalfof, err = helpers.ConvertStringToInt64(raw.Origcallterminationonbehalfof)
 	if err != nil {
-		logger.Error("Error parsing Origcallterminationonbehalfof: %s in %s", err, filename)
+		return nil, newConversionError("Origcallterminationonbehalfof", raw.Origcallterminationonbehalfof, err)
 	}
+	ParsedOrigcallterminationonbehalfof_Description := DecodeCucmOnBehalfOf(ParsedOrigcallterminationonbehalfof)
 	ParsedDestcallterminationonbehalfof, err = helpers.ConvertStringToInt64(raw.Destcallterminationonbehalfof)
 	if err != nil {
-		logger.Error("Error parsing Destcallterminationonbehalfof: %s in %s", err, filename)
+		return nil, newConversionError("Destcallterminationonbehalfof", raw.Destcallterminationonbehalfof, err)
 	}
+	ParsedDestcallterminationonbehalfof_Description := DecodeCucmOnBehalfOf(ParsedDestcallterminationonbehalfof)
 	ParsedOrigcalledpartyredirectonbehalfof, err = helpers.ConvertStringToInt64(raw.Origcalledpartyredirectonbehalfof)
 	if err != nil {
-		logger.Error("Error parsing Origcalledpartyredirectonbehalfof: %s in %s", err, filename)
+		return nil, newConversionError("Origcalledpartyredirectonbehalfof", raw.Origcalledpartyredirectonbehalfof, err)
 	}
+	ParsedOrigcalledpartyredirectonbehalfof_Description := DecodeCucmOnBehalfOf(ParsedOrigcalledpartyredirectonbehalfof)
 	ParsedLastredirectredirectonbehalfof, err = helpers.ConvertStringToInt64(raw.Lastredirectredirectonbehalfof)
 	if err != nil {
-		logger.Error("Error parsing Lastredirectredirectonbehalfof: %s in %s", err, filename)
+		return nil, newConversionError("Lastredirectredirectonbehalfof", raw.Lastredirectredirectonbehalfof, err)
 	}
+	ParsedLastredirectredirectonbehalfof_Description := DecodeCucmOnBehalfOf(ParsedLastredirectredirectonbehalfof)
 	ParsedOrigcalledpartyredirectreason, err = helpers.ConvertStringToInt64(raw.Origcalledpartyredirectreason)
 	if err != nil {
-		logger.Error("Error parsing Origcalledpartyredirectreason: %s in %s", err, filename)
+		return nil, newConversionError("Origcalledpartyredirectreason", raw.Origcalledpartyredirectreason, err)
 	}
+	ParsedOrigcalledpartyredirectreason_Description := DecodeCucmRedirectReason(ParsedOrigcalledpartyredirectreason)
 	ParsedLastredirectredirectreason, err = helpers.ConvertStringToInt64(raw.Lastredirectredirectreason)
 	if err != nil {
-		logger.Error("Error parsing Lastredirectredirectreason: %s in %s", err, filename)
+		return nil, newConversionError("Lastredirectredirectreason", raw.Lastredirectredirectreason, err)
 	}
+	ParsedLastredirectredirectreason_Description := DecodeCucmRedirectReason(ParsedLastredirectredirectreason)
 	ParsedDestconversationid, err = helpers.ConvertStringToInt64(raw.Destconversationid)
 	if err != nil {
-		logger.Error("Error parsing Destconversationid: %s in %s", err, filename)
+		return nil, newConversionError("Destconversationid", raw.Destconversationid, err)
 	}
 	ParsedJoinonbehalfof, err = helpers.ConvertStringToInt64(raw.Joinonbehalfof)
 	if err != nil {
-		logger.Error("Error parsing Joinonbehalfof: %s in %s", err, filename)
+		return nil, newConversionError("Joinonbehalfof", raw.Joinonbehalfof, err)
 	}
+	ParsedJoinonbehalfof_Description := DecodeCucmOnBehalfOf(ParsedJoinonbehalfof)
 	ParsedAuthorizationlevel, err = helpers.ConvertStringToInt64(raw.Authorizationlevel)
 	if err != nil {
-		logger.Error("Error parsing Authorizationlevel: %s in %s", err, filename)
+		return nil, newConversionError("Authorizationlevel", raw.Authorizationlevel, err)
 	}
 	ParsedOrigdtmfmethod, err = helpers.ConvertStringToInt64(raw.Origdtmfmethod)
 	if err != nil {
-		logger.Error("Error parsing Origdtmfmethod: %s in %s", err, filename)
+		return nil, newConversionError("Origdtmfmethod", raw.Origdtmfmethod, err)
 	}
 	ParsedDestdtmfmethod, err = helpers.ConvertStringToInt64(raw.Destdtmfmethod)
 	if err != nil {
-		logger.Error("Error parsing Destdtmfmethod: %s in %s", err, filename)
+		return nil, newConversionError("Destdtmfmethod", raw.Destdtmfmethod, err)
 	}
 	ParsedCallsecuredstatus, err = helpers.ConvertStringToInt64(raw.Callsecuredstatus)
 	if err != nil {
-		logger.Error("Error parsing Callsecuredstatus: %s in %s", err, filename)
+		return nil, newConversionError("Callsecuredstatus", raw.Callsecuredstatus, err)
 	}
 	ParsedOrigconversationid, err = helpers.ConvertStringToInt64(raw.Origconversationid)
 	if err != nil {
-		logger.Error("Error parsing Origconversationid: %s in %s", err, filename)
+		return nil, newConversionError("Origconversationid", raw.Origconversationid, err)
 	}
 	ParsedOrigmediacap_Bandwidth, err = helpers.ConvertStringToInt64(raw.Origmediacap_Bandwidth)
 	if err != nil {
-		logger.Error("Error parsing Origmediacap_Bandwidth: %s in %s", err, filename)
+		return nil, newConversionError("Origmediacap_Bandwidth", raw.Origmediacap_Bandwidth, err)
 	}
 	ParsedDestmediacap_Bandwidth, err = helpers.ConvertStringToInt64(raw.Destmediacap_Bandwidth)
 	if err != nil {
-		logger.Error("Error parsing Destmediacap_Bandwidth: %s in %s", err, filename)
+		return nil, newConversionError("Destmediacap_Bandwidth", raw.Destmediacap_Bandwidth, err)
 	}
 	ParsedOrigvideocap_Codec_Channel2, err = helpers.ConvertStringToInt64(raw.Origvideocap_Codec_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Origvideocap_Codec_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Origvideocap_Codec_Channel2", raw.Origvideocap_Codec_Channel2, err)
 	}
+	ParsedOrigvideocap_Codec_Channel2_Name, ParsedOrigvideocap_Codec_Channel2_Family := DecodeCucmCodec(ParsedOrigvideocap_Codec_Channel2)
 	ParsedOrigvideocap_Bandwidth_Channel2, err = helpers.ConvertStringToInt64(raw.Origvideocap_Bandwidth_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Origvideocap_Bandwidth_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Origvideocap_Bandwidth_Channel2", raw.Origvideocap_Bandwidth_Channel2, err)
 	}
 	ParsedOrigvideocap_Resolution_Channel2, err = helpers.ConvertStringToInt64(raw.Origvideocap_Resolution_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Origvideocap_Resolution_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Origvideocap_Resolution_Channel2", raw.Origvideocap_Resolution_Channel2, err)
 	}
 	ParsedOrigvideotransportaddress_Port_Channel2, err = helpers.ConvertStringToInt64(raw.Origvideotransportaddress_Port_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Origvideotransportaddress_Port_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Origvideotransportaddress_Port_Channel2", raw.Origvideotransportaddress_Port_Channel2, err)
 	}
 	ParsedOrigvideochannel_Role_Channel2, err = helpers.ConvertStringToInt64(raw.Origvideochannel_Role_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Origvideochannel_Role_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Origvideochannel_Role_Channel2", raw.Origvideochannel_Role_Channel2, err)
 	}
 	ParsedDestvideocap_Codec_Channel2, err = helpers.ConvertStringToInt64(raw.Destvideocap_Codec_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Destvideocap_Codec_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Destvideocap_Codec_Channel2", raw.Destvideocap_Codec_Channel2, err)
 	}
+	ParsedDestvideocap_Codec_Channel2_Name, ParsedDestvideocap_Codec_Channel2_Family := DecodeCucmCodec(ParsedDestvideocap_Codec_Channel2)
 	ParsedDestvideocap_Bandwidth_Channel2, err = helpers.ConvertStringToInt64(raw.Destvideocap_Bandwidth_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Destvideocap_Bandwidth_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Destvideocap_Bandwidth_Channel2", raw.Destvideocap_Bandwidth_Channel2, err)
 	}
 	ParsedDestvideocap_Resolution_Channel2, err = helpers.ConvertStringToInt64(raw.Destvideocap_Resolution_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Destvideocap_Resolution_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Destvideocap_Resolution_Channel2", raw.Destvideocap_Resolution_Channel2, err)
 	}
 	ParsedDestvideotransportaddress_Port_Channel2, err = helpers.ConvertStringToInt64(raw.Destvideotransportaddress_Port_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Destvideotransportaddress_Port_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Destvideotransportaddress_Port_Channel2", raw.Destvideotransportaddress_Port_Channel2, err)
 	}
 	ParsedDestvideochannel_Role_Channel2, err = helpers.ConvertStringToInt64(raw.Destvideochannel_Role_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Destvideochannel_Role_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Destvideochannel_Role_Channel2", raw.Destvideochannel_Role_Channel2, err)
 	}
 	ParsedIncomingprotocolid, err = helpers.ConvertStringToInt64(raw.Incomingprotocolid)
 	if err != nil {
-		logger.Error("Error parsing Incomingprotocolid: %s in %s", err, filename)
+		return nil, newConversionError("Incomingprotocolid", raw.Incomingprotocolid, err)
 	}
 	ParsedOutgoingprotocolid, err = helpers.ConvertStringToInt64(raw.Outgoingprotocolid)
 	if err != nil {
-		logger.Error("Error parsing Outgoingprotocolid: %s in %s", err, filename)
+		return nil, newConversionError("Outgoingprotocolid", raw.Outgoingprotocolid, err)
 	}
 	ParsedCurrentroutingreason, err = helpers.ConvertStringToInt64(raw.Currentroutingreason)
 	if err != nil {
-		logger.Error("Error parsing Currentroutingreason: %s in %s", err, filename)
+		return nil, newConversionError("Currentroutingreason", raw.Currentroutingreason, err)
 	}
+	ParsedCurrentroutingreason_Description := DecodeCucmRoutingReason(ParsedCurrentroutingreason)
 	ParsedOrigroutingreason, err = helpers.ConvertStringToInt64(raw.Origroutingreason)
 	if err != nil {
-		logger.Error("Error parsing Origroutingreason: %s in %s", err, filename)
+		return nil, newConversionError("Origroutingreason", raw.Origroutingreason, err)
 	}
+	ParsedOrigroutingreason_Description := DecodeCucmRoutingReason(ParsedOrigroutingreason)
 	ParsedLastredirectingroutingreason, err = helpers.ConvertStringToInt64(raw.Lastredirectingroutingreason)
 	if err != nil {
-		logger.Error("Error parsing Lastredirectingroutingreason: %s in %s", err, filename)
+		return nil, newConversionError("Lastredirectingroutingreason", raw.Lastredirectingroutingreason, err)
 	}
+	ParsedLastredirectingroutingreason_Description := DecodeCucmRoutingReason(ParsedLastredirectingroutingreason)
 	ParsedCalledpartypatternusage, err = helpers.ConvertStringToInt64(raw.Calledpartypatternusage)
 	if err != nil {
-		logger.Error("Error parsing Calledpartypatternusage: %s in %s", err, filename)
+		return nil, newConversionError("Calledpartypatternusage", raw.Calledpartypatternusage, err)
 	}
 	ParsedWascallqueued, err = helpers.ConvertStringToInt64(raw.Wascallqueued)
 	if err != nil {
-		logger.Error("Error parsing Wascallqueued: %s in %s", err, filename)
+		return nil, newConversionError("Wascallqueued", raw.Wascallqueued, err)
 	}
 	ParsedTotalwaittimeinqueue, err = helpers.ConvertStringToInt64(raw.Totalwaittimeinqueue)
 	if err != nil {
-		logger.Error("Error parsing Totalwaittimeinqueue: %s in %s", err, filename)
+		return nil, newConversionError("Totalwaittimeinqueue", raw.Totalwaittimeinqueue, err)
 	}
 	ParsedOrigmobilecallduration, err = helpers.ConvertStringToInt64(raw.Origmobilecallduration)
 	if err != nil {
-		logger.Error("Error parsing Origmobilecallduration: %s in %s", err, filename)
+		return nil, newConversionError("Origmobilecallduration", raw.Origmobilecallduration, err)
 	}
 	ParsedDestmobilecallduration, err = helpers.ConvertStringToInt64(raw.Destmobilecallduration)
 	if err != nil {
-		logger.Error("Error parsing Destmobilecallduration: %s in %s", err, filename)
+		return nil, newConversionError("Destmobilecallduration", raw.Destmobilecallduration, err)
 	}
 	ParsedMobilecalltype, err = helpers.ConvertStringToInt64(raw.Mobilecalltype)
 	if err != nil {
-		logger.Error("Error parsing Mobilecalltype: %s in %s", err, filename)
+		return nil, newConversionError("Mobilecalltype", raw.Mobilecalltype, err)
 	}
 
 	ParsedOriginpkid = helpers.RemoveSpaceFromString(raw.Pkid)
@@ -602,34 +622,34 @@ func (raw *RawCucmCdr) Parse(filename string) (*CucmCdr, error) {
 	ParsedFileNodeId = helpers.RemoveSpaceFromString(raw.FileNodeId)
 	ParsedOrigipaddr, err = helpers.ConvertStringToIPCisco(raw.Origipaddr)
 	if err != nil {
-		logger.Error("Error parsing Origipaddr: %s in %s", err, filename)
+		return nil, newConversionError("Origipaddr", raw.Origipaddr, err)
 	}
 
 	ParsedCallingpartynumber = helpers.RemoveSpaceFromString(raw.Callingpartynumber)
 	ParsedCallingpartyunicodeloginuserid = helpers.RemoveSpaceFromString(raw.Callingpartyunicodeloginuserid)
 	ParsedOrigmediatransportaddress_IP, err = helpers.ConvertStringToIPCisco(raw.Origmediatransportaddress_IP)
 	if err != nil {
-		logger.Error("Error parsing Origmediatransportaddress_IP: %s in %s", err, filename)
+		return nil, newConversionError("Origmediatransportaddress_IP", raw.Origmediatransportaddress_IP, err)
 	}
 	ParsedOrigvideotransportaddress_IP, err = helpers.ConvertStringToIPCisco(raw.Origvideotransportaddress_IP)
 	if err != nil {
-		logger.Error("Error parsing Origvideotransportaddress_IP: %s in %s", err, filename)
+		return nil, newConversionError("Origvideotransportaddress_IP", raw.Origvideotransportaddress_IP, err)
 	}
 
 	ParsedDestipaddr, err = helpers.ConvertStringToIPCisco(raw.Destipaddr)
 	if err != nil {
-		logger.Error("Error parsing Destipaddr: %s in %s", err, filename)
+		return nil, newConversionError("Destipaddr", raw.Destipaddr, err)
 	}
 	ParsedOriginalcalledpartynumber = helpers.RemoveSpaceFromString(raw.Originalcalledpartynumber)
 	ParsedFinalcalledpartynumber = helpers.RemoveSpaceFromString(raw.Finalcalledpartynumber)
 	ParsedFinalcalledpartyunicodeloginuserid = helpers.RemoveSpaceFromString(raw.Finalcalledpartyunicodeloginuserid)
 	ParsedDestmediatransportaddress_IP, err = helpers.ConvertStringToIPCisco(raw.Destmediatransportaddress_IP)
 	if err != nil {
-		logger.Error("Error parsing Destmediatransportaddress_IP: %s in %s", err, filename)
+		return nil, newConversionError("Destmediatransportaddress_IP", raw.Destmediatransportaddress_IP, err)
 	}
 	ParsedDestvideotransportaddress_IP, err = helpers.ConvertStringToIPCisco(raw.Destvideotransportaddress_IP)
 	if err != nil {
-		logger.Error("Error parsing Destvideotransportaddress_IP: %s in %s", err, filename)
+		return nil, newConversionError("Destvideotransportaddress_IP", raw.Destvideotransportaddress_IP, err)
 	}
 	ParsedLastredirectdn = helpers.RemoveSpaceFromString(raw.Lastredirectdn)
 	ParsedOriginalcalledpartynumberpartition = helpers.RemoveSpaceFromString(raw.Originalcalledpartynumberpartition)
@@ -649,11 +669,11 @@ func (raw *RawCucmCdr) Parse(filename string) (*CucmCdr, error) {
 	ParsedDestipv4v6addr = helpers.RemoveSpaceFromString(raw.Destipv4v6addr)
 	ParsedOrigvideotransportaddress_IP_Channel2, err = helpers.ConvertStringToIPCisco(raw.Origvideotransportaddress_IP_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Origvideotransportaddress_IP_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Origvideotransportaddress_IP_Channel2", raw.Origvideotransportaddress_IP_Channel2, err)
 	}
 	ParsedDestvideotransportaddress_IP_Channel2, err = helpers.ConvertStringToIPCisco(raw.Destvideotransportaddress_IP_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Destvideotransportaddress_IP_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Destvideotransportaddress_IP_Channel2", raw.Destvideotransportaddress_IP_Channel2, err)
 	}
 	ParsedIncomingprotocolcallref = helpers.RemoveSpaceFromString(raw.Incomingprotocolcallref)
 	ParsedOutgoingprotocolcallref = helpers.RemoveSpaceFromString(raw.Outgoingprotocolcallref)
@@ -681,6 +701,11 @@ func (raw *RawCucmCdr) Parse(filename string) (*CucmCdr, error) {
 	ParsedOrigdevicesessionid = helpers.RemoveSpaceFromString(raw.Origdevicesessionid)
 	ParsedDestdevicesessionid = helpers.RemoveSpaceFromString(raw.Destdevicesessionid)
 
+	ParsedCallingpartynumber_E164 := plan.Normalize(ParsedCallingpartynumber)
+	ParsedOriginalcalledpartynumber_E164 := plan.Normalize(ParsedOriginalcalledpartynumber)
+	ParsedFinalcalledpartynumber_E164 := plan.Normalize(ParsedFinalcalledpartynumber)
+	ParsedCallDirection := plan.Classify(ParsedCallingpartynumber, ParsedFinalcalledpartynumber)
+
 	return &CucmCdr{
 		ID:                                      uuid.New().String(),
 		OriginPkid:                              ParsedOriginpkid,
@@ -700,6 +725,8 @@ func (raw *RawCucmCdr) Parse(filename string) (*CucmCdr, error) {
 		Callingpartyunicodeloginuserid:          ParsedCallingpartyunicodeloginuserid,
 		Origcause_Location:                      ParsedOrigcause_Location,
 		Origcause_Value:                         ParsedOrigcause_Value,
+		Origcause_Description:                   ParsedOrigcause_Description,
+		Origcause_Category:                      ParsedOrigcause_Category,
 		Origprecedencelevel:                     ParsedOrigprecedencelevel,
 		Origmediatransportaddress_IP:            ParsedOrigmediatransportaddress_IP,
 		Origmediatransportaddress_Port:          ParsedOrigmediatransportaddress_Port,
@@ -722,6 +749,8 @@ func (raw *RawCucmCdr) Parse(filename string) (*CucmCdr, error) {
 		Finalcalledpartyunicodeloginuserid:      ParsedFinalcalledpartyunicodeloginuserid,
 		Destcause_Location:                      ParsedDestcause_Location,
 		Destcause_Value:                         ParsedDestcause_Value,
+		Destcause_Description:                   ParsedDestcause_Description,
+		Destcause_Category:                      ParsedDestcause_Category,
 		Destprecedencelevel:                     ParsedDestprecedencelevel,
 		Destmediatransportaddress_IP:            ParsedDestmediatransportaddress_IP,
 		Destmediatransportaddress_Port:          ParsedDestmediatransportaddress_Port,
@@ -820,5 +849,34 @@ func (raw *RawCucmCdr) Parse(filename string) (*CucmCdr, error) {
 		Destdevicetype:                          ParsedDestdevicetype,
 		Origdevicesessionid:                     ParsedOrigdevicesessionid,
 		Destdevicesessionid:                     ParsedDestdevicesessionid,
+
+		Origcallterminationonbehalfof_Description:     ParsedOrigcallterminationonbehalfof_Description,
+		Destcallterminationonbehalfof_Description:     ParsedDestcallterminationonbehalfof_Description,
+		Origcalledpartyredirectonbehalfof_Description: ParsedOrigcalledpartyredirectonbehalfof_Description,
+		Lastredirectredirectonbehalfof_Description:    ParsedLastredirectredirectonbehalfof_Description,
+		Origcalledpartyredirectreason_Description:     ParsedOrigcalledpartyredirectreason_Description,
+		Lastredirectredirectreason_Description:        ParsedLastredirectredirectreason_Description,
+		Joinonbehalfof_Description:                    ParsedJoinonbehalfof_Description,
+		Currentroutingreason_Description:              ParsedCurrentroutingreason_Description,
+		Origroutingreason_Description:                 ParsedOrigroutingreason_Description,
+		Lastredirectingroutingreason_Description:      ParsedLastredirectingroutingreason_Description,
+
+		Origmediacap_Payloadcapability_Name:   ParsedOrigmediacap_Payloadcapability_Name,
+		Origmediacap_Payloadcapability_Family: ParsedOrigmediacap_Payloadcapability_Family,
+		Destmediacap_Payloadcapability_Name:   ParsedDestmediacap_Payloadcapability_Name,
+		Destmediacap_Payloadcapability_Family: ParsedDestmediacap_Payloadcapability_Family,
+		Origvideocap_Codec_Name:               ParsedOrigvideocap_Codec_Name,
+		Origvideocap_Codec_Family:             ParsedOrigvideocap_Codec_Family,
+		Destvideocap_Codec_Name:               ParsedDestvideocap_Codec_Name,
+		Destvideocap_Codec_Family:             ParsedDestvideocap_Codec_Family,
+		Origvideocap_Codec_Channel2_Name:      ParsedOrigvideocap_Codec_Channel2_Name,
+		Origvideocap_Codec_Channel2_Family:    ParsedOrigvideocap_Codec_Channel2_Family,
+		Destvideocap_Codec_Channel2_Name:      ParsedDestvideocap_Codec_Channel2_Name,
+		Destvideocap_Codec_Channel2_Family:    ParsedDestvideocap_Codec_Channel2_Family,
+
+		Callingpartynumber_E164:        ParsedCallingpartynumber_E164,
+		Originalcalledpartynumber_E164: ParsedOriginalcalledpartynumber_E164,
+		Finalcalledpartynumber_E164:    ParsedFinalcalledpartynumber_E164,
+		CallDirection:                  ParsedCallDirection,
 	}, nil
 }
@@ -18,7 +18,6 @@ package models
 import (
 	"github.com/google/uuid"
 	"github.com/ziondials/go-cdr/helpers"
-	"github.com/ziondials/go-cdr/logger"
 )
 
 type RawCucmCmr struct {
@@ -149,131 +148,131 @@ func (raw *RawCucmCmr) Parse(filename string) (*CucmCmr, error) {
 
 	ParsedCdrrecordtype, err := helpers.ConvertStringToInt64(raw.Cdrrecordtype)
 	if err != nil {
-		logger.Error("Error parsing Cdrrecordtype: %s in %s", err, filename)
+		return nil, newConversionError("Cdrrecordtype", raw.Cdrrecordtype, err)
 	}
 	ParsedGlobalcallid_Callmanagerid, err = helpers.ConvertStringToInt64(raw.Globalcallid_Callmanagerid)
 	if err != nil {
-		logger.Error("Error parsing Globalcallid_Callmanagerid: %s in %s", err, filename)
+		return nil, newConversionError("Globalcallid_Callmanagerid", raw.Globalcallid_Callmanagerid, err)
 	}
 	ParsedGlobalcallid_Callid, err = helpers.ConvertStringToInt64(raw.Globalcallid_Callid)
 	if err != nil {
-		logger.Error("Error parsing Globalcallid_Callid: %s in %s", err, filename)
+		return nil, newConversionError("Globalcallid_Callid", raw.Globalcallid_Callid, err)
 	}
 	ParsedNodeid, err = helpers.ConvertStringToInt64(raw.Nodeid)
 	if err != nil {
-		logger.Error("Error parsing Nodeid: %s in %s", err, filename)
+		return nil, newConversionError("Nodeid", raw.Nodeid, err)
 	}
 	ParsedCallidentifier, err = helpers.ConvertStringToInt64(raw.Callidentifier)
 	if err != nil {
-		logger.Error("Error parsing Callidentifier: %s in %s", err, filename)
+		return nil, newConversionError("Callidentifier", raw.Callidentifier, err)
 	}
 	ParsedDatetimestamp, err = helpers.ConvertStringToInt64(raw.Datetimestamp)
 	if err != nil {
-		logger.Error("Error parsing Datetimestamp: %s in %s", err, filename)
+		return nil, newConversionError("Datetimestamp", raw.Datetimestamp, err)
 	}
 	ParsedNumberpacketssent, err = helpers.ConvertStringToInt64(raw.Numberpacketssent)
 	if err != nil {
-		logger.Error("Error parsing Numberpacketssent: %s in %s", err, filename)
+		return nil, newConversionError("Numberpacketssent", raw.Numberpacketssent, err)
 	}
 	ParsedNumberoctetssent, err = helpers.ConvertStringToInt64(raw.Numberoctetssent)
 	if err != nil {
-		logger.Error("Error parsing Numberoctetssent: %s in %s", err, filename)
+		return nil, newConversionError("Numberoctetssent", raw.Numberoctetssent, err)
 	}
 	ParsedNumberpacketsreceived, err = helpers.ConvertStringToInt64(raw.Numberpacketsreceived)
 	if err != nil {
-		logger.Error("Error parsing Numberpacketsreceived: %s in %s", err, filename)
+		return nil, newConversionError("Numberpacketsreceived", raw.Numberpacketsreceived, err)
 	}
 	ParsedNumberoctetsreceived, err = helpers.ConvertStringToInt64(raw.Numberoctetsreceived)
 	if err != nil {
-		logger.Error("Error parsing Numberoctetsreceived: %s in %s", err, filename)
+		return nil, newConversionError("Numberoctetsreceived", raw.Numberoctetsreceived, err)
 	}
 	ParsedNumberpacketslost, err = helpers.ConvertStringToInt64(raw.Numberpacketslost)
 	if err != nil {
-		logger.Error("Error parsing Numberpacketslost: %s in %s", err, filename)
+		return nil, newConversionError("Numberpacketslost", raw.Numberpacketslost, err)
 	}
 	ParsedJitter, err = helpers.ConvertStringToInt64(raw.Jitter)
 	if err != nil {
-		logger.Error("Error parsing Jitter: %s in %s", err, filename)
+		return nil, newConversionError("Jitter", raw.Jitter, err)
 	}
 	ParsedLatency, err = helpers.ConvertStringToInt64(raw.Latency)
 	if err != nil {
-		logger.Error("Error parsing Latency: %s in %s", err, filename)
+		return nil, newConversionError("Latency", raw.Latency, err)
 	}
 	ParsedDuration, err = helpers.ConvertStringToInt64(raw.Duration)
 	if err != nil {
-		logger.Error("Error parsing Duration: %s in %s", err, filename)
+		return nil, newConversionError("Duration", raw.Duration, err)
 	}
 	ParsedVideoduration, err = helpers.ConvertStringToInt64(raw.Videoduration)
 	if err != nil {
-		logger.Error("Error parsing Videoduration: %s in %s", err, filename)
+		return nil, newConversionError("Videoduration", raw.Videoduration, err)
 	}
 	ParsedNumbervideopacketssent, err = helpers.ConvertStringToInt64(raw.Numbervideopacketssent)
 	if err != nil {
-		logger.Error("Error parsing Numbervideopacketssent: %s in %s", err, filename)
+		return nil, newConversionError("Numbervideopacketssent", raw.Numbervideopacketssent, err)
 	}
 	ParsedNumbervideooctetssent, err = helpers.ConvertStringToInt64(raw.Numbervideooctetssent)
 	if err != nil {
-		logger.Error("Error parsing Numbervideooctetssent: %s in %s", err, filename)
+		return nil, newConversionError("Numbervideooctetssent", raw.Numbervideooctetssent, err)
 	}
 	ParsedNumbervideopacketsreceived, err = helpers.ConvertStringToInt64(raw.Numbervideopacketsreceived)
 	if err != nil {
-		logger.Error("Error parsing Numbervideopacketsreceived: %s in %s", err, filename)
+		return nil, newConversionError("Numbervideopacketsreceived", raw.Numbervideopacketsreceived, err)
 	}
 	ParsedNumbervideooctetsreceived, err = helpers.ConvertStringToInt64(raw.Numbervideooctetsreceived)
 	if err != nil {
-		logger.Error("Error parsing Numbervideooctetsreceived: %s in %s", err, filename)
+		return nil, newConversionError("Numbervideooctetsreceived", raw.Numbervideooctetsreceived, err)
 	}
 	ParsedNumbervideopacketslost, err = helpers.ConvertStringToInt64(raw.Numbervideopacketslost)
 	if err != nil {
-		logger.Error("Error parsing Numbervideopacketslost: %s in %s", err, filename)
+		return nil, newConversionError("Numbervideopacketslost", raw.Numbervideopacketslost, err)
 	}
 	ParsedVideoaveragejitter, err = helpers.ConvertStringToInt64(raw.Videoaveragejitter)
 	if err != nil {
-		logger.Error("Error parsing Videoaveragejitter: %s in %s", err, filename)
+		return nil, newConversionError("Videoaveragejitter", raw.Videoaveragejitter, err)
 	}
 	ParsedVideoroundtriptime, err = helpers.ConvertStringToInt64(raw.Videoroundtriptime)
 	if err != nil {
-		logger.Error("Error parsing Videoroundtriptime: %s in %s", err, filename)
+		return nil, newConversionError("Videoroundtriptime", raw.Videoroundtriptime, err)
 	}
 	ParsedVideoonewaydelay, err = helpers.ConvertStringToInt64(raw.Videoonewaydelay)
 	if err != nil {
-		logger.Error("Error parsing Videoonewaydelay: %s in %s", err, filename)
+		return nil, newConversionError("Videoonewaydelay", raw.Videoonewaydelay, err)
 	}
 	ParsedVideoduration_Channel2, err = helpers.ConvertStringToInt64(raw.Videoduration_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Videoduration_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Videoduration_Channel2", raw.Videoduration_Channel2, err)
 	}
 	ParsedNumbervideopacketssent_Channel2, err = helpers.ConvertStringToInt64(raw.Numbervideopacketssent_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Numbervideopacketssent_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Numbervideopacketssent_Channel2", raw.Numbervideopacketssent_Channel2, err)
 	}
 	ParsedNumbervideooctetssent_Channel2, err = helpers.ConvertStringToInt64(raw.Numbervideooctetssent_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Numbervideooctetssent_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Numbervideooctetssent_Channel2", raw.Numbervideooctetssent_Channel2, err)
 	}
 	ParsedNumbervideopacketsreceived_Channel2, err = helpers.ConvertStringToInt64(raw.Numbervideopacketsreceived_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Numbervideopacketsreceived_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Numbervideopacketsreceived_Channel2", raw.Numbervideopacketsreceived_Channel2, err)
 	}
 	ParsedNumbervideooctetsreceived_Channel2, err = helpers.ConvertStringToInt64(raw.Numbervideooctetsreceived_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Numbervideooctetsreceived_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Numbervideooctetsreceived_Channel2", raw.Numbervideooctetsreceived_Channel2, err)
 	}
 	ParsedNumbervideopacketslost_Channel2, err = helpers.ConvertStringToInt64(raw.Numbervideopacketslost_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Numbervideopacketslost_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Numbervideopacketslost_Channel2", raw.Numbervideopacketslost_Channel2, err)
 	}
 	ParsedVideoaveragejitter_Channel2, err = helpers.ConvertStringToInt64(raw.Videoaveragejitter_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Videoaveragejitter_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Videoaveragejitter_Channel2", raw.Videoaveragejitter_Channel2, err)
 	}
 	ParsedVideoroundtriptime_Channel2, err = helpers.ConvertStringToInt64(raw.Videoroundtriptime_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Videoroundtriptime_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Videoroundtriptime_Channel2", raw.Videoroundtriptime_Channel2, err)
 	}
 	ParsedVideoonewaydelay_Channel2, err = helpers.ConvertStringToInt64(raw.Videoonewaydelay_Channel2)
 	if err != nil {
-		logger.Error("Error parsing Videoonewaydelay_Channel2: %s in %s", err, filename)
+		return nil, newConversionError("Videoonewaydelay_Channel2", raw.Videoonewaydelay_Channel2, err)
 	}
 
 	ParsedOriginpkid = helpers.RemoveSpaceFromString(raw.Pkid)
@@ -301,119 +300,119 @@ func (raw *RawCucmCmr) Parse(filename string) (*CucmCmr, error) {
 		if ok {
 			ParsedVQMLQK, err = helpers.ConvertStringToFloat64(&VQMLQK)
 			if err != nil {
-				logger.Error("Error parsing VQMLQK: %s in %s", err, filename)
+				return nil, newConversionError("VQMLQK", &VQMLQK, err)
 			}
 		}
 		Vqmlqkav, ok := VarVQMap["MLQKav"]
 		if ok {
 			ParsedVqmlqkav, err = helpers.ConvertStringToFloat64(&Vqmlqkav)
 			if err != nil {
-				logger.Error("Error parsing Vqmlqkav: %s in %s", err, filename)
+				return nil, newConversionError("Vqmlqkav", &Vqmlqkav, err)
 			}
 		}
 		Vqmlqkmn, ok := VarVQMap["MLQKmn"]
 		if ok {
 			ParsedVqmlqkmn, err = helpers.ConvertStringToFloat64(&Vqmlqkmn)
 			if err != nil {
-				logger.Error("Error parsing Vqmlqkmn: %s in %s", err, filename)
+				return nil, newConversionError("Vqmlqkmn", &Vqmlqkmn, err)
 			}
 		}
 		Vqmlqkmx, ok := VarVQMap["MLQKmx"]
 		if ok {
 			ParsedVqmlqkmx, err = helpers.ConvertStringToFloat64(&Vqmlqkmx)
 			if err != nil {
-				logger.Error("Error parsing Vqmlqkmx: %s in %s", err, filename)
+				return nil, newConversionError("Vqmlqkmx", &Vqmlqkmx, err)
 			}
 		}
 		Vqmlqkvr, ok := VarVQMap["MLQKvr"]
 		if ok {
 			ParsedVqmlqkvr, err = helpers.ConvertStringToFloat64(&Vqmlqkvr)
 			if err != nil {
-				logger.Error("Error parsing Vqmlqkvr: %s in %s", err, filename)
+				return nil, newConversionError("Vqmlqkvr", &Vqmlqkvr, err)
 			}
 		}
 		VQCCR, ok := VarVQMap["CCR"]
 		if ok {
 			ParsedVQCCR, err = helpers.ConvertStringToFloat64(&VQCCR)
 			if err != nil {
-				logger.Error("Error parsing VQCCR: %s in %s", err, filename)
+				return nil, newConversionError("VQCCR", &VQCCR, err)
 			}
 		}
 		VQICR, ok := VarVQMap["ICR"]
 		if ok {
 			ParsedVQICR, err = helpers.ConvertStringToFloat64(&VQICR)
 			if err != nil {
-				logger.Error("Error parsing VQICR: %s in %s", err, filename)
+				return nil, newConversionError("VQICR", &VQICR, err)
 			}
 		}
 		Vqicrmx, ok := VarVQMap["ICRmx"]
 		if ok {
 			ParsedVqicrmx, err = helpers.ConvertStringToFloat64(&Vqicrmx)
 			if err != nil {
-				logger.Error("Error parsing Vqicrmx: %s in %s", err, filename)
+				return nil, newConversionError("Vqicrmx", &Vqicrmx, err)
 			}
 		}
 		Vqver, ok := VarVQMap["Ver"]
 		if ok {
 			ParsedVqver, err = helpers.ConvertStringToFloat64(&Vqver)
 			if err != nil {
-				logger.Error("Error parsing Vqver: %s in %s", err, filename)
+				return nil, newConversionError("Vqver", &Vqver, err)
 			}
 		}
 		VQCS, ok := VarVQMap["CS"]
 		if ok {
 			ParsedVQCS, err = helpers.ConvertStringToInt64(&VQCS)
 			if err != nil {
-				logger.Error("Error parsing VQCS: %s in %s", err, filename)
+				return nil, newConversionError("VQCS", &VQCS, err)
 			}
 		}
 		VQSCS, ok := VarVQMap["SCS"]
 		if ok {
 			ParsedVQSCS, err = helpers.ConvertStringToInt64(&VQSCS)
 			if err != nil {
-				logger.Error("Error parsing VQSCS: %s in %s", err, filename)
+				return nil, newConversionError("VQSCS", &VQSCS, err)
 			}
 		}
 		VQCID, ok := VarVQMap["CID"]
 		if ok {
 			ParsedVQCID, err = helpers.ConvertStringToInt64(&VQCID)
 			if err != nil {
-				logger.Error("Error parsing VQCID: %s in %s", err, filename)
+				return nil, newConversionError("VQCID", &VQCID, err)
 			}
 		}
 		Vqvopktsizems, ok := VarVQMap["VoPktSizeMs"]
 		if ok {
 			ParsedVqvopktsizems, err = helpers.ConvertStringToInt64(&Vqvopktsizems)
 			if err != nil {
-				logger.Error("Error parsing Vqvopktsizems: %s in %s", err, filename)
+				return nil, newConversionError("Vqvopktsizems", &Vqvopktsizems, err)
 			}
 		}
 		Vqvopktlost, ok := VarVQMap["VoPktLost"]
 		if ok {
 			ParsedVqvopktlost, err = helpers.ConvertStringToInt64(&Vqvopktlost)
 			if err != nil {
-				logger.Error("Error parsing Vqvopktlost: %s in %s", err, filename)
+				return nil, newConversionError("Vqvopktlost", &Vqvopktlost, err)
 			}
 		}
 		Vqvopktdis, ok := VarVQMap["VoPktDis"]
 		if ok {
 			ParsedVqvopktdis, err = helpers.ConvertStringToInt64(&Vqvopktdis)
 			if err != nil {
-				logger.Error("Error parsing Vqvopktdis: %s in %s", err, filename)
+				return nil, newConversionError("Vqvopktdis", &Vqvopktdis, err)
 			}
 		}
 		Vqvoonewaydelayms, ok := VarVQMap["VoOneWayDelayMs"]
 		if ok {
 			ParsedVqvoonewaydelayms, err = helpers.ConvertStringToInt64(&Vqvoonewaydelayms)
 			if err != nil {
-				logger.Error("Error parsing Vqvoonewaydelayms: %s in %s", err, filename)
+				return nil, newConversionError("Vqvoonewaydelayms", &Vqvoonewaydelayms, err)
 			}
 		}
 		Vqmaxjitter, ok := VarVQMap["maxJitter"]
 		if ok {
 			ParsedVqmaxjitter, err = helpers.ConvertStringToInt64(&Vqmaxjitter)
 			if err != nil {
-				logger.Error("Error parsing Vqmaxjitter: %s in %s", err, filename)
+				return nil, newConversionError("Vqmaxjitter", &Vqmaxjitter, err)
 			}
 		}
 		VoRxCodec, ok := VarVQMap["VoRxCodec"]
@@ -421,6 +420,7 @@ func (raw *RawCucmCmr) Parse(filename string) (*CucmCmr, error) {
 			ParsedVqvorxcodec = &VoRxCodec
 		}
 	}
+	ParsedVqvorxcodec_Name, ParsedVqvorxcodec_Family := DecodeCodec(ParsedVqvorxcodec)
 
 	return &CucmCmr{
 		ID:                                  uuid.New().String(),
@@ -482,6 +482,8 @@ func (raw *RawCucmCmr) Parse(filename string) (*CucmCmr, error) {
 		VQSCS:                               ParsedVQSCS,
 		Vqver:                               ParsedVqver,
 		Vqvorxcodec:                         ParsedVqvorxcodec,
+		Vqvorxcodec_Name:                    ParsedVqvorxcodec_Name,
+		Vqvorxcodec_Family:                  ParsedVqvorxcodec_Family,
 		VQCID:                               ParsedVQCID,
 		Vqvopktsizems:                       ParsedVqvopktsizems,
 		Vqvopktlost:                         ParsedVqvopktlost,
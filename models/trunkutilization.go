@@ -0,0 +1,214 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+var (
+	UtilizationSourceCucm = "cucm"
+	UtilizationSourceCube = "cube"
+
+	UtilizationGranularityMinute = "minute"
+	UtilizationGranularityHour   = "hour"
+
+	// Trunk of the rows that count every call of a gateway once.
+	UtilizationAllTrunks = "*"
+)
+
+// TrunkUtilization is the utilization of a trunk during the minute or hour that
+// starts at IntervalStart. For CUBE, Gateway is the hostname of the gateway and
+// Trunk the trunk group label or the peer address of the legs. For CUCM,
+// Gateway is the cluster and Trunk the name of the gateway or SIP trunk device.
+// PeakCalls is the highest number of calls that were up at the same time and
+// CallSeconds the sum of their seconds in the interval, so CallSeconds / 3600
+// are the Erlangs of an hour.
+type TrunkUtilization struct {
+	ID            string
+	Source        *string `gorm:"size:16;not null;uniqueIndex:trunk_utilization_index"`
+	Gateway       *string `gorm:"size:255;not null;uniqueIndex:trunk_utilization_index"`
+	Trunk         *string `gorm:"size:255;not null;uniqueIndex:trunk_utilization_index"`
+	Granularity   *string `gorm:"size:16;not null;uniqueIndex:trunk_utilization_index"`
+	IntervalStart *int64  `gorm:"not null;uniqueIndex:trunk_utilization_index"`
+	PeakCalls     *int64
+	CallSeconds   *int64
+}
+
+// TrunkSpan is a call on a trunk from Start to End, in unix time.
+type TrunkSpan struct {
+	Source  string
+	Gateway string
+	Trunk   string
+	Start   int64
+	End     int64
+}
+
+type trunkKey struct{ source, gateway, trunk string }
+
+type utilizationBucket struct {
+	peak    int64
+	seconds int64
+}
+
+// TrunkUtilizationCounter computes the utilization of trunks for the minutes
+// and hours from From to To, in unix time with To excluded, from calls that are
+// added one at a time. Only the change in the number of calls at each second of
+// a trunk is kept, so memory does not grow with the number of calls.
+type TrunkUtilizationCounter struct {
+	From   int64
+	To     int64
+	deltas map[trunkKey]map[int64]int64
+}
+
+func NewTrunkUtilizationCounter(from int64, to int64) *TrunkUtilizationCounter {
+	return &TrunkUtilizationCounter{From: from, To: to, deltas: map[trunkKey]map[int64]int64{}}
+}
+
+// Add counts a call on a trunk. The part of the call outside the range of the
+// counter is ignored.
+func (c *TrunkUtilizationCounter) Add(span TrunkSpan) {
+
+	if span.End <= span.Start || span.End <= c.From || span.Start >= c.To {
+		return
+	}
+	start, end := span.Start, span.End
+	if start < c.From {
+		start = c.From
+	}
+	if end > c.To {
+		end = c.To
+	}
+
+	key := trunkKey{span.Source, span.Gateway, span.Trunk}
+	deltas, ok := c.deltas[key]
+	if !ok {
+		deltas = map[int64]int64{}
+		c.deltas[key] = deltas
+	}
+	// A call that ends when another one starts cancels out in the same
+	// second, so they are not up at the same time.
+	deltas[start]++
+	deltas[end]--
+}
+
+// Utilizations returns the utilization of every trunk a call was added for.
+// From should be a whole hour, and the last hour is partial unless To is too.
+// Intervals in which a trunk had no calls are left out.
+func (c *TrunkUtilizationCounter) Utilizations() []*TrunkUtilization {
+
+	keys := make([]trunkKey, 0, len(c.deltas))
+	for key := range c.deltas {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].source != keys[j].source {
+			return keys[i].source < keys[j].source
+		}
+		if keys[i].gateway != keys[j].gateway {
+			return keys[i].gateway < keys[j].gateway
+		}
+		return keys[i].trunk < keys[j].trunk
+	})
+
+	utilizations := []*TrunkUtilization{}
+	for _, key := range keys {
+		deltas := c.deltas[key]
+		times := make([]int64, 0, len(deltas))
+		for second := range deltas {
+			times = append(times, second)
+		}
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+		minutes := map[int64]*utilizationBucket{}
+		var calls int64
+		for i, second := range times {
+			calls += deltas[second]
+			if calls <= 0 || i == len(times)-1 {
+				continue
+			}
+
+			// The number of calls is constant until the next change.
+			start, end := second, times[i+1]
+			for minute := start - start%60; minute < end; minute += 60 {
+				overlapStart, overlapEnd := minute, minute+60
+				if overlapStart < start {
+					overlapStart = start
+				}
+				if overlapEnd > end {
+					overlapEnd = end
+				}
+				bucket, ok := minutes[minute]
+				if !ok {
+					bucket = &utilizationBucket{}
+					minutes[minute] = bucket
+				}
+				if calls > bucket.peak {
+					bucket.peak = calls
+				}
+				bucket.seconds += calls * (overlapEnd - overlapStart)
+			}
+		}
+
+		hours := map[int64]*utilizationBucket{}
+		for minute, bucket := range minutes {
+			hour := minute - minute%3600
+			total, ok := hours[hour]
+			if !ok {
+				total = &utilizationBucket{}
+				hours[hour] = total
+			}
+			if bucket.peak > total.peak {
+				total.peak = bucket.peak
+			}
+			total.seconds += bucket.seconds
+		}
+
+		utilizations = append(utilizations, newTrunkUtilizations(key, UtilizationGranularityMinute, minutes)...)
+		utilizations = append(utilizations, newTrunkUtilizations(key, UtilizationGranularityHour, hours)...)
+	}
+
+	return utilizations
+}
+
+func newTrunkUtilizations(key trunkKey, granularity string, buckets map[int64]*utilizationBucket) []*TrunkUtilization {
+
+	starts := make([]int64, 0, len(buckets))
+	for start := range buckets {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	utilizations := make([]*TrunkUtilization, 0, len(starts))
+	for _, start := range starts {
+		source, gateway, trunk := key.source, key.gateway, key.trunk
+		intervalStart := start
+		bucket := buckets[start]
+		utilizations = append(utilizations, &TrunkUtilization{
+			ID:            uuid.New().String(),
+			Source:        &source,
+			Gateway:       &gateway,
+			Trunk:         &trunk,
+			Granularity:   &granularity,
+			IntervalStart: &intervalStart,
+			PeakCalls:     &bucket.peak,
+			CallSeconds:   &bucket.seconds,
+		})
+	}
+	return utilizations
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+var (
+	VoiceQualitySourceCucm = "cucm"
+	VoiceQualitySourceCube = "cube"
+)
+
+// VoiceQualityHour is the voice quality of the calls of an SLA during the hour
+// that starts at IntervalStart. Calls are the CUCM CMRs or CUBE legs that were
+// measured, and PoorCalls those that broke at least one threshold of the SLA,
+// counted per threshold in the Violations columns. For CUBE, jitter is the
+// receive delay and latency the round trip delay of the legs. Violated is set
+// when the hour breaks the SLA.
+type VoiceQualityHour struct {
+	ID                string
+	SLA               *string `gorm:"size:255;not null;uniqueIndex:voice_quality_hour_index"`
+	Source            *string `gorm:"size:16;not null;uniqueIndex:voice_quality_hour_index"`
+	IntervalStart     *int64  `gorm:"not null;uniqueIndex:voice_quality_hour_index"`
+	Calls             *int64
+	PoorCalls         *int64
+	JitterViolations  *int64
+	LatencyViolations *int64
+	LossViolations    *int64
+	MosViolations     *int64
+	MaxJitter         *int64
+	MaxLatency        *int64
+	MaxLossPercent    *float64
+	WorstMos          *float64
+	Violated          bool
+}
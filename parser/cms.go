@@ -0,0 +1,38 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"path/filepath"
+
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/logger"
+)
+
+// ParseCMSRecords loads a file holding a <records> document posted by Cisco
+// Meeting Server.
+func ParseCMSRecords(inputFile string, db *database.DataService, outputDirectory string, deleteOriginal bool) (*FileSummary, error) {
+
+	baseFileName := filepath.Base(inputFile)
+
+	logger.Info("Found CDR file: %s", baseFileName)
+	return loadFile(inputFile, "cms", db, outputDirectory, deleteOriginal, func(tx database.DataService) (*parsedFile, error) {
+		count, rejects, err := ParseCmsRecordFile(inputFile, chunkSize(tx), func(records *CmsRecords) error {
+			return tx.SaveCmsRecords(records.Calls, records.CallLegs)
+		})
+		return &parsedFile{count: count, rejects: rejects}, err
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"io"
+	"os"
+
+	"github.com/ziondials/go-cdr/cdr"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
+)
+
+// CmsRecords are the calls and call legs parsed from a batch of Cisco Meeting
+// Server CDR records, in the order of the records.
+type CmsRecords struct {
+	Calls    []*models.CmsCall
+	CallLegs []*models.CmsCallLeg
+}
+
+// ParseCmsRecordFile parses inputFile and passes its records to write in chunks
+// of size records. It returns the number of records written and the records
+// that were rejected.
+func ParseCmsRecordFile(inputFile string, size int, write func(records *CmsRecords) error) (int, []*RejectedRow, error) {
+
+	logger.Info("Parsing file: %s", inputFile)
+
+	readFile, err := os.Open(inputFile)
+	if err != nil {
+		logger.Error("Error opening file: %s Error: %s", inputFile, err)
+		return 0, nil, &parseError{err: err}
+	}
+	defer readFile.Close()
+
+	records := cdr.NewCmsRecordParser().Parse(readFile, cdr.Metadata{Source: inputFile})
+	count, rejects, err := streamRecords(records, inputFile, "CDR", size, func(chunk []*cdr.CmsRecord) error {
+		return write(newCmsRecords(chunk))
+	})
+	if err != nil {
+		return count, rejects, err
+	}
+
+	logger.Info("Finished parsing file: %s", inputFile)
+	return count, rejects, nil
+}
+
+// ParseCmsRecords parses the <records> document that Cisco Meeting Server
+// posts to its CDR receivers. Records that cannot be parsed are rejected with
+// the line they start on, while a document that is not well-formed fails as a
+// whole.
+func ParseCmsRecords(reader io.Reader, source string) (*CmsRecords, []*RejectedRow, error) {
+
+	parsed, rejects, err := collectRecords(cdr.NewCmsRecordParser().Parse(reader, cdr.Metadata{Source: source}), source, "CDR")
+	return newCmsRecords(parsed), rejects, err
+}
+
+func newCmsRecords(parsed []*cdr.CmsRecord) *CmsRecords {
+
+	records := &CmsRecords{Calls: []*models.CmsCall{}, CallLegs: []*models.CmsCallLeg{}}
+	for _, record := range parsed {
+		if record.Call != nil {
+			records.Calls = append(records.Calls, record.Call)
+		}
+		if record.CallLeg != nil {
+			records.CallLegs = append(records.CallLegs, record.CallLeg)
+		}
+	}
+	return records
+}
@@ -17,60 +17,33 @@ package parser
 
 import (
 	"path/filepath"
-	"strconv"
 
 	"github.com/ziondials/go-cdr/database"
-	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/fraud"
 	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
 )
 
-func ParseCUBECDRs(inputFile string, db *database.DataService, outputDirectory string, deleteOriginal bool) {
+func ParseCUBECDRs(inputFile string, db *database.DataService, outputDirectory string, deleteOriginal bool) (*FileSummary, error) {
 
 	baseFileName := filepath.Base(inputFile)
 
 	logger.Info("Found CDR file: %s", baseFileName)
-	cdrs, err := ParseCubeCDRFile(inputFile)
-	if err != nil {
-		logger.Error("Error parsing file: %s Error: %s", inputFile, err)
-		helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
-	}
-
-	if len(cdrs) > 0 && err == nil {
-
-		err := db.CreateCubeCDRs(cdrs)
-		if err != nil {
-			logger.Error("Error while writing to database: %s", err.Error())
-			err := helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
-			if err != nil {
-				logger.Error("Error while moving file: %s", err.Error())
-			} else {
-				logger.Info("Successfully moved file to failed directory: %s", inputFile)
+	return loadFile(inputFile, "cube", db, outputDirectory, deleteOriginal, func(tx database.DataService) (*parsedFile, error) {
+		alerts := []*models.Alert{}
+		count, rejects, err := ParseCubeCDRFile(inputFile, chunkSize(tx), func(cdrs []*models.CubeCDR) error {
+			if err := tx.SaveCubeCDRs(cdrs); err != nil {
+				return err
 			}
-		} else {
-			logger.Info("Successfully wrote %s CDRs to database from %s", strconv.Itoa(len(cdrs)), inputFile)
-			err := helpers.ChangeFileNameToCompleteAndMoveOrDelete(inputFile, outputDirectory, deleteOriginal)
+			raised, err := fraud.CheckCubeCDRs(tx, cdrs)
 			if err != nil {
-				logger.Error("Error while moving file: %s", err.Error())
-			} else {
-				logger.Info("Successfully moved file to completed directory: %s", inputFile)
+				return err
 			}
-		}
-	} else if len(cdrs) == 0 && err == nil {
-		logger.Info("No CDRs found in file: %s", inputFile)
-		err := helpers.ChangeFileNameToCompleteAndMoveOrDelete(inputFile, outputDirectory, deleteOriginal)
-		if err != nil {
-			logger.Error("Error while moving file: %s", err.Error())
-		} else {
-			logger.Info("Successfully moved file to completed directory: %s", inputFile)
-		}
-	} else {
-		logger.Error("Error parsing file: %s Error: %s", inputFile, err)
-		err := helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
-		if err != nil {
-			logger.Error("Error while moving file: %s", err.Error())
-		} else {
-			logger.Info("Successfully moved file to failed directory: %s", inputFile)
-		}
-	}
-
+			saved, err := tx.SaveAlerts(raised)
+			alerts = append(alerts, saved...)
+			return err
+		})
+		committed := func() { fraud.Notify(alerts) }
+		return &parsedFile{count: count, rejects: rejects, committed: committed}, err
+	})
 }
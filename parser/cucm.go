@@ -17,102 +17,74 @@ package parser
 
 import (
 	"path/filepath"
-	"strconv"
 
 	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/fraud"
 	"github.com/ziondials/go-cdr/helpers"
 	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
 )
 
-func ParseCUCMCDRs(inputFile string, db *database.DataService, outputDirectory string, deleteOriginal bool) {
+func ParseCUCMCDRs(inputFile string, db *database.DataService, outputDirectory string, deleteOriginal bool) (*FileSummary, error) {
 
 	baseFileName := filepath.Base(inputFile)
 
+	var summary *FileSummary
+	var err error
+
 	if helpers.CMRReg.MatchString(baseFileName) {
 		logger.Info("Found CMR file: %s", baseFileName)
-		cdrs, err := ParseCucmCMRFile(inputFile)
-		if err != nil {
-			logger.Error("Error parsing file: %s Error: %s", inputFile, err)
-		}
-
-		if len(cdrs) > 0 {
-
-			err := db.CreateCucmCMRs(cdrs)
-			if err != nil {
-				logger.Error("Error while writing to database: %s", err.Error())
-			} else {
-				logger.Info("Successfully wrote %s CDRs to database from %s", strconv.Itoa(len(cdrs)), inputFile)
-				err := helpers.ChangeFileNameToCompleteAndMoveOrDelete(inputFile, outputDirectory, deleteOriginal)
-				if err != nil {
-					logger.Error("Error while moving file: %s", err.Error())
-				} else {
-					logger.Info("Successfully moved file to failed directory: %s", inputFile)
+		summary, err = loadFile(inputFile, "cucm", db, outputDirectory, deleteOriginal, func(tx database.DataService) (*parsedFile, error) {
+			count, rejects, err := ParseCucmCMRFile(inputFile, chunkSize(tx), func(cmrs []*models.CucmCmr) error {
+				if err := tx.CreateCucmCMRs(cmrs); err != nil {
+					return err
 				}
-			}
-		} else if len(cdrs) == 0 && err == nil {
-			logger.Info("No CDRs found in file: %s", inputFile)
-			err := helpers.ChangeFileNameToCompleteAndMoveOrDelete(inputFile, outputDirectory, deleteOriginal)
-			if err != nil {
-				logger.Error("Error while moving file: %s", err.Error())
-			} else {
-				logger.Info("Successfully moved file to completed directory: %s", inputFile)
-			}
-		} else {
-			logger.Error("Error parsing file: %s Error: %s", inputFile, err)
-			err := helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
-			if err != nil {
-				logger.Error("Error while moving file: %s", err.Error())
-			} else {
-				logger.Info("Successfully moved file to failed directory: %s", inputFile)
-			}
-		}
+				return tx.CorrelateCucmCMRs(globalCallIDs(cmrs, func(cmr *models.CucmCmr) *int64 { return cmr.Globalcallid_Callid }))
+			})
+			return &parsedFile{count: count, rejects: rejects}, err
+		})
 	}
 
 	if helpers.CDRReg.MatchString(baseFileName) {
 		logger.Info("Found CDR file: %s", baseFileName)
-		cdrs, err := ParseCucmCDRFile(inputFile)
-		if err != nil {
-			logger.Error("Error parsing file: %s Error: %s", inputFile, err)
-			helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
-		}
-
-		if len(cdrs) > 0 && err == nil {
-
-			err := db.CreateCucmCDRs(cdrs)
-			if err != nil {
-				logger.Error("Error while writing to database: %s", err.Error())
-				err := helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
-				if err != nil {
-					logger.Error("Error while moving file: %s", err.Error())
-				} else {
-					logger.Info("Successfully moved file to failed directory: %s", inputFile)
+		summary, err = loadFile(inputFile, "cucm", db, outputDirectory, deleteOriginal, func(tx database.DataService) (*parsedFile, error) {
+			alerts := []*models.Alert{}
+			count, rejects, err := ParseCucmCDRFile(inputFile, chunkSize(tx), func(cdrs []*models.CucmCdr) error {
+				if err := tx.CreateCucmCDRs(cdrs); err != nil {
+					return err
+				}
+				if err := tx.CorrelateCucmCMRs(globalCallIDs(cdrs, func(cdr *models.CucmCdr) *int64 { return cdr.Globalcallid_Callid })); err != nil {
+					return err
 				}
-			} else {
-				logger.Info("Successfully wrote %s CDRs to database from %s", strconv.Itoa(len(cdrs)), inputFile)
-				err := helpers.ChangeFileNameToCompleteAndMoveOrDelete(inputFile, outputDirectory, deleteOriginal)
+				if _, err := tx.RateCucmCdrs(cdrs); err != nil {
+					return err
+				}
+				raised, err := fraud.CheckCucmCdrs(tx, cdrs)
 				if err != nil {
-					logger.Error("Error while moving file: %s", err.Error())
-				} else {
-					logger.Info("Successfully moved file to completed directory: %s", inputFile)
+					return err
 				}
-			}
-		} else if len(cdrs) == 0 && err == nil {
-			logger.Info("No CDRs found in file: %s", inputFile)
-			err := helpers.ChangeFileNameToCompleteAndMoveOrDelete(inputFile, outputDirectory, deleteOriginal)
-			if err != nil {
-				logger.Error("Error while moving file: %s", err.Error())
-			} else {
-				logger.Info("Successfully moved file to completed directory: %s", inputFile)
-			}
-		} else {
-			logger.Error("Error parsing file: %s Error: %s", inputFile, err)
-			err := helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
-			if err != nil {
-				logger.Error("Error while moving file: %s", err.Error())
-			} else {
-				logger.Info("Successfully moved file to failed directory: %s", inputFile)
-			}
-		}
+				saved, err := tx.SaveAlerts(raised)
+				alerts = append(alerts, saved...)
+				return err
+			})
+			committed := func() { fraud.Notify(alerts) }
+			return &parsedFile{count: count, rejects: rejects, committed: committed}, err
+		})
 	}
 
+	return summary, err
+}
+
+// globalCallIDs returns the distinct global call IDs of a chunk of CDRs or
+// CMRs, to correlate only the CMRs of the calls in the chunk.
+func globalCallIDs[T any](records []T, callID func(T) *int64) []int64 {
+	seen := map[int64]bool{}
+	ids := []int64{}
+	for _, record := range records {
+		if id := callID(record); id != nil && !seen[*id] {
+			seen[*id] = true
+			ids = append(ids, *id)
+		}
+	}
+	return ids
 }
@@ -0,0 +1,253 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
+)
+
+// parsedFile is the result of loading a single file: the number of records
+// written, the rows that were rejected and, if set, a function that is called
+// once the records are committed.
+type parsedFile struct {
+	count     int
+	rejects   []*RejectedRow
+	committed func()
+}
+
+// fileParser parses a file and writes its records using the given transaction,
+// a chunk at a time. It returns the rows rejected so far even when it fails,
+// and an error reading the file as a *parseError.
+type fileParser func(tx database.DataService) (*parsedFile, error)
+
+// FileSummary is the outcome of loading a single file, reported in the
+// summary logged at the end of each run.
+type FileSummary struct {
+	Filename string
+	Status   string
+	Records  int
+	Rejected int
+}
+
+// loadFile parses a single file and writes its records together with its
+// ingested_files ledger entry in one transaction, so a file is either loaded
+// completely or not at all. The file is parsed and written in chunks within
+// the transaction, so files of any size are loaded in bounded memory. A file
+// whose content is already recorded as complete in the ledger is moved to
+// complete without being loaded again.
+//
+// Files with bad content are moved to failed and do not return an error. An
+// error is returned when the file could not be processed at all, e.g. because
+// the database or the output directory is unavailable; if the database cannot
+// be reached the file is left in place to be retried.
+func loadFile(inputFile string, directoryType string, db *database.DataService, outputDirectory string, deleteOriginal bool, parse fileParser) (*FileSummary, error) {
+
+	baseFileName := filepath.Base(inputFile)
+	startTime := time.Now().UTC().Unix()
+
+	ledger := &models.IngestedFile{
+		ID:            uuid.New().String(),
+		Filename:      &baseFileName,
+		DirectoryType: &directoryType,
+		StartTime:     &startTime,
+	}
+	failed := &FileSummary{Filename: baseFileName, Status: models.IngestionStatusFailed}
+
+	sha256, err := helpers.FileSha256(inputFile)
+	if err != nil {
+		logger.Error("Error hashing file: %s Error: %s", inputFile, err)
+		return failed, fmt.Errorf("error hashing file %s: %w", inputFile, err)
+	}
+	ledger.Sha256 = &sha256
+
+	// Copies of the same file being parsed in parallel are loaded one after
+	// the other, so only the first is loaded and the rest are duplicates.
+	unlock := lockHash(sha256)
+	defer unlock()
+
+	loaded, err := db.FindCompletedIngestedFile(sha256)
+	if err != nil {
+		// Leave the file where it is so the next run can try again.
+		logger.Error("Error while reading ingestion ledger for %s: %s", inputFile, err.Error())
+		return failed, fmt.Errorf("error reading ingestion ledger for %s: %w", inputFile, err)
+	}
+	if loaded != nil {
+		if loaded.Filename != nil {
+			logger.Info("Skipping file: %s Content was already loaded from %s", inputFile, *loaded.Filename)
+		} else {
+			logger.Info("Skipping file: %s Content was already loaded", inputFile)
+		}
+		recordIngestion(db, ledger, models.IngestionStatusDuplicate, &parsedFile{})
+		summary := &FileSummary{Filename: baseFileName, Status: models.IngestionStatusDuplicate}
+		return summary, moveToComplete(inputFile, outputDirectory, deleteOriginal)
+	}
+
+	parsed := &parsedFile{}
+	err = db.Transaction(func(tx database.DataService) error {
+		var err error
+		if parsed, err = parse(tx); err != nil {
+			return err
+		}
+		setIngestionResult(ledger, models.IngestionStatusComplete, parsed)
+		return tx.CreateIngestedFile(ledger)
+	})
+	var parseErr *parseError
+	if errors.As(err, &parseErr) {
+		logger.Error("Error parsing file: %s Error: %s", inputFile, err)
+		recordIngestion(db, ledger, models.IngestionStatusFailed, parsed)
+		writeRejects(inputFile, outputDirectory, models.IngestionStatusFailed, parsed.rejects)
+		failed.Rejected = len(parsed.rejects)
+		return failed, moveToFailed(inputFile, outputDirectory)
+	}
+	if err != nil {
+		logger.Error("Error while writing to database: %s", err.Error())
+		if pingErr := db.Ping(); pingErr != nil {
+			// Leave the file where it is so the next run can try again.
+			return failed, fmt.Errorf("error writing %s to database: %w", inputFile, err)
+		}
+		recordIngestion(db, ledger, models.IngestionStatusFailed, parsed)
+		writeRejects(inputFile, outputDirectory, models.IngestionStatusFailed, parsed.rejects)
+		failed.Rejected = len(parsed.rejects)
+		if moveErr := moveToFailed(inputFile, outputDirectory); moveErr != nil {
+			return failed, moveErr
+		}
+		return failed, fmt.Errorf("error writing %s to database: %w", inputFile, err)
+	}
+
+	if parsed.committed != nil {
+		parsed.committed()
+	}
+
+	if parsed.count > 0 {
+		logger.Info("Successfully wrote %s CDRs to database from %s", strconv.Itoa(parsed.count), inputFile)
+	} else {
+		logger.Info("No CDRs found in file: %s", inputFile)
+	}
+	writeRejects(inputFile, outputDirectory, models.IngestionStatusComplete, parsed.rejects)
+
+	summary := &FileSummary{Filename: baseFileName, Status: models.IngestionStatusComplete, Records: parsed.count, Rejected: len(parsed.rejects)}
+	return summary, moveToComplete(inputFile, outputDirectory, deleteOriginal)
+}
+
+// hashLock serialises the loading of files with the same content.
+type hashLock struct {
+	sync.Mutex
+	refs int
+}
+
+var (
+	hashLocksMutex sync.Mutex
+	hashLocks      = map[string]*hashLock{}
+)
+
+// lockHash blocks until no other file with the given SHA-256 is being loaded
+// and returns the function that releases the lock.
+func lockHash(sha256 string) func() {
+	hashLocksMutex.Lock()
+	lock, ok := hashLocks[sha256]
+	if !ok {
+		lock = &hashLock{}
+		hashLocks[sha256] = lock
+	}
+	lock.refs++
+	hashLocksMutex.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		hashLocksMutex.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(hashLocks, sha256)
+		}
+		hashLocksMutex.Unlock()
+	}
+}
+
+func setIngestionResult(ledger *models.IngestedFile, status string, parsed *parsedFile) {
+	endTime := time.Now().UTC().Unix()
+	recordCount := int64(parsed.count)
+	rejectedCount := int64(len(parsed.rejects))
+
+	ledger.Status = &status
+	ledger.RecordCount = &recordCount
+	ledger.RejectedCount = &rejectedCount
+	ledger.EndTime = &endTime
+}
+
+// recordIngestion writes a ledger entry outside of a load transaction, for
+// files that were skipped or whose load was rolled back.
+func recordIngestion(db *database.DataService, ledger *models.IngestedFile, status string, parsed *parsedFile) {
+	setIngestionResult(ledger, status, parsed)
+	if err := db.CreateIngestedFile(ledger); err != nil {
+		logger.Error("Error while writing to ingestion ledger: %s", err.Error())
+	}
+}
+
+// writeRejects writes the rejected rows of inputFile to a rejects file in the
+// complete or failed directory, next to where inputFile itself is moved.
+func writeRejects(inputFile string, outputDirectory string, status string, rejects []*RejectedRow) {
+	if len(rejects) == 0 {
+		return
+	}
+
+	logger.Info("Rejected %s rows from %s", strconv.Itoa(len(rejects)), inputFile)
+
+	rejectsFile, err := helpers.RejectsFilePath(inputFile, outputDirectory, status)
+	if err == nil {
+		err = writeRejectsFile(rejectsFile, rejects)
+	}
+	if err != nil {
+		logger.Error("Error while writing rejects file for %s: %s", inputFile, err.Error())
+	} else {
+		logger.Info("Successfully wrote rejected rows to: %s", rejectsFile)
+	}
+}
+
+func moveToComplete(inputFile string, outputDirectory string, deleteOriginal bool) error {
+	err := helpers.ChangeFileNameToCompleteAndMoveOrDelete(inputFile, outputDirectory, deleteOriginal)
+	if err != nil {
+		logger.Error("Error while moving file: %s", err.Error())
+		return fmt.Errorf("error moving %s to complete: %w", inputFile, err)
+	}
+	if !deleteOriginal {
+		logger.Info("Successfully moved file to completed directory: %s", inputFile)
+	}
+	return nil
+}
+
+func moveToFailed(inputFile string, outputDirectory string) error {
+	err := helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
+	if err != nil {
+		logger.Error("Error while moving file: %s", err.Error())
+		return fmt.Errorf("error moving %s to failed: %w", inputFile, err)
+	}
+	logger.Info("Successfully moved file to failed directory: %s", inputFile)
+	return nil
+}
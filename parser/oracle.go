@@ -17,60 +17,18 @@ package parser
 
 import (
 	"path/filepath"
-	"strconv"
 
 	"github.com/ziondials/go-cdr/database"
-	"github.com/ziondials/go-cdr/helpers"
 	"github.com/ziondials/go-cdr/logger"
 )
 
-func ParseOracleCDRs(inputFile string, db *database.DataService, outputDirectory string, deleteOriginal bool) {
+func ParseOracleCDRs(inputFile string, db *database.DataService, outputDirectory string, deleteOriginal bool) (*FileSummary, error) {
 
 	baseFileName := filepath.Base(inputFile)
 
 	logger.Info("Found CDR file: %s", baseFileName)
-	cdrs, err := ParseCubeCDRFile(inputFile)
-	if err != nil {
-		logger.Error("Error parsing file: %s Error: %s", inputFile, err)
-		helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
-	}
-
-	if len(cdrs) > 0 && err == nil {
-
-		err := db.CreateCubeCDRs(cdrs)
-		if err != nil {
-			logger.Error("Error while writing to database: %s", err.Error())
-			err := helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
-			if err != nil {
-				logger.Error("Error while moving file: %s", err.Error())
-			} else {
-				logger.Info("Successfully moved file to failed directory: %s", inputFile)
-			}
-		} else {
-			logger.Info("Successfully wrote %s CDRs to database from %s", strconv.Itoa(len(cdrs)), inputFile)
-			err := helpers.ChangeFileNameToCompleteAndMoveOrDelete(inputFile, outputDirectory, deleteOriginal)
-			if err != nil {
-				logger.Error("Error while moving file: %s", err.Error())
-			} else {
-				logger.Info("Successfully moved file to completed directory: %s", inputFile)
-			}
-		}
-	} else if len(cdrs) == 0 && err == nil {
-		logger.Info("No CDRs found in file: %s", inputFile)
-		err := helpers.ChangeFileNameToCompleteAndMoveOrDelete(inputFile, outputDirectory, deleteOriginal)
-		if err != nil {
-			logger.Error("Error while moving file: %s", err.Error())
-		} else {
-			logger.Info("Successfully moved file to completed directory: %s", inputFile)
-		}
-	} else {
-		logger.Error("Error parsing file: %s Error: %s", inputFile, err)
-		err := helpers.ChangeFileNameToFailedAndMove(inputFile, outputDirectory)
-		if err != nil {
-			logger.Error("Error while moving file: %s", err.Error())
-		} else {
-			logger.Info("Successfully moved file to failed directory: %s", inputFile)
-		}
-	}
-
+	return loadFile(inputFile, "oracle", db, outputDirectory, deleteOriginal, func(tx database.DataService) (*parsedFile, error) {
+		count, rejects, err := ParseOracleCDRFile(inputFile, chunkSize(tx), tx.CreateOracleCDRs)
+		return &parsedFile{count: count, rejects: rejects}, err
+	})
 }
@@ -16,19 +16,36 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
 
 	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/helpers"
 	"github.com/ziondials/go-cdr/logger"
 )
 
-func ParseFiles(inputDirectory string, outputDirectory string, fileType string, deleteOriginal bool, db *database.DataService) {
+// ErrUnknownFileType is returned for a directory type other than cms, cube,
+// cucm or oracle.
+var ErrUnknownFileType = errors.New("unknown file type")
+
+// ParseFiles parses every file in inputDirectory, up to workers files at a
+// time. The summary is logged in directory order once all files are done. The
+// errors of every file that could not be processed are returned together.
+func ParseFiles(inputDirectory string, outputDirectory string, fileType string, deleteOriginal bool, workers int, db *database.DataService) error {
+	if !isKnownFileType(fileType) {
+		logger.Error("Failed to match file type: %s", fileType)
+		return fmt.Errorf("%w: %s", ErrUnknownFileType, fileType)
+	}
+
 	// Get a list of files in the input directory
 	files, err := os.ReadDir(inputDirectory)
 	if err != nil {
 		logger.Error("Error reading directory: %s Error: %s", inputDirectory, err)
-		os.Exit(1)
+		return fmt.Errorf("error reading directory %s: %w", inputDirectory, err)
 	}
 
 	logger.Info("Parsing files in directory: %s", inputDirectory)
@@ -43,26 +60,87 @@ func ParseFiles(inputDirectory string, outputDirectory string, fileType string,
 	// }
 	// defer writeFile.Close()
 
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]*FileSummary, len(files))
+	errs := make([]error, len(files))
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
 	// Loop through the files in the input directory
-	for _, file := range files {
+	for i, file := range files {
 
-		// Check if the file is a directory
-		if !file.IsDir() {
+		// Check if the file is a directory or an upload still in progress
+		if !file.IsDir() && !helpers.IsPartialUpload(file.Name()) {
 
 			fullFilePath := filepath.Join(inputDirectory, file.Name())
-			switch fileType {
-			case "cube":
-				ParseCUBECDRs(fullFilePath, db, outputDirectory, deleteOriginal)
-			case "cucm":
-				ParseCUCMCDRs(fullFilePath, db, outputDirectory, deleteOriginal)
-			default:
-				// Failed to match a file type
-				logger.Error("Failed to match file type: %s", fileType)
-				os.Exit(1)
-
-			}
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				results[i], errs[i] = ParseFile(fullFilePath, outputDirectory, fileType, deleteOriginal, db)
+			}(i)
 		}
 	}
 
+	wg.Wait()
+
+	summaries := []*FileSummary{}
+	for _, summary := range results {
+		if summary != nil {
+			summaries = append(summaries, summary)
+		}
+	}
+
+	logSummary(inputDirectory, summaries)
+
 	logger.Info("Finished parsing files in directory: %s", inputDirectory)
+
+	return errors.Join(errs...)
+}
+
+// ParseFile parses a single file of the given type and loads it into the
+// database.
+func ParseFile(inputFile string, outputDirectory string, fileType string, deleteOriginal bool, db *database.DataService) (*FileSummary, error) {
+	switch fileType {
+	case "cms":
+		return ParseCMSRecords(inputFile, db, outputDirectory, deleteOriginal)
+	case "cube":
+		return ParseCUBECDRs(inputFile, db, outputDirectory, deleteOriginal)
+	case "cucm":
+		return ParseCUCMCDRs(inputFile, db, outputDirectory, deleteOriginal)
+	case "oracle":
+		return ParseOracleCDRs(inputFile, db, outputDirectory, deleteOriginal)
+	default:
+		// Failed to match a file type
+		logger.Error("Failed to match file type: %s", fileType)
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFileType, fileType)
+	}
+}
+
+func isKnownFileType(fileType string) bool {
+	switch fileType {
+	case "cms", "cube", "cucm", "oracle":
+		return true
+	default:
+		return false
+	}
+}
+
+// logSummary logs the outcome of every file loaded from inputDirectory in this
+// run, along with the totals, so the number of rejected rows can be accounted
+// for per file.
+func logSummary(inputDirectory string, summaries []*FileSummary) {
+
+	records, rejected := 0, 0
+	for _, summary := range summaries {
+		records += summary.Records
+		rejected += summary.Rejected
+		logger.Info("Summary for %s: %s, %s records loaded, %s rows rejected", summary.Filename, summary.Status, strconv.Itoa(summary.Records), strconv.Itoa(summary.Rejected))
+	}
+
+	logger.Info("Summary for directory %s: %s files, %s records loaded, %s rows rejected", inputDirectory, strconv.Itoa(len(summaries)), strconv.Itoa(records), strconv.Itoa(rejected))
 }
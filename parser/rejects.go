@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+
+	"github.com/ziondials/go-cdr/cdr"
+)
+
+// RejectedRow is a row that was read from a file but not loaded. Record holds
+// the fields exactly as they were read, so the row can be corrected and
+// resubmitted. Record is nil if the row was not valid CSV.
+type RejectedRow struct {
+	Line   int
+	Reason string
+	Record []string
+}
+
+// collectRecords reads every record of records, rejecting the rows that could
+// not be parsed. kind names the records in the log, e.g. CDR.
+func collectRecords[T any](records *cdr.Records[T], inputFile string, kind string) ([]T, []*RejectedRow, error) {
+
+	parsed := []T{}
+	rejects := []*RejectedRow{}
+	for records.Next() {
+		if rowErr := records.RowError(); rowErr != nil {
+			rejects = append(rejects, rejectRow(rowErr, inputFile, kind))
+			continue
+		}
+		parsed = append(parsed, records.Record())
+	}
+
+	return parsed, rejects, records.Err()
+}
+
+// writeRejectsFile writes rejects to path as CSV. Each row starts with the line
+// number and the reason, followed by the fields of the rejected row.
+func writeRejectsFile(path string, rejects []*RejectedRow) error {
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	for _, reject := range rejects {
+		row := append([]string{strconv.Itoa(reject.Line), reject.Reason}, reject.Record...)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
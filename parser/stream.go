@@ -0,0 +1,148 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ziondials/go-cdr/cdr"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/logger"
+)
+
+// parseError is an error reading or parsing a file, as opposed to an error
+// writing its records, so loadFile can tell a bad file from a database that is
+// unavailable.
+type parseError struct {
+	err error
+}
+
+func (e *parseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *parseError) Unwrap() error {
+	return e.err
+}
+
+// chunkSize returns the number of records parsed and written at a time, which
+// is the batch size of the database.
+func chunkSize(ds database.DataService) int {
+	if ds.Config == nil || ds.Config.Limit < 1 {
+		return 1
+	}
+	return int(ds.Config.Limit)
+}
+
+// streamRecords passes the records of records to write in chunks of size
+// records. The next chunk is parsed while write runs, and parsing waits for
+// write once a chunk is ready, so no more than three chunks are held in memory
+// however large the file is. It returns the number of records written and the
+// rows that were rejected.
+//
+// Reading stops at the first error of write, which is returned as is. An error
+// reading the input is returned as a *parseError, and the records of the last
+// chunk are then not written.
+func streamRecords[T any](records *cdr.Records[T], inputFile string, kind string, size int, write func(chunk []T) error) (int, []*RejectedRow, error) {
+
+	chunks := make(chan []T, 1)
+	done := make(chan struct{})
+	rejects := []*RejectedRow{}
+	var readErr error
+
+	go func() {
+		defer close(chunks)
+
+		send := func(chunk []T) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-done:
+				return false
+			}
+		}
+
+		chunk := make([]T, 0, size)
+		for records.Next() {
+			if rowErr := records.RowError(); rowErr != nil {
+				rejects = append(rejects, rejectRow(rowErr, inputFile, kind))
+				continue
+			}
+			chunk = append(chunk, records.Record())
+			if len(chunk) == size {
+				if !send(chunk) {
+					return
+				}
+				chunk = make([]T, 0, size)
+			}
+		}
+
+		if readErr = records.Err(); readErr == nil && len(chunk) > 0 {
+			send(chunk)
+		}
+	}()
+
+	count := 0
+	var writeErr error
+	for chunk := range chunks {
+		if writeErr = write(chunk); writeErr != nil {
+			close(done)
+			break
+		}
+		count += len(chunk)
+	}
+	// Wait for the parser to stop before reading what it left behind.
+	for range chunks {
+	}
+	logWarnings(records.Warnings(), kind)
+
+	if writeErr != nil {
+		return count, rejects, writeErr
+	}
+	if readErr != nil {
+		return count, rejects, &parseError{err: readErr}
+	}
+	return count, rejects, nil
+}
+
+// rejectRow logs a row of inputFile that could not be parsed and returns it
+// for the rejects file. kind names the records in the log, e.g. CDR.
+func rejectRow(rowErr *cdr.RowError, inputFile string, kind string) *RejectedRow {
+	logger.Error("Error parsing %s: %s %s on line %s", kind, inputFile, rowErr.Err, strconv.Itoa(rowErr.Line))
+	return &RejectedRow{Line: rowErr.Line, Reason: rowErr.Err.Error(), Record: rowErr.Record}
+}
+
+// unknownColumns holds the unknown columns that were already logged, so each
+// is only logged once rather than for every file.
+var unknownColumns sync.Map
+
+// logWarnings logs the warnings of a file. kind names the records, e.g. CDR.
+func logWarnings(warnings []error, kind string) {
+	for _, warning := range warnings {
+		var unknownColumn *cdr.UnknownColumnError
+		if errors.As(warning, &unknownColumn) {
+			key := kind + "." + strings.ToLower(strings.TrimSpace(unknownColumn.Column))
+			if _, logged := unknownColumns.LoadOrStore(key, true); !logged {
+				logger.Warn("%s", warning)
+			}
+			continue
+		}
+		logger.Debug("%s", warning)
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package quality
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
+	"github.com/ziondials/go-cdr/webhook"
+)
+
+// VoiceQuality checks the voice quality of calls against the SLAs, or is nil if
+// no SLAs are configured.
+var VoiceQuality *Engine
+
+func InitVoiceQuality() {
+
+	conf := config.GetVoiceQualityFromGlobalConfig()
+	if conf == nil {
+		return
+	}
+
+	engine, err := NewEngine(conf)
+	if err != nil {
+		logger.Fatal("Voice Quality Error: %s", err)
+	}
+
+	VoiceQuality = engine
+}
+
+// NewSummary starts rolling legs up per hour and SLA.
+func NewSummary(from int64, to int64) *Summary {
+	return VoiceQuality.NewSummary(from, to)
+}
+
+// Notify posts alerts to the configured webhook in the background.
+func Notify(alerts []*models.Alert) {
+	VoiceQuality.Notify(alerts)
+}
+
+// Post posts alerts to the configured webhook.
+func Post(alerts []*models.Alert) error {
+	return VoiceQuality.Post(alerts)
+}
+
+// Leg is the voice quality measured for one leg of a call at Time, in unix
+// time. Names are the device, gateway and trunk group names of the leg and
+// Address the IP address of the device or of the remote end. Metrics that were
+// not measured are nil.
+type Leg struct {
+	Source         string
+	Time           int64
+	Names          []*string
+	Address        *string
+	Jitter         *int64
+	Latency        *int64
+	ReceiveDelay   *int64
+	RoundTripDelay *int64
+	LossPercent    *float64
+	Mos            *float64
+}
+
+// LossPercent returns the percentage of packets lost, or nil if no packets
+// were expected.
+func LossPercent(received *int64, lost *int64) *float64 {
+	if received == nil || lost == nil || *received+*lost <= 0 {
+		return nil
+	}
+	percent := 100 * float64(*lost) / float64(*received+*lost)
+	return &percent
+}
+
+// Engine checks the voice quality of calls against the thresholds of SLAs. The
+// methods of a nil Engine return nothing.
+type Engine struct {
+	slas    []*sla
+	webhook string
+}
+
+type sla struct {
+	conf    *config.VoiceQualitySLAConfig
+	subnets []*net.IPNet
+}
+
+// NewEngine creates an Engine from conf.
+func NewEngine(conf *config.VoiceQualityConfig) (*Engine, error) {
+
+	engine := &Engine{webhook: conf.Webhook}
+
+	names := map[string]bool{}
+	for i := range conf.SLAs {
+		s, err := newSLA(&conf.SLAs[i])
+		if err != nil {
+			return nil, fmt.Errorf("sla %s: %w", conf.SLAs[i].Name, err)
+		}
+		if names[s.conf.Name] {
+			return nil, fmt.Errorf("sla %s: name is used more than once", s.conf.Name)
+		}
+		names[s.conf.Name] = true
+		engine.slas = append(engine.slas, s)
+	}
+
+	return engine, nil
+}
+
+func newSLA(conf *config.VoiceQualitySLAConfig) (*sla, error) {
+
+	if conf.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	for _, device := range conf.Devices {
+		if _, err := path.Match(device, ""); err != nil {
+			return nil, fmt.Errorf("device %s: %w", device, err)
+		}
+	}
+
+	s := &sla{conf: conf}
+	for _, subnet := range conf.Subnets {
+		network, err := helpers.ParseIPNetwork(strings.TrimSpace(subnet))
+		if err != nil {
+			return nil, fmt.Errorf("subnet %s: %w", subnet, err)
+		}
+		s.subnets = append(s.subnets, network)
+	}
+
+	return s, nil
+}
+
+// matches reports whether leg is one of the calls of the SLA. CDRs and CMRs do
+// not carry the device pool of a device, so the subnets of an SLA stand in for
+// its device pool or site.
+func (s *sla) matches(leg *Leg) bool {
+	if len(s.conf.Devices) == 0 && len(s.subnets) == 0 {
+		return true
+	}
+	for _, name := range leg.Names {
+		if name == nil || *name == "" {
+			continue
+		}
+		for _, pattern := range s.conf.Devices {
+			if ok, _ := path.Match(pattern, *name); ok {
+				return true
+			}
+		}
+	}
+	if leg.Address == nil {
+		return false
+	}
+	ip := net.ParseIP(*leg.Address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.subnets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type hourKey struct {
+	sla    int
+	source string
+	start  int64
+}
+
+// Summary rolls the legs measured from From to To, in unix time with To
+// excluded, up per hour, SLA and source as they are added, so the legs do not
+// have to be kept.
+type Summary struct {
+	From   int64
+	To     int64
+	engine *Engine
+	hours  map[hourKey]*models.VoiceQualityHour
+}
+
+// NewSummary starts a Summary from from to to.
+func (e *Engine) NewSummary(from int64, to int64) *Summary {
+	return &Summary{From: from, To: to, engine: e, hours: map[hourKey]*models.VoiceQualityHour{}}
+}
+
+// Add counts leg in the hours of the SLAs it matches.
+func (summary *Summary) Add(leg *Leg) {
+	if summary.engine == nil || leg.Time < summary.From || leg.Time >= summary.To {
+		return
+	}
+	for index, s := range summary.engine.slas {
+		if !s.matches(leg) {
+			continue
+		}
+		key := hourKey{index, leg.Source, leg.Time - leg.Time%3600}
+		hour, ok := summary.hours[key]
+		if !ok {
+			hour = newHour(s, key)
+			summary.hours[key] = hour
+		}
+		s.add(hour, leg)
+	}
+}
+
+// Hours returns the rows of the hours that had calls and an alert for every
+// hour that broke its SLA.
+func (summary *Summary) Hours() ([]*models.VoiceQualityHour, []*models.Alert) {
+	if summary.engine == nil {
+		return nil, nil
+	}
+
+	keys := make([]hourKey, 0, len(summary.hours))
+	for key := range summary.hours {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].sla != keys[j].sla {
+			return keys[i].sla < keys[j].sla
+		}
+		if keys[i].source != keys[j].source {
+			return keys[i].source < keys[j].source
+		}
+		return keys[i].start < keys[j].start
+	})
+
+	rows := make([]*models.VoiceQualityHour, 0, len(keys))
+	alerts := []*models.Alert{}
+	for _, key := range keys {
+		hour := summary.hours[key]
+		s := summary.engine.slas[key.sla]
+		hour.Violated = s.violated(hour)
+		if hour.Violated {
+			alerts = append(alerts, s.newAlert(hour))
+		}
+		rows = append(rows, hour)
+	}
+	return rows, alerts
+}
+
+func newHour(s *sla, key hourKey) *models.VoiceQualityHour {
+	var calls, poor, jitter, latency, loss, mos int64
+	source, start := key.source, key.start
+	return &models.VoiceQualityHour{
+		ID:                uuid.New().String(),
+		SLA:               &s.conf.Name,
+		Source:            &source,
+		IntervalStart:     &start,
+		Calls:             &calls,
+		PoorCalls:         &poor,
+		JitterViolations:  &jitter,
+		LatencyViolations: &latency,
+		LossViolations:    &loss,
+		MosViolations:     &mos,
+	}
+}
+
+// add counts leg in hour.
+func (s *sla) add(hour *models.VoiceQualityHour, leg *Leg) {
+
+	jitter := firstInt(leg.Jitter, leg.ReceiveDelay)
+	latency := firstInt(leg.Latency, leg.RoundTripDelay)
+	if jitter == nil && latency == nil && leg.LossPercent == nil && leg.Mos == nil {
+		return
+	}
+	*hour.Calls++
+
+	poor := false
+	if over(leg.Jitter, s.conf.MaxJitter) || over(leg.ReceiveDelay, s.conf.MaxReceiveDelay) {
+		*hour.JitterViolations++
+		poor = true
+	}
+	if over(leg.Latency, s.conf.MaxLatency) || over(leg.RoundTripDelay, s.conf.MaxRoundTripDelay) {
+		*hour.LatencyViolations++
+		poor = true
+	}
+	if s.conf.MaxLossPercent > 0 && leg.LossPercent != nil && *leg.LossPercent > s.conf.MaxLossPercent {
+		*hour.LossViolations++
+		poor = true
+	}
+	// A MOS of 0 was not measured.
+	if s.conf.MinMos > 0 && leg.Mos != nil && *leg.Mos > 0 && *leg.Mos < s.conf.MinMos {
+		*hour.MosViolations++
+		poor = true
+	}
+	if poor {
+		*hour.PoorCalls++
+	}
+
+	if jitter != nil && (hour.MaxJitter == nil || *jitter > *hour.MaxJitter) {
+		hour.MaxJitter = jitter
+	}
+	if latency != nil && (hour.MaxLatency == nil || *latency > *hour.MaxLatency) {
+		hour.MaxLatency = latency
+	}
+	if leg.LossPercent != nil && (hour.MaxLossPercent == nil || *leg.LossPercent > *hour.MaxLossPercent) {
+		hour.MaxLossPercent = leg.LossPercent
+	}
+	if leg.Mos != nil && *leg.Mos > 0 && (hour.WorstMos == nil || *leg.Mos < *hour.WorstMos) {
+		hour.WorstMos = leg.Mos
+	}
+}
+
+// violated reports whether hour breaks the SLA.
+func (s *sla) violated(hour *models.VoiceQualityHour) bool {
+	if *hour.PoorCalls == 0 || *hour.PoorCalls < s.conf.MinCalls {
+		return false
+	}
+	return float64(*hour.PoorCalls)*100 > s.conf.MaxPoorPercent*float64(*hour.Calls)
+}
+
+func (s *sla) newAlert(hour *models.VoiceQualityHour) *models.Alert {
+
+	start := time.Unix(*hour.IntervalStart, 0).UTC()
+	key := start.Format(time.RFC3339)
+	createdAt := time.Now().Unix()
+	severity := s.conf.Severity
+	if severity == "" {
+		severity = "warning"
+	}
+
+	message := fmt.Sprintf("%d of %d calls of %s were poor in the hour from %s: %d over jitter, %d over latency, %d over loss, %d under MOS",
+		*hour.PoorCalls, *hour.Calls, s.conf.Name, start.Format("2006-01-02 15:04 MST"),
+		*hour.JitterViolations, *hour.LatencyViolations, *hour.LossViolations, *hour.MosViolations)
+
+	return &models.Alert{
+		ID:        uuid.New().String(),
+		Rule:      &s.conf.Name,
+		Source:    hour.Source,
+		CallKey:   &key,
+		Type:      &models.AlertTypeVoiceQuality,
+		Severity:  &severity,
+		CallTime:  hour.IntervalStart,
+		Message:   &message,
+		CreatedAt: &createdAt,
+	}
+}
+
+// Notify posts alerts to the webhook in the background. It should be called
+// once the alerts are committed, and does nothing if there is no webhook.
+func (e *Engine) Notify(alerts []*models.Alert) {
+	if e == nil {
+		return
+	}
+	webhook.Notify(e.webhook, alerts)
+}
+
+// Post posts alerts to the webhook and waits for the response. It does nothing
+// if there is no webhook.
+func (e *Engine) Post(alerts []*models.Alert) error {
+	if e == nil || e.webhook == "" || len(alerts) == 0 {
+		return nil
+	}
+	return webhook.Post(e.webhook, alerts)
+}
+
+func over(value *int64, threshold int64) bool {
+	return threshold > 0 && value != nil && *value > threshold
+}
+
+func firstInt(values ...*int64) *int64 {
+	for _, value := range values {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
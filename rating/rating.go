@@ -0,0 +1,235 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package rating
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/dialplan"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
+)
+
+// RatingEngine rates the calls that are parsed, or is nil if no tariffs are
+// configured.
+var RatingEngine *Engine
+
+func InitRating() {
+
+	conf := config.GetRatingFromGlobalConfig()
+	if conf == nil {
+		return
+	}
+
+	engine, err := NewEngine(conf)
+	if err != nil {
+		logger.Fatal("Rating Error: %s", err)
+	}
+
+	RatingEngine = engine
+}
+
+// RateCucmCdrs rates cdrs with the configured tariffs.
+func RateCucmCdrs(cdrs []*models.CucmCdr) []*models.CallCharge {
+	return RatingEngine.RateCucmCdrs(cdrs)
+}
+
+// RateCubeCalls rates calls with the configured tariffs.
+func RateCubeCalls(calls []*models.CubeCall) []*models.CallCharge {
+	return RatingEngine.RateCubeCalls(calls)
+}
+
+// Engine rates outbound calls by the E.164 form of their called number. The
+// methods of a nil Engine return no charges.
+type Engine struct {
+	currency *string
+	location *time.Location
+	tariffs  []*tariff
+}
+
+type tariff struct {
+	conf *config.TariffConfig
+	band *helpers.TimeBand
+}
+
+// NewEngine creates an Engine from conf.
+func NewEngine(conf *config.RatingConfig) (*Engine, error) {
+
+	location := time.Local
+	if conf.Timezone != "" {
+		var err error
+		location, err = time.LoadLocation(conf.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("timezone: %w", err)
+		}
+	}
+
+	engine := &Engine{location: location}
+	if conf.Currency != "" {
+		engine.currency = &conf.Currency
+	}
+
+	for i := range conf.Tariffs {
+		t, err := newTariff(&conf.Tariffs[i])
+		if err != nil {
+			return nil, fmt.Errorf("tariff %s: %w", conf.Tariffs[i].Name, err)
+		}
+		engine.tariffs = append(engine.tariffs, t)
+	}
+
+	return engine, nil
+}
+
+func newTariff(conf *config.TariffConfig) (*tariff, error) {
+
+	if conf.Increment < 0 || conf.InitialIncrement < 0 {
+		return nil, fmt.Errorf("increments must not be negative")
+	}
+
+	band, err := helpers.ParseTimeBand(conf.Days, conf.From, conf.To)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tariff{conf: conf, band: band}, nil
+}
+
+// matches reports whether the tariff applies to a call to e164 at start.
+func (t *tariff) matches(e164 string, start time.Time) bool {
+	return strings.HasPrefix(e164, t.conf.Prefix) && t.band.Contains(start)
+}
+
+// billedSeconds rounds duration up to the increments of the tariff.
+func (t *tariff) billedSeconds(duration int64) int64 {
+	initial, increment := t.conf.InitialIncrement, t.conf.Increment
+	if increment == 0 {
+		increment = 1
+	}
+	if duration <= initial {
+		return initial
+	}
+	steps := (duration - initial + increment - 1) / increment
+	return initial + steps*increment
+}
+
+// rate returns the charge of a call, or nil if the call is not outbound or no
+// tariff matches it.
+func (e *Engine) rate(called *string, direction *string, duration *int64, start *int64) *models.CallCharge {
+
+	if called == nil || duration == nil || *duration <= 0 || start == nil {
+		return nil
+	}
+	if direction == nil || *direction == dialplan.CallDirectionInternal || *direction == dialplan.CallDirectionInbound {
+		return nil
+	}
+
+	startTime := time.Unix(*start, 0).In(e.location)
+
+	var best *tariff
+	for _, t := range e.tariffs {
+		if t.matches(*called, startTime) && (best == nil || len(t.conf.Prefix) > len(best.conf.Prefix)) {
+			best = t
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	billed := best.billedSeconds(*duration)
+	charge := float64(billed) / 60 * best.conf.RatePerMinute
+	if charge < best.conf.MinimumCharge {
+		charge = best.conf.MinimumCharge
+	}
+	charge = math.Round(charge*10000) / 10000
+
+	ratedAt := time.Now().Unix()
+	rate := best.conf.RatePerMinute
+
+	return &models.CallCharge{
+		ID:              uuid.New().String(),
+		CalledNumber:    called,
+		CallDirection:   direction,
+		DurationSeconds: duration,
+		BilledSeconds:   &billed,
+		Tariff:          &best.conf.Name,
+		Prefix:          &best.conf.Prefix,
+		Band:            &best.conf.Band,
+		RatePerMinute:   &rate,
+		Charge:          &charge,
+		Currency:        e.currency,
+		RatedAt:         &ratedAt,
+	}
+}
+
+// RateCucmCdrs rates the CDRs in cdrs that are outbound and have a tariff.
+func (e *Engine) RateCucmCdrs(cdrs []*models.CucmCdr) []*models.CallCharge {
+	if e == nil {
+		return nil
+	}
+
+	charges := []*models.CallCharge{}
+	for _, cdr := range cdrs {
+		start := cdr.Datetimeconnect
+		if start == nil || *start == 0 {
+			start = cdr.Datetimeorigination
+		}
+		charge := e.rate(cdr.Finalcalledpartynumber_E164, cdr.CallDirection, cdr.Duration, start)
+		key := models.CucmCdrCallKey(cdr)
+		if charge == nil || key == nil {
+			continue
+		}
+		charge.Source = &models.CallChargeSourceCucm
+		charge.CallKey = key
+		charge.StartTime = cdr.Datetimeorigination
+		charge.CallingNumber = cdr.Callingpartynumber
+		charge.DeviceName = cdr.Origdevicename
+		charges = append(charges, charge)
+	}
+	return charges
+}
+
+// RateCubeCalls rates the calls in calls that are outbound and have a tariff.
+func (e *Engine) RateCubeCalls(calls []*models.CubeCall) []*models.CallCharge {
+	if e == nil {
+		return nil
+	}
+
+	charges := []*models.CallCharge{}
+	for _, call := range calls {
+		start := call.ConnectTime
+		if start == nil {
+			start = call.SetupTime
+		}
+		charge := e.rate(call.DialedNumberE164, call.CallDirection, call.DurationSeconds, start)
+		key := models.CubeCallCallKey(call)
+		if charge == nil || key == nil {
+			continue
+		}
+		charge.Source = &models.CallChargeSourceCube
+		charge.CallKey = key
+		charge.StartTime = call.SetupTime
+		charge.CallingNumber = call.CallingNumber
+		charge.DeviceName = call.Hostname
+		charges = append(charges, charge)
+	}
+	return charges
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package receiver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/parser"
+)
+
+// cmsMaxBodySize caps the size of a posted batch of records.
+const cmsMaxBodySize = 32 << 20
+
+// CMSServer is the HTTP CDR receiver for Cisco Meeting Server. Every posted
+// batch of records is merged into the cms_calls and cms_call_legs tables in a
+// single transaction. A batch is only acknowledged once it has been written, so
+// the server posts it again if the database is unavailable.
+type CMSServer struct {
+	db      *database.DataService
+	path    string
+	clients []*net.IPNet
+}
+
+// RunCMSServer runs the CMS CDR receiver from the global config until it
+// fails.
+func RunCMSServer() {
+
+	db := database.Connect()
+
+	cmsConfig := config.GetCMSFromGlobalConfig()
+
+	server, err := NewCMSServer(cmsConfig, db)
+	if err != nil {
+		logger.Fatal("CMS Server Error: %s", err)
+	}
+
+	httpServer := &http.Server{
+		Addr:              cmsConfig.Listen,
+		Handler:           server,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	logger.Info("CMS CDR receiver listening on %s%s", cmsConfig.Listen, cmsConfig.Path)
+
+	if cmsConfig.CertFile != "" || cmsConfig.KeyFile != "" {
+		err = httpServer.ListenAndServeTLS(cmsConfig.CertFile, cmsConfig.KeyFile)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Fatal("CMS Server Error: %s", err)
+	}
+}
+
+// NewCMSServer creates a CMS CDR receiver.
+func NewCMSServer(cmsConfig *config.CMSConfig, db *database.DataService) (*CMSServer, error) {
+
+	server := &CMSServer{db: db, path: cmsConfig.Path}
+	if server.path == "" {
+		server.path = "/"
+	}
+
+	for _, client := range cmsConfig.Clients {
+		network, err := helpers.ParseIPNetwork(client)
+		if err != nil {
+			return nil, fmt.Errorf("cms client: %w", err)
+		}
+		server.clients = append(server.clients, network)
+	}
+
+	return server, nil
+}
+
+func (s *CMSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != s.path {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.isAllowed(r.RemoteAddr) {
+		logger.Error("CMS records from unknown client %s", r.RemoteAddr)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	source := "CMS records from " + r.RemoteAddr
+
+	records, rejects, err := parser.ParseCmsRecords(http.MaxBytesReader(w, r.Body, cmsMaxBodySize), source)
+	if err != nil {
+		logger.Error("Error parsing %s Error: %s", source, err)
+		http.Error(w, "invalid records", http.StatusBadRequest)
+		return
+	}
+
+	err = s.db.Transaction(func(tx database.DataService) error {
+		return tx.SaveCmsRecords(records.Calls, records.CallLegs)
+	})
+	if err != nil {
+		logger.Error("Error while writing to database: %s", err.Error())
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	count := len(records.Calls) + len(records.CallLegs)
+	logger.Debug("Successfully wrote %s records to database from %s, %s rejected", strconv.Itoa(count), source, strconv.Itoa(len(rejects)))
+	w.WriteHeader(http.StatusOK)
+}
+
+// isAllowed reports whether remoteAddr may post records.
+func (s *CMSServer) isAllowed(remoteAddr string) bool {
+	if len(s.clients) == 0 {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	for _, client := range s.clients {
+		if ip != nil && client.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package receiver
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
+	"layeh.com/radius"
+	"layeh.com/radius/rfc2865"
+	"layeh.com/radius/rfc2866"
+	"layeh.com/radius/rfc2869"
+)
+
+// ciscoVendorID is the vendor ID of Cisco vendor-specific attributes.
+const ciscoVendorID = 9
+
+// ciscoAccountingFields maps the names of the Cisco VSAs sent with
+// callhistory-detail accounting to the matching column of a gw-accounting
+// file. Every Cisco VSA, cisco-avpair and the h323-* attributes alike, has a
+// value of the form name=value.
+var ciscoAccountingFields = map[string]func(raw *models.RawCubeCDR) **string{
+	"h323-conf-id":               func(raw *models.RawCubeCDR) **string { return &raw.H323ConfId },
+	"h323-setup-time":            func(raw *models.RawCubeCDR) **string { return &raw.H323SetupTime },
+	"h323-connect-time":          func(raw *models.RawCubeCDR) **string { return &raw.H323ConnectTime },
+	"h323-disconnect-time":       func(raw *models.RawCubeCDR) **string { return &raw.H323DisconnectTime },
+	"h323-disconnect-cause":      func(raw *models.RawCubeCDR) **string { return &raw.H323DisconnectCause },
+	"h323-call-origin":           func(raw *models.RawCubeCDR) **string { return &raw.H323CallOrigin },
+	"h323-voice-quality":         func(raw *models.RawCubeCDR) **string { return &raw.H323VoiceQuality },
+	"h323-ivr-out":               func(raw *models.RawCubeCDR) **string { return &raw.H323IvrOut },
+	"peer-address":               func(raw *models.RawCubeCDR) **string { return &raw.PeerAddress },
+	"peer-sub-address":           func(raw *models.RawCubeCDR) **string { return &raw.PeerSubAddress },
+	"alert-time":                 func(raw *models.RawCubeCDR) **string { return &raw.AlertTime },
+	"disconnect-text":            func(raw *models.RawCubeCDR) **string { return &raw.DisconnectText },
+	"charged-units":              func(raw *models.RawCubeCDR) **string { return &raw.ChargedUnits },
+	"info-type":                  func(raw *models.RawCubeCDR) **string { return &raw.InfoType },
+	"gtd-orig-cic":               func(raw *models.RawCubeCDR) **string { return &raw.GtdOrigCic },
+	"gtd-term-cic":               func(raw *models.RawCubeCDR) **string { return &raw.GtdTermCic },
+	"tx-duration":                func(raw *models.RawCubeCDR) **string { return &raw.TxDuration },
+	"peer-id":                    func(raw *models.RawCubeCDR) **string { return &raw.PeerId },
+	"peer-if-index":              func(raw *models.RawCubeCDR) **string { return &raw.PeerIfIndex },
+	"logical-if-index":           func(raw *models.RawCubeCDR) **string { return &raw.LogicalIfIndex },
+	"acom-level":                 func(raw *models.RawCubeCDR) **string { return &raw.AcomLevel },
+	"noise-level":                func(raw *models.RawCubeCDR) **string { return &raw.NoiseLevel },
+	"voice-tx-duration":          func(raw *models.RawCubeCDR) **string { return &raw.VoiceTxDuration },
+	"account-code":               func(raw *models.RawCubeCDR) **string { return &raw.AccountCode },
+	"codec-bytes":                func(raw *models.RawCubeCDR) **string { return &raw.CodecBytes },
+	"codec-type-rate":            func(raw *models.RawCubeCDR) **string { return &raw.CodecTypeRate },
+	"ontime-rv-playout":          func(raw *models.RawCubeCDR) **string { return &raw.OntimeRvPlayout },
+	"remote-udp-port":            func(raw *models.RawCubeCDR) **string { return &raw.RemoteUdpPort },
+	"remote-media-udp-port":      func(raw *models.RawCubeCDR) **string { return &raw.RemoteMediaUdpPort },
+	"vad-enable":                 func(raw *models.RawCubeCDR) **string { return &raw.VadEnable },
+	"receive-delay":              func(raw *models.RawCubeCDR) **string { return &raw.ReceiveDelay },
+	"round-trip-delay":           func(raw *models.RawCubeCDR) **string { return &raw.RoundTripDelay },
+	"hiwater-playout-delay":      func(raw *models.RawCubeCDR) **string { return &raw.HiwaterPlayoutDelay },
+	"lowater-playout-delay":      func(raw *models.RawCubeCDR) **string { return &raw.LowaterPlayoutDelay },
+	"gapfill-with-interpolation": func(raw *models.RawCubeCDR) **string { return &raw.GapfillWithInterpolation },
+	"gapfill-with-redundancy":    func(raw *models.RawCubeCDR) **string { return &raw.GapfillWithRedundancy },
+	"gapfill-with-silence":       func(raw *models.RawCubeCDR) **string { return &raw.GapfillWithSilence },
+	"gapfill-with-prediction":    func(raw *models.RawCubeCDR) **string { return &raw.GapfillWithPrediction },
+	"early-packets":              func(raw *models.RawCubeCDR) **string { return &raw.EarlyPackets },
+	"late-packets":               func(raw *models.RawCubeCDR) **string { return &raw.LatePackets },
+	"lost-packets":               func(raw *models.RawCubeCDR) **string { return &raw.LostPackets },
+	"max-bitrate":                func(raw *models.RawCubeCDR) **string { return &raw.MaxBitrate },
+	"faxrelay-start-time":        func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayStartTime },
+	"faxrelay-stop-time":         func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayStopTime },
+	"faxrelay-max-jit-buf-depth": func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayMaxJitBufDepth },
+	"faxrelay-jit-buf-ovflow":    func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayJitBufOvflow },
+	"faxrelay-init-hs-mod":       func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayInitHsMod },
+	"faxrelay-mr-hs-mod":         func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayMrHsMod },
+	"faxrelay-num-pages":         func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayNumPages },
+	"faxrelay-tx-packets":        func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayTxPackets },
+	"faxrelay-rx-packets":        func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayRxPackets },
+	"faxrelay-direction":         func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayDirection },
+	"faxrelay-pkt-conceal":       func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayPktConceal },
+	"faxrelay-ecm-status":        func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayEcmStatus },
+	"faxrelay-encap-protocol":    func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayEncapProtocol },
+	"faxrelay-nsf-country-code":  func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayNsfCountryCode },
+	"faxrelay-nsf-manuf-code":    func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayNsfManufCode },
+	"faxrelay-fax-success":       func(raw *models.RawCubeCDR) **string { return &raw.FaxrelayFaxSuccess },
+	"override-session-time":      func(raw *models.RawCubeCDR) **string { return &raw.OverrideSessionTime },
+	"internal-error-code":        func(raw *models.RawCubeCDR) **string { return &raw.InternalErrorCode },
+	"remote-media-address":       func(raw *models.RawCubeCDR) **string { return &raw.RemoteMediaAddress },
+	"remote-media-id":            func(raw *models.RawCubeCDR) **string { return &raw.RemoteMediaId },
+	"carrier-id":                 func(raw *models.RawCubeCDR) **string { return &raw.CarrierId },
+	"calling-party-category":     func(raw *models.RawCubeCDR) **string { return &raw.CallingPartyCategory },
+	"originating-line-info":      func(raw *models.RawCubeCDR) **string { return &raw.OriginatingLineInfo },
+	"charge-number":              func(raw *models.RawCubeCDR) **string { return &raw.ChargeNumber },
+	"transmission-medium-req":    func(raw *models.RawCubeCDR) **string { return &raw.TransmissionMediumReq },
+	"service-descriptor":         func(raw *models.RawCubeCDR) **string { return &raw.ServiceDescriptor },
+	"outgoing-area":              func(raw *models.RawCubeCDR) **string { return &raw.OutgoingArea },
+	"incoming-area":              func(raw *models.RawCubeCDR) **string { return &raw.IncomingArea },
+	"out-trunkgroup-label":       func(raw *models.RawCubeCDR) **string { return &raw.OutTrunkgroupLabel },
+	"out-carrier-id":             func(raw *models.RawCubeCDR) **string { return &raw.OutCarrierId },
+	"dsp-id":                     func(raw *models.RawCubeCDR) **string { return &raw.DspId },
+	"in-trunkgroup-label":        func(raw *models.RawCubeCDR) **string { return &raw.InTrunkgroupLabel },
+	"in-carrier-id":              func(raw *models.RawCubeCDR) **string { return &raw.InCarrierId },
+	"cust-biz-grp-id":            func(raw *models.RawCubeCDR) **string { return &raw.CustBizGrpId },
+	"supp-svc-xfer-by":           func(raw *models.RawCubeCDR) **string { return &raw.SuppSvcXferBy },
+	"voice-feature":              func(raw *models.RawCubeCDR) **string { return &raw.VoiceFeature },
+	"feature-operation":          func(raw *models.RawCubeCDR) **string { return &raw.FeatureOperation },
+	"feature-op-status":          func(raw *models.RawCubeCDR) **string { return &raw.FeatureOpStatus },
+	"feature-op-time":            func(raw *models.RawCubeCDR) **string { return &raw.FeatureOpTime },
+	"feature-id":                 func(raw *models.RawCubeCDR) **string { return &raw.FeatureId },
+	"gw-rxd-cdn":                 func(raw *models.RawCubeCDR) **string { return &raw.GwRxdCdn },
+	"gw-rxd-cgn":                 func(raw *models.RawCubeCDR) **string { return &raw.GwRxdCgn },
+	"gtd-gw-rxd-ocn":             func(raw *models.RawCubeCDR) **string { return &raw.GtdGwRxdOcn },
+	"gtd-gw-rxd-cnn":             func(raw *models.RawCubeCDR) **string { return &raw.GtdGwRxdCnn },
+	"gw-rxd-rdn":                 func(raw *models.RawCubeCDR) **string { return &raw.GwRxdRdn },
+	"gw-final-xlated-cdn":        func(raw *models.RawCubeCDR) **string { return &raw.GwFinalXlatedCdn },
+	"gw-final-xlated-cgn":        func(raw *models.RawCubeCDR) **string { return &raw.GwFinalXlatedCgn },
+	"gw-final-xlated-rdn":        func(raw *models.RawCubeCDR) **string { return &raw.GwFinalXlatedRdn },
+	"gk-xlated-cdn":              func(raw *models.RawCubeCDR) **string { return &raw.GkXlatedCdn },
+	"gk-xlated-cgn":              func(raw *models.RawCubeCDR) **string { return &raw.GkXlatedCgn },
+	"gw-collected-cdn":           func(raw *models.RawCubeCDR) **string { return &raw.GwCollectedCdn },
+	"ip-hop":                     func(raw *models.RawCubeCDR) **string { return &raw.IPHop },
+	"redirected-station":         func(raw *models.RawCubeCDR) **string { return &raw.RedirectedStation },
+	"subscriber":                 func(raw *models.RawCubeCDR) **string { return &raw.Subscriber },
+	"in-intrfc-desc":             func(raw *models.RawCubeCDR) **string { return &raw.InIntrfcDesc },
+	"out-intrfc-desc":            func(raw *models.RawCubeCDR) **string { return &raw.OutIntrfcDesc },
+	"session-protocol":           func(raw *models.RawCubeCDR) **string { return &raw.SessionProtocol },
+	"local-hostname":             func(raw *models.RawCubeCDR) **string { return &raw.LocalHostname },
+	"backward-call-id":           func(raw *models.RawCubeCDR) **string { return &raw.BackwardCallId },
+	"ip-phone-info":              func(raw *models.RawCubeCDR) **string { return &raw.IpPhoneInfo },
+	"ipPbxMode":                  func(raw *models.RawCubeCDR) **string { return &raw.IpPbxMode },
+	"in-lpcor-group":             func(raw *models.RawCubeCDR) **string { return &raw.InLpcorGroup },
+	"out-lpcor-group":            func(raw *models.RawCubeCDR) **string { return &raw.OutLpcorGroup },
+	"fac-digit":                  func(raw *models.RawCubeCDR) **string { return &raw.FacDigit },
+	"fac-status":                 func(raw *models.RawCubeCDR) **string { return &raw.FacStatus },
+}
+
+// cubeLegTypes maps the values of h323-call-type to the leg-type column of a
+// gw-accounting file.
+var cubeLegTypes = map[string]int{
+	"Telephony":  models.CubeLegTypeTelephony,
+	"VoIP":       models.CubeLegTypeVoIP,
+	"MMOIP":      models.CubeLegTypeMMOIP,
+	"FrameRelay": models.CubeLegTypeFrameRelay,
+	"ATM":        models.CubeLegTypeATM,
+}
+
+// decodeCubeAccounting builds the gw-accounting file row that matches a
+// callhistory-detail accounting request.
+func decodeCubeAccounting(packet *radius.Packet, remoteAddr net.Addr) *models.RawCubeCDR {
+	raw := &models.RawCubeCDR{}
+
+	setString(&raw.CdrType, strconv.Itoa(int(rfc2866.AcctStatusType_Get(packet))))
+
+	// Acct-Session-Id is the call-id of the leg in hexadecimal.
+	if sessionID := rfc2866.AcctSessionID_GetString(packet); sessionID != "" {
+		if callID, err := strconv.ParseUint(sessionID, 16, 64); err == nil {
+			setString(&raw.CallId, strconv.FormatUint(callID, 10))
+		} else {
+			setString(&raw.CallId, sessionID)
+		}
+	}
+
+	recordTime := rfc2869.EventTimestamp_Get(packet)
+	if recordTime.IsZero() {
+		delay := time.Duration(rfc2866.AcctDelayTime_Get(packet)) * time.Second
+		recordTime = time.Now().Add(-delay)
+	}
+	setString(&raw.RecordTimestamp, strconv.FormatInt(recordTime.Unix(), 10))
+
+	setInteger(&raw.PaksIn, packet, rfc2866.AcctInputPackets_Type)
+	setInteger(&raw.PaksOut, packet, rfc2866.AcctOutputPackets_Type)
+	setInteger(&raw.BytesIn, packet, rfc2866.AcctInputOctets_Type)
+	setInteger(&raw.BytesOut, packet, rfc2866.AcctOutputOctets_Type)
+
+	setString(&raw.Username, rfc2865.UserName_GetString(packet))
+	setString(&raw.Clid, rfc2865.CallingStationID_GetString(packet))
+	setString(&raw.Dnis, rfc2865.CalledStationID_GetString(packet))
+
+	// The hostname is taken from h323-gw-id below, if the gateway sends it.
+	if nasIdentifier := rfc2865.NASIdentifier_GetString(packet); nasIdentifier != "" {
+		setString(&raw.Hostname, nasIdentifier)
+	} else if udpAddr, ok := remoteAddr.(*net.UDPAddr); ok {
+		setString(&raw.Hostname, udpAddr.IP.String())
+	}
+
+	for _, avp := range packet.Attributes {
+		if avp.Type != rfc2865.VendorSpecific_Type {
+			continue
+		}
+		vendorID, vsa, err := radius.VendorSpecific(avp.Attribute)
+		if err != nil || vendorID != ciscoVendorID {
+			continue
+		}
+		for len(vsa) >= 2 && int(vsa[1]) >= 2 && int(vsa[1]) <= len(vsa) {
+			decodeCiscoAttribute(raw, string(vsa[2:vsa[1]]))
+			vsa = vsa[vsa[1]:]
+		}
+	}
+
+	return raw
+}
+
+func decodeCiscoAttribute(raw *models.RawCubeCDR, attribute string) {
+	name, value, ok := strings.Cut(attribute, "=")
+	if !ok {
+		return
+	}
+
+	switch name {
+	case "h323-gw-id":
+		// The gateway ID is the hostname followed by a dot.
+		setString(&raw.Hostname, strings.TrimSuffix(value, "."))
+	case "h323-call-type":
+		if legType, ok := cubeLegTypes[value]; ok {
+			setString(&raw.LegType, strconv.Itoa(legType))
+		} else {
+			setString(&raw.LegType, value)
+		}
+	case "feature-vsa":
+		decodeFeatureVSA(raw, value)
+	default:
+		field, ok := ciscoAccountingFields[name]
+		if !ok {
+			logger.Debug("Ignoring Cisco VSA: %s", name)
+			return
+		}
+		setString(field(raw), value)
+	}
+}
+
+// decodeFeatureVSA splits a feature-vsa such as fn:TWC,ft:...,frs:0 into the
+// feature-id columns of a gw-accounting file, which hold the values in the
+// order the gateway sends them. A request can carry a feature-vsa for every
+// feature invoked on the leg, and only the first fills the columns.
+func decodeFeatureVSA(raw *models.RawCubeCDR, value string) {
+	var featureVSA models.RawCubeFeatureVSA
+
+	for i, pair := range strings.Split(value, ",") {
+		if i >= len(featureVSA) {
+			break
+		}
+		_, fieldValue, ok := strings.Cut(pair, ":")
+		if !ok {
+			fieldValue = pair
+		}
+		setString(&featureVSA[i], fieldValue)
+	}
+
+	if raw.FeatureIdField1 != nil {
+		raw.AdditionalFeatureVSAs = append(raw.AdditionalFeatureVSAs, featureVSA)
+		return
+	}
+
+	fields := []**string{
+		&raw.FeatureIdField1, &raw.FeatureIdField2, &raw.FeatureIdField3, &raw.FeatureIdField4,
+		&raw.FeatureIdField5, &raw.FeatureIdField6, &raw.FeatureIdField7, &raw.FeatureIdField8,
+		&raw.FeatureIdField9, &raw.FeatureIdField10, &raw.FeatureIdField11, &raw.FeatureIdField12,
+	}
+	for i, field := range fields {
+		*field = featureVSA[i]
+	}
+}
+
+func setString(field **string, value string) {
+	if value == "" {
+		return
+	}
+	*field = &value
+}
+
+// setInteger sets field to the value of an integer attribute, if the packet
+// carries it.
+func setInteger(field **string, packet *radius.Packet, attributeType radius.Type) {
+	attribute, ok := packet.Lookup(attributeType)
+	if !ok {
+		return
+	}
+	value, err := radius.Integer(attribute)
+	if err != nil {
+		return
+	}
+	setString(field, strconv.FormatUint(uint64(value), 10))
+}
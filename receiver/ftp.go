@@ -0,0 +1,557 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package receiver
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+)
+
+const (
+	// ftpIdleTimeout closes control and data connections that are idle for
+	// longer than this.
+	ftpIdleTimeout = 5 * time.Minute
+	// ftpDataTimeout is how long to wait for a data connection to be opened.
+	ftpDataTimeout = 30 * time.Second
+)
+
+// FTPServer accepts gw-accounting CDR uploads from CUBE gateways. Every user
+// writes into the input directory of one cube DirectoryConfig, and each file is
+// parsed as soon as its upload completes.
+//
+// Only what a gateway needs to upload a file is implemented: logging in,
+// passive and active data connections, STOR and ABOR.
+type FTPServer struct {
+	publicIP   net.IP
+	passiveMin int
+	passiveMax int
+	users      map[string]*ftpUser
+	// parse hands a file that has been uploaded completely to the parser.
+	parse func(inputFile string, directory config.DirectoryConfig)
+}
+
+type ftpUser struct {
+	config    config.FTPUserConfig
+	directory config.DirectoryConfig
+}
+
+// RunFTPServer runs the FTP server from the global config until it fails.
+func RunFTPServer() {
+
+	db := database.Connect()
+
+	ftpConfig := config.GetFTPFromGlobalConfig()
+
+	server, err := NewFTPServer(ftpConfig, config.GetDirectoriesFromGlobalConfig(), db)
+	if err != nil {
+		logger.Fatal("FTP Server Error: %s", err)
+	}
+
+	listener, err := net.Listen("tcp", ftpConfig.Listen)
+	if err != nil {
+		logger.Fatal("FTP Server Error: %s", err)
+	}
+	logger.Info("FTP server listening on %s", listener.Addr())
+
+	if err := server.Serve(listener); err != nil {
+		logger.Fatal("FTP Server Error: %s", err)
+	}
+}
+
+// NewFTPServer creates an FTP server for the configured users.
+func NewFTPServer(ftpConfig *config.FTPConfig, directories []config.DirectoryConfig, db *database.DataService) (*FTPServer, error) {
+
+	if len(ftpConfig.Users) == 0 {
+		return nil, errors.New("no ftp users configured")
+	}
+
+	server := &FTPServer{
+		users: map[string]*ftpUser{},
+		parse: func(inputFile string, directory config.DirectoryConfig) {
+			parseReceivedFile(inputFile, directory, db)
+		},
+	}
+
+	for _, userConfig := range ftpConfig.Users {
+		if userConfig.Password == "" {
+			return nil, fmt.Errorf("ftp user %s has no password", userConfig.Username)
+		}
+
+		directory, err := findDirectory(directories, userConfig.Input, "cube")
+		if err != nil {
+			return nil, fmt.Errorf("ftp user %s: %w", userConfig.Username, err)
+		}
+
+		server.users[userConfig.Username] = &ftpUser{config: userConfig, directory: directory}
+	}
+
+	if ftpConfig.PublicHost != "" {
+		server.publicIP = net.ParseIP(ftpConfig.PublicHost).To4()
+		if server.publicIP == nil {
+			return nil, fmt.Errorf("ftp publicHost %s is not an IPv4 address", ftpConfig.PublicHost)
+		}
+	}
+
+	if ftpConfig.PassivePorts != "" {
+		low, high, ok := strings.Cut(ftpConfig.PassivePorts, "-")
+		var minErr, maxErr error
+		server.passiveMin, minErr = strconv.Atoi(strings.TrimSpace(low))
+		server.passiveMax, maxErr = strconv.Atoi(strings.TrimSpace(high))
+		if !ok || minErr != nil || maxErr != nil || server.passiveMin < 1 || server.passiveMax > 65535 || server.passiveMin > server.passiveMax {
+			return nil, fmt.Errorf("ftp passivePorts %s is not a port range such as 30000-30100", ftpConfig.PassivePorts)
+		}
+	}
+
+	return server, nil
+}
+
+// Serve accepts connections on listener until it is closed.
+func (s *FTPServer) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *FTPServer) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	session := &ftpSession{server: s, conn: conn, text: textproto.NewConn(conn)}
+	defer session.closePassive()
+
+	session.reply(220, "go-cdr FTP server ready")
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(ftpIdleTimeout))
+		line, err := session.text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, argument, _ := strings.Cut(line, " ")
+		if !session.handle(strings.ToUpper(command), argument) {
+			return
+		}
+	}
+}
+
+// ftpSession is the state of a single control connection.
+type ftpSession struct {
+	server   *FTPServer
+	conn     net.Conn
+	text     *textproto.Conn
+	username string
+	user     *ftpUser
+
+	// The data connection for the next transfer, set up by PASV or EPSV on
+	// the server side or by PORT or EPRT on the client side.
+	passive    *net.TCPListener
+	activeAddr string
+}
+
+func (s *ftpSession) reply(code int, message string) {
+	s.conn.SetWriteDeadline(time.Now().Add(ftpIdleTimeout))
+	s.text.PrintfLine("%d %s", code, message)
+}
+
+// handle runs a single command and reports whether the connection should be
+// kept open.
+func (s *ftpSession) handle(command string, argument string) bool {
+	switch command {
+	case "USER":
+		s.username = argument
+		s.user = nil
+		s.reply(331, "Password required")
+	case "PASS":
+		s.login(argument)
+	case "QUIT":
+		s.reply(221, "Goodbye")
+		return false
+	case "NOOP":
+		s.reply(200, "OK")
+	case "SYST":
+		s.reply(215, "UNIX Type: L8")
+	case "FEAT":
+		s.conn.SetWriteDeadline(time.Now().Add(ftpIdleTimeout))
+		s.text.PrintfLine("211-Features:\r\n EPSV\r\n PASV\r\n UTF8\r\n211 End")
+	case "OPTS":
+		s.reply(200, "OK")
+	default:
+		if s.user == nil {
+			s.reply(530, "Not logged in")
+			return true
+		}
+		s.handleLoggedIn(command, argument)
+	}
+	return true
+}
+
+func (s *ftpSession) handleLoggedIn(command string, argument string) {
+	switch command {
+	case "TYPE":
+		switch strings.ToUpper(strings.TrimSpace(argument)) {
+		case "A", "A N", "I", "L 8":
+			s.reply(200, "Type set to "+argument)
+		default:
+			s.reply(504, "Type not supported")
+		}
+	case "MODE":
+		if strings.EqualFold(argument, "S") {
+			s.reply(200, "Mode set to S")
+		} else {
+			s.reply(504, "Mode not supported")
+		}
+	case "STRU":
+		if strings.EqualFold(argument, "F") {
+			s.reply(200, "Structure set to F")
+		} else {
+			s.reply(504, "Structure not supported")
+		}
+	case "PWD", "XPWD":
+		s.reply(257, `"/" is the current directory`)
+	case "CWD", "XCWD", "CDUP":
+		// The directory is flat, so a path in the URL a gateway is configured
+		// with still ends up in the input directory of its user.
+		s.reply(250, "Directory changed to /")
+	case "PASV":
+		s.handlePassive(false)
+	case "EPSV":
+		s.handlePassive(true)
+	case "PORT":
+		s.handlePort(argument)
+	case "EPRT":
+		s.handleExtendedPort(argument)
+	case "STOR":
+		s.store(argument)
+	default:
+		s.reply(502, "Command not implemented")
+	}
+}
+
+func (s *ftpSession) login(password string) {
+	if s.username == "" {
+		s.reply(503, "Login with USER first")
+		return
+	}
+
+	user, ok := s.server.users[s.username]
+	if !ok || subtle.ConstantTimeCompare([]byte(user.config.Password), []byte(password)) != 1 {
+		logger.Error("FTP login failed for %s from %s", s.username, s.conn.RemoteAddr())
+		s.reply(530, "Login incorrect")
+		return
+	}
+
+	s.user = user
+	logger.Info("FTP user %s connected from %s", s.username, s.conn.RemoteAddr())
+	s.reply(230, "Logged in")
+}
+
+// localPath maps an FTP path onto a file in the input directory of the user.
+// Only the name of the file is kept, and it must follow the naming convention
+// of gw-accounting files.
+func (s *ftpSession) localPath(ftpPath string) (string, error) {
+	name := path.Base(path.Clean("/" + ftpPath))
+	if name == "/" || helpers.IsPartialUpload(name) {
+		return "", fmt.Errorf("invalid file name %s", ftpPath)
+	}
+	if !helpers.IsCUBEFilename(name) {
+		return "", fmt.Errorf("file name %s does not match Filename.Hostname.Timestamp", name)
+	}
+	return filepath.Join(s.user.directory.Input, name), nil
+}
+
+func (s *ftpSession) store(ftpPath string) {
+	localPath, err := s.localPath(ftpPath)
+	if err != nil {
+		logger.Error("Rejected upload from FTP user %s Error: %s", s.username, err)
+		s.reply(553, "File name not allowed")
+		return
+	}
+
+	// Write to a hidden file first, so the parser never sees a partial upload.
+	partPath := filepath.Join(filepath.Dir(localPath), "."+filepath.Base(localPath)+".part")
+	file, err := os.OpenFile(partPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		logger.Error("Error creating file: %s Error: %s", partPath, err)
+		s.reply(451, "Local error")
+		return
+	}
+
+	s.reply(150, "Opening data connection")
+
+	data, err := s.openDataConn()
+	if err != nil {
+		file.Close()
+		os.Remove(partPath)
+		logger.Error("FTP data connection failed for %s Error: %s", s.username, err)
+		s.reply(425, "Can't open data connection")
+		return
+	}
+
+	stopWatching := s.watchControl(data)
+	_, err = io.Copy(file, &idleTimeoutReader{conn: data})
+	data.Close()
+	aborted := stopWatching()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || aborted {
+		os.Remove(partPath)
+		if aborted {
+			err = errors.New("transfer aborted by client")
+		}
+		logger.Error("Error receiving file: %s Error: %s", localPath, err)
+		s.reply(426, "Transfer aborted")
+		if aborted {
+			s.reply(226, "ABOR command successful")
+		}
+		return
+	}
+
+	if err := os.Rename(partPath, localPath); err != nil {
+		os.Remove(partPath)
+		logger.Error("Error moving file: %s Error: %s", partPath, err)
+		s.reply(451, "Local error")
+		return
+	}
+
+	s.reply(226, "Transfer complete")
+	s.received(localPath)
+}
+
+// received parses a file once it is complete in the input directory.
+func (s *ftpSession) received(localPath string) {
+	logger.Info("Received file %s from FTP user %s", localPath, s.username)
+	go s.server.parse(localPath, s.user.directory)
+}
+
+// watchControl reads the control connection while a file is received over
+// data, since the end of the data connection alone does not tell a complete
+// upload from one the client gave up on. An ABOR command or a closed control
+// connection closes data. The returned function stops watching and reports
+// whether the transfer was aborted.
+func (s *ftpSession) watchControl(data net.Conn) func() bool {
+	aborted := make(chan bool, 1)
+	s.conn.SetReadDeadline(time.Time{})
+
+	go func() {
+		for {
+			line, err := s.text.ReadLine()
+			if err != nil {
+				var netErr net.Error
+				stopped := errors.As(err, &netErr) && netErr.Timeout()
+				if !stopped {
+					data.Close()
+				}
+				aborted <- !stopped
+				return
+			}
+			// ABOR may be preceded by the Telnet Interrupt Process and
+			// Synch sequences.
+			command, _, _ := strings.Cut(strings.TrimLeft(line, "\xff\xf4\xf2"), " ")
+			if strings.EqualFold(command, "ABOR") {
+				data.Close()
+				aborted <- true
+				return
+			}
+			s.reply(503, "Transfer in progress")
+		}
+	}()
+
+	return func() bool {
+		s.conn.SetReadDeadline(time.Now())
+		return <-aborted
+	}
+}
+
+func (s *ftpSession) handlePassive(extended bool) {
+	s.closePassive()
+	s.activeAddr = ""
+
+	localIP := s.conn.LocalAddr().(*net.TCPAddr).IP
+	publicIP := s.server.publicIP
+	if publicIP == nil {
+		publicIP = localIP.To4()
+	}
+	if !extended && publicIP == nil {
+		s.reply(425, "Use EPSV for IPv6")
+		return
+	}
+
+	listener, err := s.listenPassive(localIP)
+	if err != nil {
+		logger.Error("Error opening passive FTP port Error: %s", err)
+		s.reply(425, "Can't open passive connection")
+		return
+	}
+	s.passive = listener
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	if extended {
+		s.reply(229, fmt.Sprintf("Entering Extended Passive Mode (|||%d|)", port))
+		return
+	}
+	s.reply(227, fmt.Sprintf("Entering Passive Mode (%d,%d,%d,%d,%d,%d)", publicIP[0], publicIP[1], publicIP[2], publicIP[3], port/256, port%256))
+}
+
+// listenPassive listens on a free port of the configured passive range, or on
+// any free port if no range is configured.
+func (s *ftpSession) listenPassive(ip net.IP) (*net.TCPListener, error) {
+	if s.server.passiveMin == 0 {
+		return net.ListenTCP("tcp", &net.TCPAddr{IP: ip})
+	}
+
+	count := s.server.passiveMax - s.server.passiveMin + 1
+	offset := rand.Intn(count)
+	var err error
+	for i := 0; i < count; i++ {
+		port := s.server.passiveMin + (offset+i)%count
+		var listener *net.TCPListener
+		listener, err = net.ListenTCP("tcp", &net.TCPAddr{IP: ip, Port: port})
+		if err == nil {
+			return listener, nil
+		}
+	}
+	return nil, fmt.Errorf("no free port in passive range: %w", err)
+}
+
+// handlePort sets up an active data connection from a PORT h1,h2,h3,h4,p1,p2
+// command.
+func (s *ftpSession) handlePort(argument string) {
+	fields := strings.Split(argument, ",")
+	if len(fields) != 6 {
+		s.reply(501, "Invalid PORT command")
+		return
+	}
+	values := make([]int, 6)
+	for i, field := range fields {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || value < 0 || value > 255 {
+			s.reply(501, "Invalid PORT command")
+			return
+		}
+		values[i] = value
+	}
+	ip := net.IPv4(byte(values[0]), byte(values[1]), byte(values[2]), byte(values[3]))
+	s.setActive(ip, values[4]*256+values[5])
+}
+
+// handleExtendedPort sets up an active data connection from an EPRT
+// |protocol|address|port| command.
+func (s *ftpSession) handleExtendedPort(argument string) {
+	if argument == "" {
+		s.reply(501, "Invalid EPRT command")
+		return
+	}
+	fields := strings.Split(argument, argument[:1])
+	if len(fields) != 5 {
+		s.reply(501, "Invalid EPRT command")
+		return
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.Atoi(fields[3])
+	if ip == nil || err != nil {
+		s.reply(501, "Invalid EPRT command")
+		return
+	}
+	s.setActive(ip, port)
+}
+
+// setActive records the address to connect to for the next transfer. Only the
+// host of the control connection may be used, so the server cannot be made to
+// connect anywhere else.
+func (s *ftpSession) setActive(ip net.IP, port int) {
+	s.closePassive()
+	s.activeAddr = ""
+
+	remoteIP := s.conn.RemoteAddr().(*net.TCPAddr).IP
+	if !ip.Equal(remoteIP) || port < 1 || port > 65535 {
+		s.reply(501, "Data connection must be to the client host")
+		return
+	}
+
+	s.activeAddr = net.JoinHostPort(ip.String(), strconv.Itoa(port))
+	s.reply(200, "PORT command successful")
+}
+
+// openDataConn opens the data connection set up by the last PASV, EPSV, PORT
+// or EPRT command. Each can be used for a single transfer.
+func (s *ftpSession) openDataConn() (net.Conn, error) {
+	if s.passive != nil {
+		listener := s.passive
+		s.passive = nil
+		defer listener.Close()
+
+		listener.SetDeadline(time.Now().Add(ftpDataTimeout))
+		conn, err := listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		remoteIP := s.conn.RemoteAddr().(*net.TCPAddr).IP
+		if !conn.RemoteAddr().(*net.TCPAddr).IP.Equal(remoteIP) {
+			conn.Close()
+			return nil, fmt.Errorf("data connection from %s does not match control connection from %s", conn.RemoteAddr(), remoteIP)
+		}
+		return conn, nil
+	}
+
+	if s.activeAddr != "" {
+		addr := s.activeAddr
+		s.activeAddr = ""
+		return net.DialTimeout("tcp", addr, ftpDataTimeout)
+	}
+
+	return nil, errors.New("no PASV, EPSV, PORT or EPRT command was sent")
+}
+
+func (s *ftpSession) closePassive() {
+	if s.passive != nil {
+		s.passive.Close()
+		s.passive = nil
+	}
+}
+
+// idleTimeoutReader reads from a data connection, failing if no data arrives
+// for longer than ftpIdleTimeout.
+type idleTimeoutReader struct {
+	conn net.Conn
+}
+
+func (r *idleTimeoutReader) Read(p []byte) (int, error) {
+	r.conn.SetReadDeadline(time.Now().Add(ftpIdleTimeout))
+	return r.conn.Read(p)
+}
@@ -0,0 +1,324 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package receiver
+
+import (
+	"fmt"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ziondials/go-cdr/config"
+)
+
+const ftpTestFile = "cdr.gw1.01_16_2023_09_51_17.583"
+
+// startFTPServer runs an FTP server with a single user on a random port and
+// returns its address, the input directory of the user and the files handed
+// to the parser.
+func startFTPServer(t *testing.T) (string, string, <-chan string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	if err := os.Mkdir(input, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	directories := []config.DirectoryConfig{{Input: input, Output: filepath.Join(dir, "output"), Type: "cube"}}
+	ftpConfig := &config.FTPConfig{
+		Users: []config.FTPUserConfig{{Username: "cube", Password: "secret", Input: input}},
+	}
+
+	server, err := NewFTPServer(ftpConfig, directories, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 1)
+	server.parse = func(inputFile string, directory config.DirectoryConfig) {
+		received <- inputFile
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go server.Serve(listener)
+
+	return listener.Addr().String(), input, received
+}
+
+// ftpClient speaks just enough FTP to upload a file.
+type ftpClient struct {
+	t    *testing.T
+	conn net.Conn
+	text *textproto.Conn
+}
+
+func dialFTP(t *testing.T, address string) *ftpClient {
+	t.Helper()
+
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	client := &ftpClient{t: t, conn: conn, text: textproto.NewConn(conn)}
+	t.Cleanup(func() { client.text.Close() })
+
+	client.expect(220)
+	return client
+}
+
+// command sends a command and returns the message of the reply, which must
+// have the given code.
+func (c *ftpClient) command(code int, format string, args ...any) string {
+	c.t.Helper()
+
+	if err := c.text.PrintfLine(format, args...); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.expect(code)
+}
+
+func (c *ftpClient) expect(code int) string {
+	c.t.Helper()
+
+	_, message, err := c.text.ReadResponse(code)
+	if err != nil {
+		c.t.Fatalf("got %v, want reply %d", err, code)
+	}
+	return message
+}
+
+func (c *ftpClient) login(user string, password string) {
+	c.t.Helper()
+
+	c.command(331, "USER %s", user)
+	c.command(230, "PASS %s", password)
+}
+
+// passive sets up a passive data connection with PASV or EPSV and returns the
+// address to connect to.
+func (c *ftpClient) passive(extended bool) string {
+	c.t.Helper()
+
+	if extended {
+		message := c.command(229, "EPSV")
+		start, end := strings.Index(message, "(|||"), strings.LastIndex(message, "|)")
+		if start < 0 || end < start {
+			c.t.Fatalf("invalid EPSV reply %s", message)
+		}
+		host, _, _ := net.SplitHostPort(c.conn.RemoteAddr().String())
+		return net.JoinHostPort(host, message[start+4:end])
+	}
+
+	message := c.command(227, "PASV")
+	start, end := strings.Index(message, "("), strings.Index(message, ")")
+	if start < 0 || end < start {
+		c.t.Fatalf("invalid PASV reply %s", message)
+	}
+	fields := strings.Split(message[start+1:end], ",")
+	if len(fields) != 6 {
+		c.t.Fatalf("invalid PASV reply %s", message)
+	}
+	high, _ := strconv.Atoi(fields[4])
+	low, _ := strconv.Atoi(fields[5])
+	return net.JoinHostPort(strings.Join(fields[:4], "."), strconv.Itoa(high*256+low))
+}
+
+// startStore sends STOR for name and returns the opened data connection.
+func (c *ftpClient) startStore(extended bool, name string) net.Conn {
+	c.t.Helper()
+
+	address := c.passive(extended)
+	c.command(150, "STOR %s", name)
+	data, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return data
+}
+
+// waitForEmpty fails unless the directory is empty within a few seconds.
+func waitForEmpty(t *testing.T, dir string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s still has %s", dir, entries[0].Name())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFTPUpload(t *testing.T) {
+
+	for _, extended := range []bool{false, true} {
+		t.Run(fmt.Sprintf("extended=%t", extended), func(t *testing.T) {
+
+			address, input, received := startFTPServer(t)
+
+			client := dialFTP(t, address)
+			client.login("cube", "secret")
+			client.command(200, "TYPE I")
+
+			content := []byte("1,2,3\n")
+			data := client.startStore(extended, "/"+ftpTestFile)
+			if _, err := data.Write(content); err != nil {
+				t.Fatal(err)
+			}
+			data.Close()
+			client.expect(226)
+
+			want := filepath.Join(input, ftpTestFile)
+			select {
+			case got := <-received:
+				if got != want {
+					t.Errorf("parsed %s, want %s", got, want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("uploaded file was not handed to the parser")
+			}
+
+			got, err := os.ReadFile(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(content) {
+				t.Errorf("uploaded file has %q, want %q", got, content)
+			}
+			client.command(221, "QUIT")
+		})
+	}
+}
+
+func TestFTPRejectsBadCredentials(t *testing.T) {
+
+	address, _, _ := startFTPServer(t)
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+	}{
+		{"wrong password", "cube", "wrong"},
+		{"unknown user", "nobody", "secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			client := dialFTP(t, address)
+			client.command(331, "USER %s", tt.user)
+			client.command(530, "PASS %s", tt.password)
+			client.command(530, "PASV")
+			client.command(530, "STOR %s", ftpTestFile)
+		})
+	}
+}
+
+func TestFTPFileNames(t *testing.T) {
+
+	address, input, received := startFTPServer(t)
+
+	client := dialFTP(t, address)
+	client.login("cube", "secret")
+
+	// Only the name of the file is kept, so a path cannot leave the input
+	// directory.
+	data := client.startStore(false, "../../"+ftpTestFile)
+	data.Close()
+	client.expect(226)
+	select {
+	case got := <-received:
+		if want := filepath.Join(input, ftpTestFile); got != want {
+			t.Errorf("parsed %s, want %s", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("uploaded file was not handed to the parser")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(filepath.Dir(input)), ftpTestFile)); !os.IsNotExist(err) {
+		t.Errorf("upload escaped the input directory: %v", err)
+	}
+
+	for _, name := range []string{"/", "cdr.csv", "." + ftpTestFile, "." + ftpTestFile + ".part"} {
+		client.passive(false)
+		client.command(553, "STOR %s", name)
+	}
+	select {
+	case got := <-received:
+		t.Errorf("rejected upload %s was handed to the parser", got)
+	default:
+	}
+}
+
+// A transfer that the client aborts, or whose control connection is dropped,
+// is deleted rather than moved into place and parsed.
+func TestFTPDiscardsAbortedTransfer(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		abort func(client *ftpClient, data net.Conn)
+	}{
+		{"ABOR", func(client *ftpClient, data net.Conn) {
+			client.command(426, "ABOR")
+			client.expect(226)
+			data.Close()
+		}},
+		{"dropped control connection", func(client *ftpClient, data net.Conn) {
+			client.text.Close()
+			time.Sleep(50 * time.Millisecond)
+			data.Close()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			address, input, received := startFTPServer(t)
+
+			client := dialFTP(t, address)
+			client.login("cube", "secret")
+
+			data := client.startStore(true, ftpTestFile)
+			if _, err := data.Write([]byte("1,2,3\n")); err != nil {
+				t.Fatal(err)
+			}
+			tt.abort(client, data)
+
+			waitForEmpty(t, input)
+			select {
+			case got := <-received:
+				t.Errorf("aborted upload %s was handed to the parser", got)
+			case <-time.After(100 * time.Millisecond):
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package receiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/dialplan"
+	"github.com/ziondials/go-cdr/fraud"
+	"github.com/ziondials/go-cdr/helpers"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
+	"go.uber.org/zap"
+	"layeh.com/radius"
+	"layeh.com/radius/rfc2866"
+)
+
+// RADIUSServer receives the callhistory-detail records of CUBE gateways as
+// RADIUS Accounting Start and Stop requests. Each request is decoded into a
+// models.RawCubeCDR, parsed like a row of a gw-accounting file and written to
+// the database straight away.
+//
+// A request is only acknowledged once its CDR has been written, so the gateway
+// sends it again if the database is unavailable.
+type RADIUSServer struct {
+	db      *database.DataService
+	clients []*radiusClient
+}
+
+type radiusClient struct {
+	network *net.IPNet
+	secret  []byte
+}
+
+// RunRADIUSServer runs the RADIUS server from the global config until it
+// fails.
+func RunRADIUSServer() {
+
+	db := database.Connect()
+
+	radiusConfig := config.GetRADIUSFromGlobalConfig()
+
+	server, err := NewRADIUSServer(radiusConfig, db)
+	if err != nil {
+		logger.Fatal("RADIUS Server Error: %s", err)
+	}
+
+	conn, err := net.ListenPacket("udp", radiusConfig.Listen)
+	if err != nil {
+		logger.Fatal("RADIUS Server Error: %s", err)
+	}
+	logger.Info("RADIUS server listening on %s", conn.LocalAddr())
+
+	if err := server.Serve(conn); err != nil {
+		logger.Fatal("RADIUS Server Error: %s", err)
+	}
+}
+
+// NewRADIUSServer creates a RADIUS server for the configured clients.
+func NewRADIUSServer(radiusConfig *config.RADIUSConfig, db *database.DataService) (*RADIUSServer, error) {
+
+	if len(radiusConfig.Clients) == 0 {
+		return nil, errors.New("no radius clients configured")
+	}
+
+	server := &RADIUSServer{db: db}
+
+	for _, clientConfig := range radiusConfig.Clients {
+		if clientConfig.Secret == "" {
+			return nil, fmt.Errorf("radius client %s has no secret", clientConfig.Address)
+		}
+
+		network, err := helpers.ParseIPNetwork(clientConfig.Address)
+		if err != nil {
+			return nil, fmt.Errorf("radius client: %w", err)
+		}
+
+		server.clients = append(server.clients, &radiusClient{network: network, secret: []byte(clientConfig.Secret)})
+	}
+
+	return server, nil
+}
+
+// Serve handles requests received on conn until it is closed.
+func (s *RADIUSServer) Serve(conn net.PacketConn) error {
+	packetServer := &radius.PacketServer{
+		SecretSource: s,
+		Handler:      radius.HandlerFunc(s.handle),
+		ErrorLog:     zap.NewStdLog(logger.Logger),
+	}
+
+	err := packetServer.Serve(conn)
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// RADIUSSecret returns the shared secret of the client at remoteAddr. Requests
+// from addresses that are not configured are dropped.
+func (s *RADIUSServer) RADIUSSecret(ctx context.Context, remoteAddr net.Addr) ([]byte, error) {
+	if udpAddr, ok := remoteAddr.(*net.UDPAddr); ok {
+		for _, client := range s.clients {
+			if client.network.Contains(udpAddr.IP) {
+				return client.secret, nil
+			}
+		}
+	}
+	logger.Error("RADIUS request from unknown client %s", remoteAddr)
+	return nil, nil
+}
+
+func (s *RADIUSServer) handle(w radius.ResponseWriter, r *radius.Request) {
+	if r.Code != radius.CodeAccountingRequest {
+		logger.Error("Unexpected RADIUS %s from %s", r.Code, r.RemoteAddr)
+		return
+	}
+
+	switch rfc2866.AcctStatusType_Get(r.Packet) {
+	case rfc2866.AcctStatusType_Value_Start, rfc2866.AcctStatusType_Value_Stop:
+		if !s.writeCDR(r) {
+			// No response, so the gateway sends the request again.
+			return
+		}
+	default:
+		// Interim-Update, Accounting-On and Accounting-Off carry no call
+		// history and are only acknowledged.
+	}
+
+	if err := w.Write(r.Response(radius.CodeAccountingResponse)); err != nil {
+		logger.Error("Error sending RADIUS response to %s Error: %s", r.RemoteAddr, err)
+	}
+}
+
+// writeCDR writes the CDR carried by an accounting request to the database and
+// reports whether the request can be acknowledged. Requests that cannot be
+// parsed or whose CDR has already been written are acknowledged as well, since
+// sending them again would not help.
+func (s *RADIUSServer) writeCDR(r *radius.Request) bool {
+	source := "RADIUS accounting from " + r.RemoteAddr.String()
+
+	raw := decodeCubeAccounting(r.Packet, r.RemoteAddr)
+	cdr, err := raw.Parse(source, dialplan.DialPlan)
+	if err != nil {
+		logger.Error("Error parsing CDR: %s %s", source, err)
+		return true
+	}
+
+	var alerts []*models.Alert
+	err = s.db.Transaction(func(tx database.DataService) error {
+		cdrs := []*models.CubeCDR{cdr}
+		if err := tx.SaveCubeCDRs(cdrs); err != nil {
+			return err
+		}
+		raised, err := fraud.CheckCubeCDRs(tx, cdrs)
+		if err != nil {
+			return err
+		}
+		alerts, err = tx.SaveAlerts(raised)
+		return err
+	})
+	if errors.Is(err, database.ErrConflict) {
+		// The gateway sent the request again because the response was lost.
+		logger.Info("CDR from %s has already been written: %s", source, err)
+		return true
+	}
+	if err != nil {
+		logger.Error("Error while writing to database: %s", err.Error())
+		return false
+	}
+	fraud.Notify(alerts)
+
+	logger.Debug("Successfully wrote CDR to database from %s", source)
+	return true
+}
@@ -0,0 +1,314 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package receiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/dialplan"
+	"github.com/ziondials/go-cdr/fraud"
+	"github.com/ziondials/go-cdr/models"
+	"layeh.com/radius"
+	"layeh.com/radius/rfc2865"
+	"layeh.com/radius/rfc2866"
+)
+
+const radiusSecret = "secret"
+
+// newTestDataService opens a migrated SQLite database in a temporary
+// directory.
+func newTestDataService(t *testing.T) *database.DataService {
+	t.Helper()
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("database.driver", "sqlite")
+	viper.Set("database.path", filepath.Join(t.TempDir(), "go-cdr.db"))
+	viper.Set("database.autoMigrate", true)
+	viper.Set("database.conflictPolicy", "skip")
+	viper.Set("database.limit", 100)
+
+	db, err := database.InitDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.Session.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// startRADIUSServer runs a RADIUS server for clients on the loopback address on
+// a random port and returns its address.
+func startRADIUSServer(t *testing.T, db *database.DataService) string {
+	t.Helper()
+
+	server, err := NewRADIUSServer(&config.RADIUSConfig{
+		Clients: []config.RADIUSClientConfig{{Address: "127.0.0.1", Secret: radiusSecret}},
+	}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go server.Serve(conn)
+
+	return conn.LocalAddr().String()
+}
+
+// ciscoVSA adds a Cisco vendor-specific attribute of the form name=value.
+func ciscoVSA(t *testing.T, packet *radius.Packet, vendorType byte, value string) {
+	t.Helper()
+
+	vsa := append([]byte{vendorType, byte(len(value) + 2)}, value...)
+	attribute, err := radius.NewVendorSpecific(ciscoVendorID, vsa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet.Add(rfc2865.VendorSpecific_Type, attribute)
+}
+
+// newStopRequest builds the Accounting Stop request a CUBE sends for the
+// outgoing VoIP leg of a call.
+func newStopRequest(t *testing.T, secret string) *radius.Packet {
+	t.Helper()
+
+	packet := radius.New(radius.CodeAccountingRequest, []byte(secret))
+	if err := rfc2866.AcctStatusType_Set(packet, rfc2866.AcctStatusType_Value_Stop); err != nil {
+		t.Fatal(err)
+	}
+	if err := rfc2866.AcctSessionID_SetString(packet, "1A2B"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rfc2865.CallingStationID_SetString(packet, "5551001"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rfc2865.CalledStationID_SetString(packet, "5552002"); err != nil {
+		t.Fatal(err)
+	}
+	ciscoVSA(t, packet, 33, "h323-gw-id=cube01.")
+	ciscoVSA(t, packet, 24, "h323-conf-id=4F3C2A10 12345678 9ABCDEF0 11223344")
+	ciscoVSA(t, packet, 27, "h323-call-type=VoIP")
+	ciscoVSA(t, packet, 26, "h323-call-origin=originate")
+	ciscoVSA(t, packet, 25, "h323-setup-time=10:00:00.000 UTC Mon Jan 1 2024")
+	ciscoVSA(t, packet, 28, "h323-connect-time=10:00:05.000 UTC Mon Jan 1 2024")
+	ciscoVSA(t, packet, 29, "h323-disconnect-time=10:01:05.000 UTC Mon Jan 1 2024")
+	ciscoVSA(t, packet, 30, "h323-disconnect-cause=10")
+	ciscoVSA(t, packet, 1, "peer-address=5552002")
+	ciscoVSA(t, packet, 1, "session-protocol=sipv2")
+	ciscoVSA(t, packet, 1, "feature-vsa=fn:TWC,ft:01/01/2024 10:00:30.000,frs:0")
+	return packet
+}
+
+func TestRADIUSAccounting(t *testing.T) {
+
+	db := newTestDataService(t)
+	address := startRADIUSServer(t, db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := radius.Exchange(ctx, newStopRequest(t, radiusSecret), address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Code != radius.CodeAccountingResponse {
+		t.Fatalf("got %s, want %s", response.Code, radius.CodeAccountingResponse)
+	}
+
+	// The request is only acknowledged once its CDR has been written.
+	var cdrs []*models.CubeCDR
+	if err := db.Session.Find(&cdrs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(cdrs) != 1 {
+		t.Fatalf("got %d CDRs, want 1", len(cdrs))
+	}
+	cdr := cdrs[0]
+
+	assertString(t, "Hostname", cdr.Hostname, "cube01")
+	assertString(t, "H323ConfId", cdr.H323ConfId, "4F3C2A10 12345678 9ABCDEF0 11223344")
+	assertString(t, "Clid", cdr.Clid, "5551001")
+	assertString(t, "Dnis", cdr.Dnis, "5552002")
+	assertString(t, "PeerAddress", cdr.PeerAddress, "5552002")
+	assertString(t, "H323DisconnectCause", cdr.H323DisconnectCause, "10")
+	assertString(t, "SessionProtocol", cdr.SessionProtocol, "sipv2")
+	assertString(t, "FeatureIdField1", cdr.FeatureIdField1, "TWC")
+	assertInt64(t, "CallId", cdr.CallId, 0x1A2B)
+	assertInt64(t, "CdrType", cdr.CdrType, int64(rfc2866.AcctStatusType_Value_Stop))
+	assertInt64(t, "H323SetupTime", cdr.H323SetupTime, 1704103200)
+	if cdr.LegType == nil || *cdr.LegType != models.CubeLegTypeVoIP {
+		t.Errorf("LegType is %v, want %d", cdr.LegType, models.CubeLegTypeVoIP)
+	}
+}
+
+// A request whose CDR has already been written, e.g. because the gateway did
+// not receive the first response, is acknowledged again rather than sent
+// forever.
+func TestRADIUSAcknowledgesDuplicate(t *testing.T) {
+
+	db := newTestDataService(t)
+	db.Config.ConflictPolicy = database.ConflictPolicyFail
+	address := startRADIUSServer(t, db)
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		response, err := radius.Exchange(ctx, newStopRequest(t, radiusSecret), address)
+		cancel()
+		if err != nil {
+			t.Fatalf("request %d: %s", i+1, err)
+		}
+		if response.Code != radius.CodeAccountingResponse {
+			t.Fatalf("request %d: got %s, want %s", i+1, response.Code, radius.CodeAccountingResponse)
+		}
+	}
+
+	var count int64
+	if err := db.Session.Model(&models.CubeCDR{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got %d CDRs, want 1", count)
+	}
+}
+
+// Unanswered attempts count as 0 second calls towards a burst, and the peer
+// of the answered leg is its peer address.
+func TestRADIUSFraudUnansweredAttempts(t *testing.T) {
+
+	db := newTestDataService(t)
+	address := startRADIUSServer(t, db)
+
+	plan, err := dialplan.NewPlan(&config.DialPlanConfig{CountryCode: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := fraud.NewEngine(&config.FraudConfig{Rules: []config.FraudRuleConfig{
+		{Name: "burst", Type: models.AlertTypeBurst, Prefixes: []string{"+44"}, Count: 3, MaxDuration: 10, Window: 5},
+		{Name: "peer", Type: models.AlertTypeUnexpectedPeer, Peers: []string{"192.0.2.0/24"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialplan.DialPlan, fraud.FraudEngine = plan, engine
+	t.Cleanup(func() { dialplan.DialPlan, fraud.FraudEngine = nil, nil })
+
+	for i := 0; i < 3; i++ {
+		packet := radius.New(radius.CodeAccountingRequest, []byte(radiusSecret))
+		if err := rfc2866.AcctStatusType_Set(packet, rfc2866.AcctStatusType_Value_Stop); err != nil {
+			t.Fatal(err)
+		}
+		if err := rfc2866.AcctSessionID_SetString(packet, fmt.Sprintf("%X", 0x100+i)); err != nil {
+			t.Fatal(err)
+		}
+		if err := rfc2865.CallingStationID_SetString(packet, "5551001"); err != nil {
+			t.Fatal(err)
+		}
+		if err := rfc2865.CalledStationID_SetString(packet, "+442071234567"); err != nil {
+			t.Fatal(err)
+		}
+		ciscoVSA(t, packet, 33, "h323-gw-id=cube01.")
+		ciscoVSA(t, packet, 24, fmt.Sprintf("h323-conf-id=4F3C2A10 12345678 9ABCDEF0 0000000%d", i))
+		ciscoVSA(t, packet, 27, "h323-call-type=VoIP")
+		ciscoVSA(t, packet, 26, "h323-call-origin=answer")
+		ciscoVSA(t, packet, 25, fmt.Sprintf("h323-setup-time=10:0%d:00.000 UTC Mon Jan 1 2024", i))
+		ciscoVSA(t, packet, 29, fmt.Sprintf("h323-disconnect-time=10:0%d:20.000 UTC Mon Jan 1 2024", i))
+		ciscoVSA(t, packet, 30, "h323-disconnect-cause=13")
+		ciscoVSA(t, packet, 1, "gw-rxd-cdn=ton:1,npi:1,#:+442071234567")
+		ciscoVSA(t, packet, 1, "peer-address=198.51.100.7")
+		ciscoVSA(t, packet, 1, "remote-media-address=192.0.2.10")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		response, err := radius.Exchange(ctx, packet, address)
+		cancel()
+		if err != nil {
+			t.Fatalf("request %d: %s", i+1, err)
+		}
+		if response.Code != radius.CodeAccountingResponse {
+			t.Fatalf("request %d: got %s, want %s", i+1, response.Code, radius.CodeAccountingResponse)
+		}
+	}
+
+	var alerts []*models.Alert
+	if err := db.Session.Order("call_time").Find(&alerts).Error; err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, alert := range alerts {
+		counts[*alert.Rule]++
+		if *alert.Rule == "peer" {
+			assertString(t, "PeerAddress", alert.PeerAddress, "198.51.100.7")
+		}
+	}
+	if counts["burst"] != 1 {
+		t.Errorf("got %d burst alerts, want 1 for the third attempt", counts["burst"])
+	}
+	if counts["peer"] != 3 {
+		t.Errorf("got %d peer alerts, want 3", counts["peer"])
+	}
+}
+
+func TestRADIUSRejectsWrongSecret(t *testing.T) {
+
+	db := newTestDataService(t)
+	address := startRADIUSServer(t, db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	// A request signed with the wrong secret is dropped without a response.
+	_, err := radius.Exchange(ctx, newStopRequest(t, "wrong"), address)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want no response", err)
+	}
+
+	var count int64
+	if err := db.Session.Model(&models.CubeCDR{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("got %d CDRs, want 0", count)
+	}
+}
+
+func assertString(t *testing.T, name string, got *string, want string) {
+	t.Helper()
+	if got == nil || *got != want {
+		t.Errorf("%s is %v, want %q", name, got, want)
+	}
+}
+
+func assertInt64(t *testing.T, name string, got *int64, want int64) {
+	t.Helper()
+	if got == nil || *got != want {
+		t.Errorf("%s is %v, want %d", name, got, want)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package receiver contains the embedded servers that CDR sources push
+// records to directly, instead of to a separate server whose directory is
+// then polled by the parser.
+package receiver
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/parser"
+)
+
+// findDirectory returns the DirectoryConfig whose input directory is input,
+// which must be of the given type.
+func findDirectory(directories []config.DirectoryConfig, input string, fileType string) (config.DirectoryConfig, error) {
+	for _, directory := range directories {
+		if filepath.Clean(directory.Input) != filepath.Clean(input) {
+			continue
+		}
+		if directory.Type != fileType {
+			return directory, fmt.Errorf("directory %s has type %s instead of %s", input, directory.Type, fileType)
+		}
+		return directory, nil
+	}
+	return config.DirectoryConfig{}, fmt.Errorf("directory %s is not configured as a parser directory", input)
+}
+
+// parseReceivedFile hands a file that has been received completely to the
+// parser of its directory.
+func parseReceivedFile(inputFile string, directory config.DirectoryConfig, db *database.DataService) {
+	if _, err := parser.ParseFile(inputFile, directory.Output, directory.Type, directory.DeleteOriginal, db); err != nil {
+		logger.Error("Error while parsing file: %s Error: %s", inputFile, err)
+	}
+}
@@ -0,0 +1,428 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package receiver
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"github.com/ziondials/go-cdr/config"
+	"github.com/ziondials/go-cdr/database"
+	"github.com/ziondials/go-cdr/logger"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTPServer accepts CDR and CMR uploads from CUCM Billing Servers. Every user
+// writes into the input directory of one cucm DirectoryConfig, and each file is
+// parsed as soon as its upload completes.
+type SFTPServer struct {
+	sshConfig *ssh.ServerConfig
+	users     map[string]*sftpUser
+	// parse hands a file that has been uploaded completely to the parser.
+	parse func(inputFile string, directory config.DirectoryConfig)
+}
+
+type sftpUser struct {
+	config    config.SFTPUserConfig
+	directory config.DirectoryConfig
+	publicKey ssh.PublicKey
+}
+
+// RunSFTPServer runs the SFTP server from the global config until it fails.
+func RunSFTPServer() {
+
+	db := database.Connect()
+
+	sftpConfig := config.GetSFTPFromGlobalConfig()
+
+	server, err := NewSFTPServer(sftpConfig, config.GetDirectoriesFromGlobalConfig(), db)
+	if err != nil {
+		logger.Fatal("SFTP Server Error: %s", err)
+	}
+
+	listener, err := net.Listen("tcp", sftpConfig.Listen)
+	if err != nil {
+		logger.Fatal("SFTP Server Error: %s", err)
+	}
+	logger.Info("SFTP server listening on %s", listener.Addr())
+
+	if err := server.Serve(listener); err != nil {
+		logger.Fatal("SFTP Server Error: %s", err)
+	}
+}
+
+// NewSFTPServer creates an SFTP server for the configured users. The host key
+// is generated and saved to sftpConfig.HostKey if it does not exist yet.
+func NewSFTPServer(sftpConfig *config.SFTPConfig, directories []config.DirectoryConfig, db *database.DataService) (*SFTPServer, error) {
+
+	if len(sftpConfig.Users) == 0 {
+		return nil, errors.New("no sftp users configured")
+	}
+
+	server := &SFTPServer{
+		users: map[string]*sftpUser{},
+		parse: func(inputFile string, directory config.DirectoryConfig) {
+			parseReceivedFile(inputFile, directory, db)
+		},
+	}
+
+	for _, userConfig := range sftpConfig.Users {
+		if userConfig.Password == "" && userConfig.PublicKey == "" {
+			return nil, fmt.Errorf("sftp user %s has neither a password nor a public key", userConfig.Username)
+		}
+
+		directory, err := findDirectory(directories, userConfig.Input, "cucm")
+		if err != nil {
+			return nil, fmt.Errorf("sftp user %s: %w", userConfig.Username, err)
+		}
+
+		user := &sftpUser{config: userConfig, directory: directory}
+		if userConfig.PublicKey != "" {
+			user.publicKey, _, _, _, err = ssh.ParseAuthorizedKey([]byte(userConfig.PublicKey))
+			if err != nil {
+				return nil, fmt.Errorf("sftp user %s: invalid public key: %w", userConfig.Username, err)
+			}
+		}
+		server.users[userConfig.Username] = user
+	}
+
+	hostKey, err := loadOrCreateHostKey(sftpConfig.HostKey)
+	if err != nil {
+		return nil, err
+	}
+
+	server.sshConfig = &ssh.ServerConfig{
+		PasswordCallback:  server.checkPassword,
+		PublicKeyCallback: server.checkPublicKey,
+	}
+	server.sshConfig.AddHostKey(hostKey)
+
+	return server, nil
+}
+
+// Serve accepts connections on listener until it is closed.
+func (s *SFTPServer) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *SFTPServer) checkPassword(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	user, ok := s.users[conn.User()]
+	if ok && user.config.Password != "" && subtle.ConstantTimeCompare([]byte(user.config.Password), password) == 1 {
+		return nil, nil
+	}
+	logger.Error("SFTP login failed for %s from %s", conn.User(), conn.RemoteAddr())
+	return nil, errors.New("invalid username or password")
+}
+
+func (s *SFTPServer) checkPublicKey(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	user, ok := s.users[conn.User()]
+	if ok && user.publicKey != nil && bytes.Equal(user.publicKey.Marshal(), key.Marshal()) {
+		return nil, nil
+	}
+	return nil, errors.New("invalid username or public key")
+}
+
+func (s *SFTPServer) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	sshConn, channels, requests, err := ssh.NewServerConn(conn, s.sshConfig)
+	if err != nil {
+		logger.Error("SFTP handshake failed from %s: %s", conn.RemoteAddr(), err)
+		return
+	}
+	defer sshConn.Close()
+
+	logger.Info("SFTP user %s connected from %s", sshConn.User(), sshConn.RemoteAddr())
+
+	go ssh.DiscardRequests(requests)
+
+	user := s.users[sshConn.User()]
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			logger.Error("SFTP channel error for %s: %s", sshConn.User(), err)
+			continue
+		}
+		go s.handleSession(user, channel, requests)
+	}
+}
+
+// handleSession serves the sftp subsystem on a session channel. Shells and
+// commands are refused.
+func (s *SFTPServer) handleSession(user *sftpUser, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	for request := range requests {
+		ok := request.Type == "subsystem" && isSFTPSubsystem(request.Payload)
+		request.Reply(ok, nil)
+		if !ok {
+			continue
+		}
+
+		handler := &sftpHandler{server: s, user: user}
+		server := sftp.NewRequestServer(channel, sftp.Handlers{
+			FileGet:  handler,
+			FilePut:  handler,
+			FileCmd:  handler,
+			FileList: handler,
+		})
+		if err := server.Serve(); err != nil && err != io.EOF {
+			logger.Error("SFTP session error for %s: %s", user.config.Username, err)
+		}
+		server.Close()
+		return
+	}
+}
+
+func isSFTPSubsystem(payload []byte) bool {
+	if len(payload) < 4 {
+		return false
+	}
+	length := binary.BigEndian.Uint32(payload)
+	return uint32(len(payload)-4) >= length && string(payload[4:4+length]) == "sftp"
+}
+
+// sftpHandler serves the input directory of a single user. The directory is
+// flat and write-only: files can be uploaded, renamed, removed and listed, but
+// not downloaded.
+type sftpHandler struct {
+	server *SFTPServer
+	user   *sftpUser
+}
+
+// localPath maps an SFTP path onto a file in the input directory of the user.
+func (h *sftpHandler) localPath(sftpPath string) (string, error) {
+	clean := path.Clean("/" + sftpPath)
+	name := path.Base(clean)
+	if path.Dir(clean) != "/" || clean == "/" || strings.HasPrefix(name, ".") {
+		return "", sftp.ErrSSHFxPermissionDenied
+	}
+	return filepath.Join(h.user.directory.Input, name), nil
+}
+
+func (h *sftpHandler) Fileread(request *sftp.Request) (io.ReaderAt, error) {
+	return nil, sftp.ErrSSHFxPermissionDenied
+}
+
+func (h *sftpHandler) Filewrite(request *sftp.Request) (io.WriterAt, error) {
+	localPath, err := h.localPath(request.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write to a hidden file first, so the parser never sees a partial upload.
+	partPath := filepath.Join(filepath.Dir(localPath), "."+filepath.Base(localPath)+".part")
+	file, err := os.OpenFile(partPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		logger.Error("Error creating file: %s Error: %s", partPath, err)
+		return nil, sftp.ErrSSHFxFailure
+	}
+
+	return &sftpUpload{File: file, handler: h, partPath: partPath, localPath: localPath}, nil
+}
+
+func (h *sftpHandler) Filecmd(request *sftp.Request) error {
+	switch request.Method {
+	case "Setstat":
+		return nil
+	case "Rename":
+		source, err := h.localPath(request.Filepath)
+		if err != nil {
+			return err
+		}
+		target, err := h.localPath(request.Target)
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(source, target); err != nil {
+			return sftp.ErrSSHFxFailure
+		}
+		h.received(target)
+		return nil
+	case "Remove":
+		localPath, err := h.localPath(request.Filepath)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(localPath); err != nil {
+			return sftp.ErrSSHFxNoSuchFile
+		}
+		return nil
+	default:
+		return sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (h *sftpHandler) Filelist(request *sftp.Request) (sftp.ListerAt, error) {
+	switch request.Method {
+	case "List":
+		entries, err := os.ReadDir(h.user.directory.Input)
+		if err != nil {
+			return nil, sftp.ErrSSHFxFailure
+		}
+		files := sftpListerAt{}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			if info, err := entry.Info(); err == nil {
+				files = append(files, info)
+			}
+		}
+		return files, nil
+	case "Stat", "Lstat":
+		if path.Clean("/"+request.Filepath) == "/" {
+			info, err := os.Stat(h.user.directory.Input)
+			if err != nil {
+				return nil, sftp.ErrSSHFxFailure
+			}
+			return sftpListerAt{info}, nil
+		}
+		localPath, err := h.localPath(request.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return nil, sftp.ErrSSHFxNoSuchFile
+		}
+		return sftpListerAt{info}, nil
+	default:
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+// received parses a file once it is complete in the input directory.
+func (h *sftpHandler) received(localPath string) {
+	logger.Info("Received file %s from SFTP user %s", localPath, h.user.config.Username)
+	go h.server.parse(localPath, h.user.directory)
+}
+
+// sftpUpload is a file being uploaded. It is moved into place and parsed when
+// the client closes it, or deleted when the transfer failed, e.g. because the
+// connection was dropped.
+type sftpUpload struct {
+	*os.File
+	handler   *sftpHandler
+	partPath  string
+	localPath string
+
+	mu          sync.Mutex
+	transferErr error
+}
+
+var _ sftp.TransferError = (*sftpUpload)(nil)
+
+// TransferError is called by the SFTP server before Close when the transfer
+// did not complete.
+func (u *sftpUpload) TransferError(err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.transferErr = err
+}
+
+func (u *sftpUpload) Close() error {
+	u.mu.Lock()
+	transferErr := u.transferErr
+	u.mu.Unlock()
+
+	if err := u.File.Close(); err != nil {
+		return err
+	}
+	if transferErr != nil {
+		logger.Error("Discarding incomplete upload: %s from SFTP user %s Error: %s", u.localPath, u.handler.user.config.Username, transferErr)
+		if err := os.Remove(u.partPath); err != nil {
+			logger.Error("Error removing file: %s Error: %s", u.partPath, err)
+		}
+		return transferErr
+	}
+	if err := os.Rename(u.partPath, u.localPath); err != nil {
+		logger.Error("Error moving file: %s Error: %s", u.partPath, err)
+		return err
+	}
+	u.handler.received(u.localPath)
+	return nil
+}
+
+type sftpListerAt []os.FileInfo
+
+func (l sftpListerAt) ListAt(files []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(files, l[offset:])
+	if n < len(files) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// loadOrCreateHostKey reads the SSH host key at path, generating a new
+// ed25519 key there if the file does not exist.
+func loadOrCreateHostKey(keyPath string) (ssh.Signer, error) {
+	pemBytes, err := os.ReadFile(keyPath)
+	if err == nil {
+		return ssh.ParsePrivateKey(pemBytes)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyPath), os.ModePerm); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600); err != nil {
+		return nil, err
+	}
+	logger.Info("Generated SFTP host key: %s", keyPath)
+
+	return ssh.NewSignerFromKey(privateKey)
+}
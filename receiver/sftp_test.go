@@ -0,0 +1,204 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package receiver
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/ziondials/go-cdr/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// startSFTPServer runs an SFTP server with a single password user on a random
+// port and returns its address, the input directory of the user and the files
+// handed to the parser.
+func startSFTPServer(t *testing.T) (string, string, <-chan string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	if err := os.Mkdir(input, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	directories := []config.DirectoryConfig{{Input: input, Output: filepath.Join(dir, "output"), Type: "cucm"}}
+	sftpConfig := &config.SFTPConfig{
+		HostKey: filepath.Join(dir, "host_key"),
+		Users:   []config.SFTPUserConfig{{Username: "cucm", Password: "secret", Input: input}},
+	}
+
+	server, err := NewSFTPServer(sftpConfig, directories, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 1)
+	server.parse = func(inputFile string, directory config.DirectoryConfig) {
+		received <- inputFile
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go server.Serve(listener)
+
+	return listener.Addr().String(), input, received
+}
+
+func dialSFTP(address string, user string, password string) (*ssh.Client, error) {
+	return ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.Password(password)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+}
+
+func TestSFTPUpload(t *testing.T) {
+
+	address, input, received := startSFTPServer(t)
+
+	sshClient, err := dialSFTP(address, "cucm", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sshClient.Close()
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	content := []byte("cdrRecordType,globalCallID_callManagerId\n")
+	file, err := client.Create("/cdr_StandAloneCluster_01_202401011000_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := filepath.Join(input, "cdr_StandAloneCluster_01_202401011000_1")
+	select {
+	case got := <-received:
+		if got != want {
+			t.Errorf("parsed %s, want %s", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("uploaded file was not handed to the parser")
+	}
+
+	got, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(content) {
+		t.Errorf("uploaded file has %q, want %q", got, content)
+	}
+
+	// The partial upload has been moved into place.
+	entries, err := os.ReadDir(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("input directory has %d files, want 1", len(entries))
+	}
+}
+
+// An upload whose connection is dropped before the file is closed is deleted
+// rather than moved into place and parsed.
+func TestSFTPDiscardsAbortedUpload(t *testing.T) {
+
+	address, input, received := startSFTPServer(t)
+
+	sshClient, err := dialSFTP(address, "cucm", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := client.Create("/cdr_StandAloneCluster_01_202401011000_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte("cdrRecordType,globalCallID_callManagerId\n")); err != nil {
+		t.Fatal(err)
+	}
+	// The partial upload is in the input directory until the server notices
+	// that the connection is gone.
+	if entries, err := os.ReadDir(input); err != nil || len(entries) != 1 {
+		t.Fatalf("input directory has %d files, want the partial upload: %v", len(entries), err)
+	}
+	sshClient.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entries, err := os.ReadDir(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("input directory still has %s", entries[0].Name())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case got := <-received:
+		t.Errorf("aborted upload %s was handed to the parser", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSFTPRejectsBadCredentials(t *testing.T) {
+
+	address, _, _ := startSFTPServer(t)
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+	}{
+		{"wrong password", "cucm", "wrong"},
+		{"unknown user", "unknown", "secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sshClient, err := dialSFTP(address, tt.user, tt.password)
+			if err == nil {
+				sshClient.Close()
+				t.Fatal("login succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Zion Dials <me@ziondials.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ziondials/go-cdr/logger"
+	"github.com/ziondials/go-cdr/models"
+)
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Notify posts alerts to url as a JSON array in the background. It should be
+// called once the alerts are committed, and does nothing if url is empty.
+func Notify(url string, alerts []*models.Alert) {
+	if url == "" || len(alerts) == 0 {
+		return
+	}
+
+	go func() {
+		if err := Post(url, alerts); err != nil {
+			logger.Error("Error posting %d alerts to webhook: %s", len(alerts), err)
+			return
+		}
+		logger.Debug("Successfully posted %d alerts to webhook", len(alerts))
+	}()
+}
+
+// Post posts alerts to url as a JSON array.
+func Post(url string, alerts []*models.Alert) error {
+
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", rsp.Status)
+	}
+	return nil
+}